@@ -4,7 +4,10 @@ import (
 	"encoding/base64"
 	"github.com/pkg/errors"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config interface {
@@ -13,6 +16,12 @@ type Config interface {
 	RedisUserConn() (string, string, int, error)
 	RedisPuzzleConn() (string, string, int, error)
 	PasswordPepper() ([]byte, error)
+	// PracticeTargets returns the strategy targets of the practice pools filled by the generator.
+	PracticeTargets() ([]StrategyTarget, error)
+	// GeneratorWorkers returns the number of goroutines generating puzzles at the same time.
+	GeneratorWorkers() (int, error)
+	// GeneratorAttemptTimeout returns the time after which the generation of one seed is abandoned.
+	GeneratorAttemptTimeout() (time.Duration, error)
 }
 
 type config struct{}
@@ -30,6 +39,9 @@ const (
 	envvarRedisUserDB       = "REDIS_USER_DB"
 	envvarRedisPuzzleDB     = "REDIS_PUZZLE_DB"
 	envvarPasswordPepper    = "PASSWORD_PEPPER"
+	envvarGeneratorPractice = "GENERATOR_PRACTICE"
+	envvarGeneratorWorkers  = "GENERATOR_WORKERS"
+	envvarGeneratorTimeout  = "GENERATOR_ATTEMPT_TIMEOUT"
 )
 
 func (c config) Debug() bool {
@@ -61,6 +73,56 @@ func (c config) PasswordPepper() ([]byte, error) {
 	return c.getBytes(envvarPasswordPepper)
 }
 
+// PracticeTargets parses the targets separated by ";" in the format of ParseStrategyTarget, e.g.
+// "x-wing;hidden triple:2,-pointing pair". No practice pools are filled if the envvar is not set.
+func (c config) PracticeTargets() ([]StrategyTarget, error) {
+	var targets []StrategyTarget
+	for _, s := range strings.Split(os.Getenv(envvarGeneratorPractice), ";") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		target, err := ParseStrategyTarget(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse '%s'", envvarGeneratorPractice)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// GeneratorWorkers parses the positive number of workers. The number of CPUs is used if the envvar is not set.
+func (c config) GeneratorWorkers() (int, error) {
+	s, ok := os.LookupEnv(envvarGeneratorWorkers)
+	if !ok {
+		return runtime.NumCPU(), nil
+	}
+	workers, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse '%s' as int", envvarGeneratorWorkers)
+	}
+	if workers < 1 {
+		return 0, errors.Errorf("envvar '%s' must be positive", envvarGeneratorWorkers)
+	}
+	return workers, nil
+}
+
+// GeneratorAttemptTimeout parses the positive duration, e.g. "10m". DefaultGeneratorAttemptTimeout is used if the
+// envvar is not set.
+func (c config) GeneratorAttemptTimeout() (time.Duration, error) {
+	s, ok := os.LookupEnv(envvarGeneratorTimeout)
+	if !ok {
+		return DefaultGeneratorAttemptTimeout, nil
+	}
+	timeout, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse '%s' as duration", envvarGeneratorTimeout)
+	}
+	if timeout <= 0 {
+		return 0, errors.Errorf("envvar '%s' must be positive", envvarGeneratorTimeout)
+	}
+	return timeout, nil
+}
+
 func (config) redisConn(envvarRedisDB string) (string, string, int, error) {
 	address, ok := os.LookupEnv(envvarRedisAddress)
 	if !ok {
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+type CreateCustomPuzzleGameParams struct {
+	Session *Session
+	Type    PuzzleType
+	CustomPuzzle
+}
+
+// CustomPuzzle is the puzzle entered by the user and checked by CheckCustomPuzzle.
+type CustomPuzzle struct {
+	Clues      string
+	Candidates string
+	Solution   string
+	Canonical  string
+	Symmetry   PuzzleSymmetry
+	// Rating is the level measured by the logic solver. It is empty if the solver gets stuck.
+	Rating PuzzleLevel
+}
+
+// CheckCustomPuzzle parses the puzzle typed or pasted by the user, checks that it has the unique solution and rates it
+// by the logic solver with all known strategies. The check of a sparse puzzle takes long, so it stops when the context
+// is done.
+//
+// Errors: ErrorPuzzleTypeUnknown, ErrorPuzzleInvalid, ErrorPuzzleNoSolution, ErrorPuzzleMultipleSolutions,
+// context.Canceled, context.DeadlineExceeded, unknown.
+func CheckCustomPuzzle(ctx context.Context, library PuzzleLibrary, typ PuzzleType, text string) (*CustomPuzzle, error) {
+	creator, err := library.GetCreator(typ)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	generator, err := creator.ParseClues(text)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	solutions, err := generator.FindSolutions(ctx, 2)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch len(solutions) {
+	case 0:
+		return nil, errors.WithStack(ErrorPuzzleNoSolution)
+	case 1:
+		custom := &CustomPuzzle{
+			Clues:      generator.String(),
+			Candidates: generator.GetCandidates(),
+			Solution:   solutions[0],
+			Canonical:  generator.Canonical(),
+			Symmetry:   generator.Symmetry(),
+		}
+		custom.Rating, err = RatePuzzle(ctx, generator)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return custom, nil
+	default:
+		return nil, errors.WithStack(ErrorPuzzleMultipleSolutions)
+	}
+}
+
+// RatePuzzle solves the puzzle with all known strategies and returns the level of the hardest strategy used. The level
+// is empty if the solver gets stuck. The puzzle is solved in place step by step, and the context is checked between
+// the steps.
+//
+// Errors: context.Canceled, context.DeadlineExceeded, unknown.
+func RatePuzzle(ctx context.Context, generator PuzzleGenerator) (PuzzleLevel, error) {
+	strategies := PuzzleLevelDemon.Strategies()
+	var used PuzzleStrategy
+	candidates := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return PuzzleLevelUnknown, errors.WithStack(err)
+		}
+		outcome, err := generator.SolveOneStep(candidates, strategies)
+		if err != nil {
+			return PuzzleLevelUnknown, errors.WithStack(err)
+		}
+		switch outcome.Status {
+		case SolveStatusProgress:
+			used |= outcome.Step.Strategy()
+			candidates = outcome.Candidates
+		case SolveStatusSolved:
+			return used.Level(), nil
+		default:
+			return PuzzleLevelUnknown, nil
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// DailyDateLayout is the layout of the date of daily puzzles.
+const DailyDateLayout = "2006-01-02"
+
+// DailyDate returns the date of daily puzzles at the moment t. The day changes at midnight UTC.
+func DailyDate(t time.Time) string {
+	return t.UTC().Format(DailyDateLayout)
+}
+
+// DailySeed returns the seed of the daily puzzle of the type and level for the date.
+// The seed is the same for all instances of the service.
+func DailySeed(date string, typ PuzzleType, level PuzzleLevel) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(date + ":" + typ.String() + ":" + level.String()))
+	return int64(h.Sum64() & math.MaxInt64)
+}
+
+type CreateDailyPuzzleGameParams struct {
+	Session *Session
+	// Date is the date of the daily puzzle in DailyDateLayout.
+	Date  string
+	Type  PuzzleType
+	Level PuzzleLevel
+}
+
+// DailyStreak is the number of days in a row with a won daily puzzle.
+type DailyStreak struct {
+	UserID int64 `json:"user_id" redis:"-"`
+	// Last is the date of the last won daily puzzle.
+	Last    string `json:"last" redis:"last"`
+	Current int    `json:"current" redis:"current"`
+	Best    int    `json:"best" redis:"best"`
+}
+
+// Add records the win of the daily puzzle of the date.
+// The second win at the same date and a win of an older daily puzzle do not change the streak.
+func (s *DailyStreak) Add(date string) {
+	if date <= s.Last {
+		return
+	}
+	if s.Last != "" && s.Last == dailyDateBefore(date) {
+		s.Current++
+	} else {
+		s.Current = 1
+	}
+	if s.Current > s.Best {
+		s.Best = s.Current
+	}
+	s.Last = date
+}
+
+// Actual returns the current streak at the date. The streak is kept for the day after the last win.
+func (s DailyStreak) Actual(date string) int {
+	if s.Last == date || s.Last == dailyDateBefore(date) {
+		return s.Current
+	}
+	return 0
+}
+
+func dailyDateBefore(date string) string {
+	t, err := time.Parse(DailyDateLayout, date)
+	if err != nil {
+		return ""
+	}
+	return t.AddDate(0, 0, -1).Format(DailyDateLayout)
+}
+
+// DailyLeaderboardEntry is a place in the leaderboard of the daily puzzle.
+type DailyLeaderboardEntry struct {
+	Rank   int   `json:"rank"`
+	UserID int64 `json:"user_id"`
+	// Username is not set by PuzzleRepository, the users are kept by UserRepository.
+	Username string        `json:"username"`
+	Elapsed  time.Duration `json:"elapsed"`
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyDate(t *testing.T) {
+	moment := time.Date(2026, 10, 19, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+	if got, want := DailyDate(moment), "2026-10-20"; got != want {
+		t.Errorf("DailyDate() got = %s, want = %s", got, want)
+	}
+}
+
+func TestDailySeed(t *testing.T) {
+	a := DailySeed("2026-10-19", PuzzleSudokuClassic, PuzzleLevelEasy)
+	if a < 0 {
+		t.Errorf("DailySeed() got negative seed %d", a)
+	}
+	if b := DailySeed("2026-10-19", PuzzleSudokuClassic, PuzzleLevelEasy); a != b {
+		t.Errorf("DailySeed() is not deterministic: %d != %d", a, b)
+	}
+	if b := DailySeed("2026-10-20", PuzzleSudokuClassic, PuzzleLevelEasy); a == b {
+		t.Errorf("DailySeed() is the same for different dates")
+	}
+	if b := DailySeed("2026-10-19", PuzzleSudokuClassic, PuzzleLevelNormal); a == b {
+		t.Errorf("DailySeed() is the same for different levels")
+	}
+}
+
+func TestDailyStreak(t *testing.T) {
+	tests := []struct {
+		name   string
+		streak DailyStreak
+		date   string
+		want   DailyStreak
+		// actual is the streak at the day after date.
+		actual int
+	}{
+		{
+			name:   "first",
+			date:   "2026-10-19",
+			want:   DailyStreak{Last: "2026-10-19", Current: 1, Best: 1},
+			actual: 1,
+		},
+		{
+			name:   "next day",
+			streak: DailyStreak{Last: "2026-10-18", Current: 2, Best: 2},
+			date:   "2026-10-19",
+			want:   DailyStreak{Last: "2026-10-19", Current: 3, Best: 3},
+			actual: 3,
+		},
+		{
+			name:   "next month",
+			streak: DailyStreak{Last: "2026-09-30", Current: 1, Best: 4},
+			date:   "2026-10-01",
+			want:   DailyStreak{Last: "2026-10-01", Current: 2, Best: 4},
+			actual: 2,
+		},
+		{
+			name:   "same day",
+			streak: DailyStreak{Last: "2026-10-19", Current: 2, Best: 2},
+			date:   "2026-10-19",
+			want:   DailyStreak{Last: "2026-10-19", Current: 2, Best: 2},
+			actual: 2,
+		},
+		{
+			name:   "older daily",
+			streak: DailyStreak{Last: "2026-10-19", Current: 2, Best: 2},
+			date:   "2026-10-17",
+			want:   DailyStreak{Last: "2026-10-19", Current: 2, Best: 2},
+			actual: 0,
+		},
+		{
+			name:   "broken",
+			streak: DailyStreak{Last: "2026-10-16", Current: 5, Best: 5},
+			date:   "2026-10-19",
+			want:   DailyStreak{Last: "2026-10-19", Current: 1, Best: 5},
+			actual: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.streak.Add(tt.date)
+			if tt.streak != tt.want {
+				t.Errorf("Add() got = %+v, want = %+v", tt.streak, tt.want)
+			}
+			day, _ := time.Parse(DailyDateLayout, tt.date)
+			next := day.AddDate(0, 0, 1).Format(DailyDateLayout)
+			if got := tt.streak.Actual(next); got != tt.actual {
+				t.Errorf("Actual() got = %d, want = %d", got, tt.actual)
+			}
+		})
+	}
+}
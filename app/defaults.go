@@ -1,5 +1,7 @@
 package app
 
+import "time"
+
 const (
 	DefaultPuzzleType = PuzzleSudokuClassic
 
@@ -7,17 +9,69 @@ const (
 
 	DefaultCandidatesAtStart = false
 
+	DefaultSymmetric = false
+
 	DefaultUseHighlights = false
 
 	DefaultShowCandidates = true
 
 	DefaultShowWrongs = false
+
+	// DefaultGameIdleTimeout is the time without activity after which the game
+	// timer stops counting.
+	DefaultGameIdleTimeout = 5 * time.Minute
+
+	// DefaultDailyLeaderboardSize is the number of places shown in the leaderboard of the daily puzzle.
+	DefaultDailyLeaderboardSize = 10
+
+	// DefaultLeaderboardSize is the number of places shown in leaderboards.
+	DefaultLeaderboardSize = 20
+
+	// DefaultRaceCountdown is the time between the start of the race by the owner and the start of the boards.
+	DefaultRaceCountdown = 5 * time.Second
+
+	// DefaultRaceMaxPlayers is the maximum number of players of a race.
+	DefaultRaceMaxPlayers = 8
+
+	// DefaultRaceRetention is how long a race is kept after it is created.
+	DefaultRaceRetention = 24 * time.Hour
+
+	// DefaultDrillRetention is how long a drill is kept after it is created.
+	DefaultDrillRetention = 24 * time.Hour
+
+	// DefaultCustomPuzzleCheckTimeout is the time limit of the search of the solutions and of the rating of one custom
+	// puzzle.
+	DefaultCustomPuzzleCheckTimeout = 5 * time.Second
+
+	// DefaultDrillSearchTimeout is the time limit of the search of the position of a drill in the random puzzles.
+	DefaultDrillSearchTimeout = 5 * time.Second
+
+	// DefaultGeneratorAttemptTimeout is the time after which the generator abandons one seed and tries the next one.
+	DefaultGeneratorAttemptTimeout = time.Minute
+
+	// DefaultGenerateJobClaimIdle is the time without a touch after which a generate job in progress is considered
+	// abandoned and is read again by another generator.
+	DefaultGenerateJobClaimIdle = 2 * time.Minute
+
+	// DefaultGenerateJobMaxDeliveries is the number of reads after which a generate job that is never done is dropped.
+	DefaultGenerateJobMaxDeliveries = 3
+
+	// DefaultGenerateJobTTL is how long a queued generate job blocks new jobs of its pool.
+	DefaultGenerateJobTTL = time.Hour
+
+	// DefaultRefillThreshold is the number of unsolved puzzles of the player below which the frontend asks the
+	// generator to refill the pool.
+	DefaultRefillThreshold = 2
+
+	// DefaultRefillPuzzles is the number of puzzles generated for one refill of the pool.
+	DefaultRefillPuzzles = 5
 )
 
 func (up *UserPreferences) Defaults() {
 	up.PuzzleType = DefaultPuzzleType
 	up.PuzzleLevel = DefaultPuzzleLevel
 	up.CandidatesAtStart = DefaultCandidatesAtStart
+	up.Symmetric = DefaultSymmetric
 	up.UseHighlights = DefaultUseHighlights
 	up.ShowCandidates = DefaultShowCandidates
 	up.ShowWrongs = DefaultShowWrongs
@@ -0,0 +1,245 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"sort"
+	"strconv"
+)
+
+var (
+	ErrorDrillNotFound = fmt.Errorf("drill not found")
+	ErrorDrillAnswered = fmt.Errorf("drill is already answered")
+)
+
+// DrillStrategies are the strategies offered for drills.
+var DrillStrategies = PuzzleLevelHarder.Strategies()
+
+type CreateDrillParams struct {
+	Session *Session
+	DrillPosition
+}
+
+// DrillPosition is a position in the middle of the logical solution of a generated puzzle where exactly one step of
+// the strategy makes progress. See FindDrills.
+type DrillPosition struct {
+	PuzzleID   int64          `json:"puzzle_id" redis:"puzzle_id"`
+	Type       PuzzleType     `json:"type" redis:"type"`
+	Strategy   PuzzleStrategy `json:"strategy" redis:"strategy"`
+	State      string         `json:"state" redis:"state"`
+	Candidates string         `json:"candidates" redis:"candidates"`
+	// Changes are PuzzleStep.CandidateChanges of the step of the strategy.
+	Changes     string `json:"-" redis:"changes"`
+	Description string `json:"-" redis:"description"`
+	// Answer is the move expected from the player.
+	Answer DrillAnswer `json:"-" redis:"answer"`
+}
+
+// Drill is the position shown to the player to find the step of the strategy.
+type Drill struct {
+	ID        uuid.UUID `json:"id" redis:"-"`
+	SessionID int64     `json:"-" redis:"session_id"`
+	UserID    int64     `json:"-" redis:"user_id"`
+	DrillPosition
+	CreatedAt DateTime `json:"created_at" redis:"created_at"`
+	// Answers is the number of answers of the player. Only the first answer is counted in DrillStats.
+	Answers int  `json:"answers" redis:"answers"`
+	Correct bool `json:"correct" redis:"correct"`
+}
+
+// ValidateSession checks that the session is the owner of the drill.
+//
+// Errors: ErrorPuzzleGameNotAllowed.
+func (d *Drill) ValidateSession(session *Session) error {
+	if d.UserID > 0 {
+		if d.UserID != session.UserID {
+			return ErrorPuzzleGameNotAllowed
+		}
+	} else {
+		if d.SessionID != session.SessionID {
+			return ErrorPuzzleGameNotAllowed
+		}
+	}
+	return nil
+}
+
+// DrillAnswer is the move of the player at the position of the drill: the digit to set for the singles or the
+// candidates to delete for other strategies.
+type DrillAnswer struct {
+	Set    *StepPlacement   `json:"set,omitempty"`
+	Delete []StepCandidates `json:"del,omitempty"`
+}
+
+// NewDrillAnswer returns the answer of the step: the placement of the step or the candidates deleted by its
+// PuzzleStep.CandidateChanges.
+//
+// Errors: unknown.
+func NewDrillAnswer(step PuzzleStep) (DrillAnswer, error) {
+	if placements := step.Payload().Placements; len(placements) > 0 {
+		return DrillAnswer{Set: &placements[0]}, nil
+	}
+	var changes struct {
+		Delete map[string][]int8 `json:"del"`
+	}
+	if err := json.Unmarshal([]byte(step.CandidateChanges()), &changes); err != nil {
+		return DrillAnswer{}, errors.Wrap(err, "failed to decode candidate changes")
+	}
+	var answer DrillAnswer
+	for pointStr, digits := range changes.Delete {
+		point, err := PointFromString(pointStr)
+		if err != nil {
+			return DrillAnswer{}, errors.WithStack(err)
+		}
+		answer.Delete = append(answer.Delete, StepCandidates{Point: point, Digits: digits})
+	}
+	return answer, nil
+}
+
+// Equal reports whether the answers set the same digit or delete the same candidates in any order.
+func (a DrillAnswer) Equal(b DrillAnswer) bool {
+	if a.Set != nil || b.Set != nil {
+		return a.Set != nil && b.Set != nil && *a.Set == *b.Set
+	}
+	keys := func(answer DrillAnswer) []string {
+		set := make(map[string]bool)
+		for _, candidates := range answer.Delete {
+			for _, digit := range candidates.Digits {
+				set[fmt.Sprintf("%s:%d", candidates.Point, digit)] = true
+			}
+		}
+		out := make([]string, 0, len(set))
+		for key := range set {
+			out = append(out, key)
+		}
+		sort.Strings(out)
+		return out
+	}
+	aKeys, bKeys := keys(a), keys(b)
+	if len(aKeys) != len(bKeys) {
+		return false
+	}
+	for i := range aKeys {
+		if aKeys[i] != bKeys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (a DrillAnswer) RedisArg() interface{} {
+	bts, _ := json.Marshal(a)
+	return string(bts)
+}
+
+func (a *DrillAnswer) RedisScan(src interface{}) error {
+	if a == nil {
+		return fmt.Errorf("nil pointer")
+	}
+	switch src := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(src), a)
+	case []uint8:
+		return json.Unmarshal(src, a)
+	default:
+		return fmt.Errorf("cannot convert from %T to %T", src, a)
+	}
+}
+
+func (i PuzzleStrategy) RedisArg() interface{} {
+	return uint64(i)
+}
+
+func (i *PuzzleStrategy) RedisScan(src interface{}) error {
+	if i == nil {
+		return fmt.Errorf("nil pointer")
+	}
+	var str string
+	switch src := src.(type) {
+	case string:
+		str = src
+	case []uint8:
+		str = string(src)
+	default:
+		return fmt.Errorf("cannot convert from %T to %T", src, i)
+	}
+	n, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid puzzle strategy %q", str)
+	}
+	*i = PuzzleStrategy(n)
+	return nil
+}
+
+// FindDrills walks the logical solution of the puzzle from its clues and returns the positions where the strategy
+// makes the step: no easier strategy applies, and the position has exactly one step of the strategy, so the player
+// has only one answer. The context is checked between the steps.
+//
+// Errors: ErrorPuzzleTypeUnknown, context.Canceled, context.DeadlineExceeded, unknown.
+func FindDrills(ctx context.Context, library PuzzleLibrary, puzzle *Puzzle, strategy PuzzleStrategy) ([]DrillPosition, error) {
+	assistant, err := library.GetAssistant(puzzle.Type, puzzle.Clues)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	strategies := PuzzleLevelDemon.Strategies()
+	candidates := puzzle.Candidates
+	var drills []DrillPosition
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		state := assistant.String()
+		outcome, err := assistant.SolveOneStep(candidates, strategies)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if outcome.Status != SolveStatusProgress {
+			return drills, nil
+		}
+		// the solver tries the easiest strategy first, so the step of the strategy means no easier one applies
+		if outcome.Step.Strategy() == strategy {
+			position, err := library.GetAssistant(puzzle.Type, state)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			count, err := position.CountSteps(candidates, strategy)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if count == 1 {
+				answer, err := NewDrillAnswer(outcome.Step)
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				drills = append(drills, DrillPosition{
+					PuzzleID:    puzzle.ID,
+					Type:        puzzle.Type,
+					Strategy:    strategy,
+					State:       state,
+					Candidates:  candidates,
+					Changes:     outcome.Step.CandidateChanges(),
+					Description: outcome.Step.Description(),
+					Answer:      answer,
+				})
+			}
+		}
+		candidates = outcome.Candidates
+	}
+}
+
+// DrillStats is the accuracy of the user in the drills of one strategy.
+type DrillStats struct {
+	Strategy PuzzleStrategy `json:"strategy"`
+	Attempts int            `json:"attempts"`
+	Correct  int            `json:"correct"`
+}
+
+// Accuracy returns the share of correct answers in percent.
+func (s DrillStats) Accuracy() int {
+	if s.Attempts == 0 {
+		return 0
+	}
+	return s.Correct * 100 / s.Attempts
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDrillAnswer_Equal(t *testing.T) {
+	answer := DrillAnswer{Delete: []StepCandidates{
+		{Point: Point{Row: 0, Col: 3}, Digits: []int8{2, 5}},
+		{Point: Point{Row: 4, Col: 3}, Digits: []int8{5}},
+	}}
+	tests := []struct {
+		name string
+		a, b DrillAnswer
+		want bool
+	}{
+		{
+			name: "same set",
+			a:    DrillAnswer{Set: &StepPlacement{Point: Point{Row: 2, Col: 7}, Digit: 6}},
+			b:    DrillAnswer{Set: &StepPlacement{Point: Point{Row: 2, Col: 7}, Digit: 6}},
+			want: true,
+		},
+		{
+			name: "other digit",
+			a:    DrillAnswer{Set: &StepPlacement{Point: Point{Row: 2, Col: 7}, Digit: 6}},
+			b:    DrillAnswer{Set: &StepPlacement{Point: Point{Row: 2, Col: 7}, Digit: 5}},
+		},
+		{
+			name: "set and delete",
+			a:    DrillAnswer{Set: &StepPlacement{Point: Point{Row: 2, Col: 7}, Digit: 6}},
+			b:    answer,
+		},
+		{
+			name: "delete in other order",
+			a:    answer,
+			b: DrillAnswer{Delete: []StepCandidates{
+				{Point: Point{Row: 4, Col: 3}, Digits: []int8{5}},
+				{Point: Point{Row: 0, Col: 3}, Digits: []int8{5, 2}},
+			}},
+			want: true,
+		},
+		{
+			name: "delete less",
+			a:    answer,
+			b:    DrillAnswer{Delete: []StepCandidates{{Point: Point{Row: 0, Col: 3}, Digits: []int8{2, 5}}}},
+		},
+		{name: "empty", a: answer, b: DrillAnswer{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.want {
+				t.Errorf("Equal() = %t, want = %t", got, tt.want)
+			}
+			if got := tt.b.Equal(tt.a); got != tt.want {
+				t.Errorf("Equal() reversed = %t, want = %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrillAnswer_Redis(t *testing.T) {
+	for _, answer := range []DrillAnswer{
+		{Set: &StepPlacement{Point: Point{Row: 8, Col: 0}, Digit: 9}},
+		{Delete: []StepCandidates{{Point: Point{Row: 1, Col: 1}, Digits: []int8{3, 4}}}},
+	} {
+		var got DrillAnswer
+		if err := got.RedisScan([]byte(answer.RedisArg().(string))); err != nil {
+			t.Fatalf("RedisScan() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, answer) {
+			t.Errorf("RedisScan() = %+v, want = %+v", got, answer)
+		}
+	}
+}
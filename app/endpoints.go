@@ -15,7 +15,17 @@ const (
 	EndpointSignup              = "/signup"
 	EndpointLogout              = "/logout"
 	EndpointSettings            = "/settings"
+	EndpointStats               = "/stats"
+	EndpointStatsJSON           = "/stats.json"
+	EndpointDaily               = "/daily"
+	EndpointLeaderboards        = "/leaderboards"
+	EndpointDrill               = "/drill"
+	endpointDrillIDPattern      = "/drill/%s"
 	endpointGameIDPattern       = "/game/%s"
+	endpointGameReplayPattern   = "/game/%s/replay"
+	endpointGameJoinPattern     = "/game/%s/join/%s"
+	endpointGameWatchPattern    = "/game/%s/watch"
+	endpointRacePattern         = "/race/%s"
 	EndpointGameWs              = "/game_ws"
 )
 
@@ -40,3 +50,97 @@ func (EndpointGameID) MuxParse(r *http.Request) (uuid.UUID, error) {
 	}
 	return gameID, nil
 }
+
+type EndpointGameReplay struct{}
+
+func (EndpointGameReplay) Path(gameID uuid.UUID) string {
+	return fmt.Sprintf(endpointGameReplayPattern, gameID.String())
+}
+
+func (EndpointGameReplay) MuxPath() string {
+	return fmt.Sprintf(endpointGameReplayPattern, "{game_id}")
+}
+
+func (EndpointGameReplay) MuxParse(r *http.Request) (uuid.UUID, error) {
+	return EndpointGameID{}.MuxParse(r)
+}
+
+type EndpointGameWatch struct{}
+
+func (EndpointGameWatch) Path(gameID uuid.UUID) string {
+	return fmt.Sprintf(endpointGameWatchPattern, gameID.String())
+}
+
+func (EndpointGameWatch) MuxPath() string {
+	return fmt.Sprintf(endpointGameWatchPattern, "{game_id}")
+}
+
+func (EndpointGameWatch) MuxParse(r *http.Request) (uuid.UUID, error) {
+	return EndpointGameID{}.MuxParse(r)
+}
+
+type EndpointGameJoin struct{}
+
+func (EndpointGameJoin) Path(gameID uuid.UUID, code string) string {
+	return fmt.Sprintf(endpointGameJoinPattern, gameID.String(), code)
+}
+
+func (EndpointGameJoin) MuxPath() string {
+	return fmt.Sprintf(endpointGameJoinPattern, "{game_id}", "{code}")
+}
+
+func (EndpointGameJoin) MuxParse(r *http.Request) (uuid.UUID, string, error) {
+	gameID, err := EndpointGameID{}.MuxParse(r)
+	if err != nil {
+		return uuid.UUID{}, "", errors.WithStack(err)
+	}
+	code, ok := mux.Vars(r)["code"]
+	if !ok || code == "" {
+		return uuid.UUID{}, "", errors.Errorf("code not found")
+	}
+	return gameID, code, nil
+}
+
+type EndpointRace struct{}
+
+func (EndpointRace) Path(raceID uuid.UUID) string {
+	return fmt.Sprintf(endpointRacePattern, raceID.String())
+}
+
+func (EndpointRace) MuxPath() string {
+	return fmt.Sprintf(endpointRacePattern, "{race_id}")
+}
+
+func (EndpointRace) MuxParse(r *http.Request) (uuid.UUID, error) {
+	raceIDStr, ok := mux.Vars(r)["race_id"]
+	if !ok {
+		return uuid.UUID{}, errors.Errorf("race_id not found")
+	}
+	raceID, err := uuid.Parse(raceIDStr)
+	if err != nil {
+		return uuid.UUID{}, errors.Wrap(err, "race_id is not uuid")
+	}
+	return raceID, nil
+}
+
+type EndpointDrillID struct{}
+
+func (EndpointDrillID) Path(drillID uuid.UUID) string {
+	return fmt.Sprintf(endpointDrillIDPattern, drillID.String())
+}
+
+func (EndpointDrillID) MuxPath() string {
+	return fmt.Sprintf(endpointDrillIDPattern, "{drill_id}")
+}
+
+func (EndpointDrillID) MuxParse(r *http.Request) (uuid.UUID, error) {
+	drillIDStr, ok := mux.Vars(r)["drill_id"]
+	if !ok {
+		return uuid.UUID{}, errors.Errorf("drill_id not found")
+	}
+	drillID, err := uuid.Parse(drillIDStr)
+	if err != nil {
+		return uuid.UUID{}, errors.Wrap(err, "drill_id is not uuid")
+	}
+	return drillID, nil
+}
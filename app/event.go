@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// EventRepository fans out events between all instances of the frontend.
+type EventRepository interface {
+	// PublishEvent sends the event to all instances subscribed to its topic.
+	//
+	// Errors: unknown.
+	PublishEvent(ctx context.Context, event Event) error
+
+	// SubscribeEvents opens a subscription of the instance. The subscription survives reconnects to the data store
+	// and keeps its topics.
+	//
+	// Errors: unknown.
+	SubscribeEvents(ctx context.Context) (EventSubscription, error)
+}
+
+// EventSubscription receives the events of its topics until it is closed.
+type EventSubscription interface {
+	// Errors: unknown.
+	Subscribe(topics ...string) error
+	// Errors: unknown.
+	Unsubscribe(topics ...string) error
+	// Events returns the received events. The channel is closed when the subscription is closed.
+	Events() <-chan Event
+	Close() error
+}
+
+// Event is a message pushed to the websocket connections subscribed to the topic.
+type Event struct {
+	Topic string `json:"topic"`
+	// Name is the name of the event for the client, e.g. "gameUpdate".
+	Name string          `json:"name"`
+	Body json.RawMessage `json:"body"`
+	// Except is the identifier of the connection that must not receive the event.
+	Except string `json:"except,omitempty"`
+}
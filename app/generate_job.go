@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrorGenerateJobNone is returned when no job is queued before the timeout of the read.
+	ErrorGenerateJobNone = fmt.Errorf("no generate job")
+	// ErrorGenerateJobQueued rejects the job of the pool that already has a queued job.
+	ErrorGenerateJobQueued = fmt.Errorf("generate job of the pool is already queued")
+)
+
+// Reasons of GenerateJob.
+const (
+	GenerateJobReasonEmpty    = "empty"
+	GenerateJobReasonLow      = "low"
+	GenerateJobReasonSchedule = "schedule"
+)
+
+// GenerateJob is the request to the generator to add puzzles to the pool of the type and level or to the practice
+// pool of the strategy target.
+type GenerateJob struct {
+	// ID is the identifier of the job in the queue.
+	ID    string      `json:"id" redis:"-"`
+	Type  PuzzleType  `json:"type" redis:"type"`
+	Level PuzzleLevel `json:"level" redis:"level"`
+	// Practice is the StrategyTarget of the practice pool. Level is ignored if it is set.
+	Practice string `json:"practice,omitempty" redis:"practice"`
+	// Count is the number of puzzles to generate.
+	Count     int      `json:"count" redis:"count"`
+	Reason    string   `json:"reason" redis:"reason"`
+	CreatedAt DateTime `json:"created_at" redis:"created_at"`
+	// Deliveries is the number of times the job was read by the generators, including the current read.
+	Deliveries int `json:"deliveries" redis:"-"`
+	// Done is the number of puzzles generated by the previous deliveries of the job.
+	Done int `json:"done" redis:"-"`
+}
+
+// Pool returns the name of the pool of the job. Only one job of a pool is queued at a time.
+func (j GenerateJob) Pool() string {
+	if j.Practice != "" {
+		return fmt.Sprintf("%s:practice:%s", j.Type, j.Practice)
+	}
+	return fmt.Sprintf("%s:%s", j.Type, j.Level)
+}
+
+// GenerateJobRepository is the queue of the generate jobs shared by the frontends and the generators. A read job is
+// acknowledged after it is done. A job that is not acknowledged for DefaultGenerateJobClaimIdle, because its
+// generator is stopped or its process is killed, is read again by any generator.
+type GenerateJobRepository interface {
+	// PushGenerateJob queues the job unless a job of its pool is queued or in progress.
+	//
+	// Errors: ErrorGenerateJobQueued, unknown.
+	PushGenerateJob(ctx context.Context, job GenerateJob) (*GenerateJob, error)
+
+	// ReadGenerateJob returns the next job for the consumer. The jobs abandoned by other consumers are returned first
+	// with the progress of their previous deliveries. It waits for a new job up to the timeout.
+	//
+	// Errors: ErrorGenerateJobNone, unknown.
+	ReadGenerateJob(ctx context.Context, consumer string, timeout time.Duration) (*GenerateJob, error)
+
+	// TouchGenerateJob tells other consumers that the job is still in progress, so it is not read again.
+	//
+	// Errors: unknown.
+	TouchGenerateJob(ctx context.Context, consumer string, job *GenerateJob) error
+
+	// AddGenerateJobDone adds the number of the puzzles generated by the job, so a retry of the job generates only the
+	// missing puzzles.
+	//
+	// Errors: unknown.
+	AddGenerateJobDone(ctx context.Context, job *GenerateJob, done int) error
+
+	// AckGenerateJob removes the done job from the queue. A new job of its pool can be queued then.
+	//
+	// Errors: unknown.
+	AckGenerateJob(ctx context.Context, job *GenerateJob) error
+}
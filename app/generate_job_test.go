@@ -0,0 +1,29 @@
+package app
+
+import "testing"
+
+func TestGenerateJob_Pool(t *testing.T) {
+	tests := []struct {
+		name string
+		job  GenerateJob
+		want string
+	}{
+		{
+			name: "level",
+			job:  GenerateJob{Type: PuzzleSudokuClassic, Level: PuzzleLevelHard, Count: 5},
+			want: "sudoku_classic:hard",
+		},
+		{
+			name: "practice",
+			job:  GenerateJob{Type: PuzzleSudokuClassic, Level: PuzzleLevelHard, Practice: "xwing"},
+			want: "sudoku_classic:practice:xwing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.job.Pool(); got != tt.want {
+				t.Errorf("Pool() = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
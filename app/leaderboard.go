@@ -0,0 +1,98 @@
+package app
+
+import (
+	"fmt"
+	"time"
+)
+
+// LeaderboardKind is what the leaderboard ranks.
+type LeaderboardKind string
+
+const (
+	// LeaderboardPuzzle ranks the fastest solves of one puzzle.
+	LeaderboardPuzzle LeaderboardKind = "puzzle"
+	// LeaderboardLevel ranks the fastest solves of the type and level in the period.
+	LeaderboardLevel LeaderboardKind = "level"
+	// LeaderboardSolved ranks the numbers of solved puzzles.
+	LeaderboardSolved LeaderboardKind = "solved"
+)
+
+// LeaderboardPeriod is the time range of the leaderboard of the level.
+type LeaderboardPeriod string
+
+const (
+	LeaderboardDay     LeaderboardPeriod = "day"
+	LeaderboardWeek    LeaderboardPeriod = "week"
+	LeaderboardAllTime LeaderboardPeriod = "all"
+)
+
+// Errors: unknown.
+func (p LeaderboardPeriod) Validate() error {
+	switch p {
+	case LeaderboardDay, LeaderboardWeek, LeaderboardAllTime:
+		return nil
+	}
+	return fmt.Errorf("unknown leaderboard period %q", string(p))
+}
+
+// Bucket returns the name of the period that contains t, e.g. "day:2021-10-19" or "week:2021-W42".
+func (p LeaderboardPeriod) Bucket(t time.Time) string {
+	t = t.UTC()
+	switch p {
+	case LeaderboardDay:
+		return "day:" + t.Format(DailyDateLayout)
+	case LeaderboardWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("week:%d-W%02d", year, week)
+	}
+	return string(LeaderboardAllTime)
+}
+
+// Retention returns how long the leaderboard of the period is kept after the last change.
+// Zero means forever.
+func (p LeaderboardPeriod) Retention() time.Duration {
+	switch p {
+	case LeaderboardDay:
+		return 30 * 24 * time.Hour
+	case LeaderboardWeek:
+		return 365 * 24 * time.Hour
+	}
+	return 0
+}
+
+// LeaderboardPeriods are the periods of the leaderboards of levels.
+var LeaderboardPeriods = []LeaderboardPeriod{LeaderboardDay, LeaderboardWeek, LeaderboardAllTime}
+
+// Leaderboard identifies a leaderboard. Users and anonymous sessions are ranked in separate leaderboards.
+type Leaderboard struct {
+	Kind LeaderboardKind
+	// PuzzleID is set for LeaderboardPuzzle.
+	PuzzleID int64
+	// Type, Level, Period and At are set for LeaderboardLevel. At is any moment of the period.
+	Type   PuzzleType
+	Level  PuzzleLevel
+	Period LeaderboardPeriod
+	At     time.Time
+	// Anonymous chooses the leaderboard of anonymous sessions.
+	Anonymous bool
+}
+
+// LeaderboardEntry is a place in the leaderboard.
+type LeaderboardEntry struct {
+	Rank int `json:"rank"`
+	// UserID is empty for anonymous sessions.
+	UserID int64 `json:"user_id,omitempty"`
+	// Name is the username or the pseudonym of the anonymous session. PuzzleRepository sets only the pseudonym, the
+	// users are kept by UserRepository.
+	Name string `json:"name"`
+	// Elapsed is the solve time for the leaderboards of the fastest solves.
+	Elapsed time.Duration `json:"elapsed,omitempty"`
+	// Solved is the number of solved puzzles for LeaderboardSolved.
+	Solved int `json:"solved,omitempty"`
+}
+
+// IsRanked reports whether the won game is added to leaderboards. Games with hints, shared games and games of custom
+// puzzles are not ranked.
+func (g PuzzleGame) IsRanked() bool {
+	return g.IsWin && g.Hints.Total() == 0 && !g.Shared && g.PuzzleLevel != PuzzleLevelCustom
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaderboardPeriod_Bucket(t *testing.T) {
+	moment := time.Date(2026, 1, 1, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+	tests := []struct {
+		period  LeaderboardPeriod
+		want    string
+		wantErr bool
+	}{
+		{period: LeaderboardDay, want: "day:2025-12-31"},
+		{period: LeaderboardWeek, want: "week:2026-W01"},
+		{period: LeaderboardAllTime, want: "all"},
+		{period: "month", want: "all", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.period), func(t *testing.T) {
+			if err := tt.period.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := tt.period.Bucket(moment); got != tt.want {
+				t.Errorf("Bucket() got = %s, want = %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPuzzleGame_IsRanked(t *testing.T) {
+	tests := []struct {
+		name string
+		game PuzzleGame
+		want bool
+	}{
+		{name: "not won", game: PuzzleGame{}, want: false},
+		{name: "won", game: PuzzleGame{IsWin: true}, want: true},
+		{name: "won with hints", game: PuzzleGame{IsWin: true, Hints: HintCounter{0, 0, 0, 1}}, want: false},
+		{name: "won together", game: PuzzleGame{IsWin: true, Shared: true}, want: false},
+		{name: "custom puzzle", game: PuzzleGame{IsWin: true, PuzzleLevel: PuzzleLevelCustom}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.game.IsRanked(); got != tt.want {
+				t.Errorf("IsRanked() got = %v, want = %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,11 +2,15 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"strconv"
+	"strings"
+	"time"
 )
 
 //go:generate stringer -type=PuzzleStrategy -linecomment -trimprefix Strategy -output puzzle_strategy_string.go
@@ -18,10 +22,36 @@ type PuzzleRepository interface {
 	// Errors: ErrorPuzzleGameNotFound, unknown.
 	GetPuzzleGame(ctx context.Context, id uuid.UUID) (*PuzzleGame, error)
 
+	// UpdatePuzzleGame saves the game if it was not changed since it was read: the stored Version must be equal to
+	// game.Version. The Version is incremented on success.
+	//
+	// Errors: ErrorPuzzleGameConflict, unknown.
 	UpdatePuzzleGame(ctx context.Context, game *PuzzleGame) error
 
+	// CreatePuzzle creates the puzzle and adds it to the pool. The puzzle with the canonical form of an existing
+	// puzzle of the type is rejected, the puzzle without the canonical form is not checked.
+	//
+	// Errors: ErrorPuzzleDuplicate, unknown.
 	CreatePuzzle(ctx context.Context, params CreatePuzzleParams) (*Puzzle, error)
 
+	// CreatePuzzles creates the puzzles in a few round trips and adds them to the pool. A puzzle with the canonical
+	// form of an existing puzzle of the type is skipped, so only the created puzzles are returned. Every puzzle must
+	// have the canonical form.
+	//
+	// Errors: unknown.
+	CreatePuzzles(ctx context.Context, params []CreatePuzzleParams) ([]*Puzzle, error)
+
+	// GetLastPuzzleID returns the identifier of the last created puzzle. The identifiers start from 1.
+	//
+	// Errors: unknown.
+	GetLastPuzzleID(ctx context.Context) (int64, error)
+
+	// SetPuzzleCanonical adds the stored puzzle to the index of the canonical forms by puzzle.Canonical and saves the
+	// canonical form in the puzzle. The puzzle with the canonical form of another puzzle of the type is not changed.
+	//
+	// Errors: ErrorPuzzleDuplicate, unknown.
+	SetPuzzleCanonical(ctx context.Context, puzzle *Puzzle) error
+
 	// Errors: ErrorPuzzleNotFound, unknown.
 	GetPuzzle(ctx context.Context, id int64) (*Puzzle, error)
 
@@ -33,6 +63,135 @@ type PuzzleRepository interface {
 
 	// it's sloooooowly (maybe)
 	GetAmountUnsolvedPuzzlesForAllUsers(ctx context.Context, typ PuzzleType, level PuzzleLevel) (int, error)
+
+	// GetPuzzlePoolSize returns the number of puzzles of the type and level.
+	//
+	// Errors: unknown.
+	GetPuzzlePoolSize(ctx context.Context, typ PuzzleType, level PuzzleLevel) (int, error)
+
+	// GetUnsolvedPuzzleCount returns the number of puzzles of the type and level not solved by the user. All puzzles of
+	// the pool are unsolved for the anonymous user (0).
+	//
+	// Errors: unknown.
+	GetUnsolvedPuzzleCount(ctx context.Context, typ PuzzleType, level PuzzleLevel, userID int64) (int, error)
+
+	// GetPracticePoolSize returns the number of puzzles of the type generated for the strategy target.
+	//
+	// Errors: unknown.
+	GetPracticePoolSize(ctx context.Context, typ PuzzleType, target StrategyTarget) (int, error)
+
+	// AddPuzzleGameMove appends the move to the log of the game. The log is never rewritten.
+	//
+	// Errors: unknown.
+	AddPuzzleGameMove(ctx context.Context, gameID uuid.UUID, move PuzzleGameMove) error
+
+	// GetUserPuzzleGames returns the games of the user from the oldest one.
+	//
+	// Errors: unknown.
+	GetUserPuzzleGames(ctx context.Context, userID int64) ([]*PuzzleGame, error)
+
+	// GetPuzzleGameMoves returns the log of the game from the first move.
+	//
+	// Errors: unknown.
+	GetPuzzleGameMoves(ctx context.Context, gameID uuid.UUID) ([]PuzzleGameMove, error)
+
+	// GetDailyPuzzle returns the daily puzzle of the type and level for the date.
+	// The puzzle is picked from the pool by DailySeed once and stays the same for the date.
+	//
+	// Errors: ErrorPuzzlePoolEmpty, ErrorPuzzleNotFound, unknown.
+	GetDailyPuzzle(ctx context.Context, date string, typ PuzzleType, level PuzzleLevel) (*Puzzle, error)
+
+	// CreateDailyPuzzleGame creates a game of the daily puzzle. A user (or an anonymous session) has only one game
+	// per daily puzzle, so the existing game is returned if it was started before.
+	//
+	// Errors: ErrorPuzzlePoolEmpty, ErrorPuzzleNotFound, unknown.
+	CreateDailyPuzzleGame(ctx context.Context, params CreateDailyPuzzleGameParams) (*Puzzle, *PuzzleGame, error)
+
+	// GetDailyLeaderboard returns the first limit places of the daily puzzle ordered by the time. The usernames are
+	// empty.
+	//
+	// Errors: unknown.
+	GetDailyLeaderboard(ctx context.Context, date string, typ PuzzleType, level PuzzleLevel, limit int) ([]DailyLeaderboardEntry, error)
+
+	// GetUserDailyStreak returns the daily streak of the user. The streak is empty if the user has not won any daily
+	// puzzle.
+	//
+	// Errors: unknown.
+	GetUserDailyStreak(ctx context.Context, userID int64) (*DailyStreak, error)
+
+	// GetLeaderboard returns the first limit places of the leaderboard. The names of the users are empty.
+	//
+	// Errors: unknown.
+	GetLeaderboard(ctx context.Context, board Leaderboard, limit int) ([]LeaderboardEntry, error)
+
+	// AddPuzzleGameMember lets the user (or the anonymous session) play the shared game.
+	//
+	// Errors: unknown.
+	AddPuzzleGameMember(ctx context.Context, gameID uuid.UUID, session *Session) error
+
+	// IsPuzzleGameMember reports whether the user (or the anonymous session) is a member of the shared game.
+	//
+	// Errors: unknown.
+	IsPuzzleGameMember(ctx context.Context, gameID uuid.UUID, session *Session) (bool, error)
+
+	// CreateRace creates a race of a random puzzle of the type and level. The session is the owner of the race.
+	//
+	// Errors: ErrorPuzzlePoolEmpty, ErrorPuzzleNotFound, unknown.
+	CreateRace(ctx context.Context, params CreateRaceParams) (*Race, error)
+
+	// Errors: ErrorRaceNotFound, unknown.
+	GetRace(ctx context.Context, id uuid.UUID) (*Race, error)
+
+	// JoinRace creates a game of the puzzle of the race for the user (or the anonymous session). A player has only
+	// one game per race, so the existing game is returned if the player has joined before.
+	//
+	// Errors: ErrorRaceNotFound, ErrorRaceStarted, ErrorRaceFull, ErrorPuzzleNotFound, unknown.
+	JoinRace(ctx context.Context, id uuid.UUID, session *Session, name string) (*PuzzleGame, error)
+
+	// StartRace sets the start of the race once. The race keeps the first start if it is started again.
+	//
+	// Errors: ErrorRaceNotFound, unknown.
+	StartRace(ctx context.Context, id uuid.UUID, at time.Time) (*Race, error)
+
+	// UpdateRacePlayer saves the progress of the player.
+	//
+	// Errors: unknown.
+	UpdateRacePlayer(ctx context.Context, id uuid.UUID, player RacePlayer) error
+
+	// GetRacePlayers returns the players of the race in the order of joining.
+	//
+	// Errors: unknown.
+	GetRacePlayers(ctx context.Context, id uuid.UUID) ([]RacePlayer, error)
+
+	// CreateCustomPuzzleGame stores the custom puzzle of the session and creates a game of it. Custom puzzles are
+	// not added to the pools of random puzzles.
+	//
+	// Errors: unknown.
+	CreateCustomPuzzleGame(ctx context.Context, params CreateCustomPuzzleGameParams) (*Puzzle, *PuzzleGame, error)
+
+	// GetRandomPuzzle returns a random puzzle of the practice pool of the target. The puzzle of the pool of the level
+	// is returned if the target is nil or its practice pool is empty.
+	//
+	// Errors: ErrorPuzzlePoolEmpty, ErrorPuzzleNotFound, unknown.
+	GetRandomPuzzle(ctx context.Context, typ PuzzleType, level PuzzleLevel, practice *StrategyTarget) (*Puzzle, error)
+
+	// CreateDrill stores the drill of the session for DefaultDrillRetention.
+	//
+	// Errors: unknown.
+	CreateDrill(ctx context.Context, params CreateDrillParams) (*Drill, error)
+
+	// Errors: ErrorDrillNotFound, unknown.
+	GetDrill(ctx context.Context, id uuid.UUID) (*Drill, error)
+
+	// AnswerDrill counts the first answer of the drill in the DrillStats of its user. Later answers are not counted.
+	//
+	// Errors: ErrorDrillAnswered, unknown.
+	AnswerDrill(ctx context.Context, drill *Drill, correct bool) error
+
+	// GetUserDrillStats returns the accuracy of the user in the drills of every strategy in the order of strategies.
+	//
+	// Errors: unknown.
+	GetUserDrillStats(ctx context.Context, userID int64) ([]DrillStats, error)
 }
 
 type PuzzleLibrary interface {
@@ -48,6 +207,10 @@ type CreateRandomPuzzleGameParams struct {
 	Session *Session
 	Type    PuzzleType
 	Level   PuzzleLevel
+	// Symmetric chooses the puzzle with symmetric clues if there is one.
+	Symmetric bool
+	// Practice chooses the puzzle of the practice pool of the strategy target instead of the pool of the level.
+	Practice *StrategyTarget
 }
 
 type CreatePuzzleParams struct {
@@ -55,10 +218,39 @@ type CreatePuzzleParams struct {
 	GeneratedPuzzle
 }
 
+// PuzzleBackfillReport counts the stored puzzles checked by ServiceGenerator.BackfillCanonical.
+type PuzzleBackfillReport struct {
+	Read int
+	// Indexed puzzles got the canonical form.
+	Indexed int
+	// Duplicates have the canonical form of another stored puzzle. They stay in the pools without the canonical form.
+	Duplicates int
+}
+
+// PuzzleLoadReport counts the puzzles read from a file by ServiceGenerator.Load.
+type PuzzleLoadReport struct {
+	Read int
+	// Invalid puzzles are not parsed, have no unique solution or are not checked in DefaultCustomPuzzleCheckTimeout.
+	Invalid int
+	// Unrated puzzles are not solved by the known strategies, so they have no level.
+	Unrated int
+	// Duplicates are repeated in the file or already in the pool up to the transformations, see
+	// PuzzleGenerator.Canonical.
+	Duplicates int
+	Created    map[PuzzleLevel]int
+}
+
 type PuzzleCreator interface {
 	Type() PuzzleType
 	NewRandomSolution() (s PuzzleGenerator, seed int64)
 	NewSolutionBySeed(seed int64) PuzzleGenerator
+	// ParseClues parses the puzzle typed or pasted by the user.
+	//
+	// Errors: ErrorPuzzleInvalid.
+	ParseClues(text string) (PuzzleGenerator, error)
+	// NewRandomTransform returns a random transform of the puzzles of the type that keeps their logic and the
+	// symmetry of their clues.
+	NewRandomTransform(symmetry PuzzleSymmetry) PuzzleTransform
 }
 
 type PuzzleAssistant interface {
@@ -66,8 +258,20 @@ type PuzzleAssistant interface {
 	Type() PuzzleType
 	GetWrongPoints() []Point
 	GetWrongCandidates(candidates string) (string, error)
+	// GetMistakes compares the state and its candidates with the solution.
+	// wrongPoints are the cells with a digit different from the solution.
+	// removedCandidates are the correct digits missing from the candidates of empty
+	// cells where the user keeps candidates.
+	//
+	// Errors: unknown (wrong format of solution or candidates).
+	GetMistakes(solution string, candidates string) (wrongPoints []Point, removedCandidates string, err error)
 	MakeUserStep(candidatesIn string, step PuzzleUserStep) (candidatesOut string, wrongCandidates string, err error)
-	SolveOneStep(candidatesIn string, strategies PuzzleStrategy) (candidatesChanges string, step PuzzleStep, err error)
+	// Errors: unknown (wrong format of candidates).
+	SolveOneStep(candidatesIn string, strategies PuzzleStrategy) (SolveOutcome, error)
+	// CountSteps returns the number of the steps of the strategy that are in the position.
+	//
+	// Errors: unknown (wrong format of candidates).
+	CountSteps(candidatesIn string, strategy PuzzleStrategy) (int, error)
 	//GetCandidates(ctx context.Context, clues string) string
 	//FindUserErrors(ctx context.Context, userState string) []Point
 	//FindUserCandidatesErrors(ctx context.Context, state string, stateCandidates string) string
@@ -84,10 +288,22 @@ type PuzzleGenerator interface {
 	Rotate(r RotationType) error
 	Reflect(r ReflectionType) error
 	SwapDigits(a, b uint8) error
-	Solve(candidatesIn string, chanSteps chan<- PuzzleStep, strategies PuzzleStrategy) (changed bool, candidatesOut string, err error)
-	SolveOneStep(candidatesIn string, strategies PuzzleStrategy) (candidatesChanges string, step PuzzleStep, err error)
-	GenerateLogic(seed int64, strategies PuzzleStrategy) (PuzzleStrategy, error)
+	// Errors: unknown (wrong format of candidates).
+	Solve(candidatesIn string, chanSteps chan<- PuzzleStep, strategies PuzzleStrategy) (SolveOutcome, error)
+	// Errors: unknown (wrong format of candidates).
+	SolveOneStep(candidatesIn string, strategies PuzzleStrategy) (SolveOutcome, error)
+	// Errors: context.Canceled, context.DeadlineExceeded, unknown.
+	GenerateLogic(ctx context.Context, seed int64, strategies PuzzleStrategy, symmetry PuzzleSymmetry) (PuzzleStrategy, error)
+	// Symmetry returns the strongest symmetry of the cells with clues.
+	Symmetry() PuzzleSymmetry
 	GenerateRandom(seed int64) error
+	// FindSolutions finds at most limit solutions of the puzzle by brute force regardless of strategies.
+	//
+	// Errors: context.Canceled, context.DeadlineExceeded.
+	FindSolutions(ctx context.Context, limit int) ([]string, error)
+	// Canonical returns the signature that is the same for all puzzles made from the puzzle by SwapLines,
+	// SwapBigLines, Rotate, Reflect and SwapDigits.
+	Canonical() string
 	//GenerateSolution(ctx context.Context, seed int64, generatedSolutions chan<- GeneratedPuzzle)
 	//GenerateClues(ctx context.Context, seed int64, generatedSolution GeneratedPuzzle, generated chan<- GeneratedPuzzle)
 }
@@ -96,6 +312,150 @@ type PuzzleStep interface {
 	Strategy() PuzzleStrategy
 	CandidateChanges() string
 	Description() string
+	// Payload returns the structured representation of the step to render its
+	// explanation.
+	Payload() PuzzleStepPayload
+}
+
+// PuzzleStepPayload describes the roles of houses, cells and candidates in a step.
+type PuzzleStepPayload struct {
+	// Houses are the houses where the step is found.
+	Houses []House `json:"houses,omitempty"`
+	// Pattern are the cells that make up the pattern of the step.
+	Pattern []Point `json:"pattern,omitempty"`
+	// Digits are the candidates of the pattern.
+	Digits []int8 `json:"digits,omitempty"`
+	// Links connect the cells of the pattern by a digit, e.g. the lines of an X-Wing.
+	Links []StepLink `json:"links,omitempty"`
+	// Placements are the digits set by the step.
+	Placements []StepPlacement `json:"placements,omitempty"`
+	// Eliminations are the candidates deleted by the step.
+	Eliminations []StepCandidates `json:"eliminations,omitempty"`
+}
+
+// ForLevel returns the part of the payload revealed by the hint level.
+func (p PuzzleStepPayload) ForLevel(level HintLevel) PuzzleStepPayload {
+	var out PuzzleStepPayload
+	if level >= HintLevelHouses {
+		out.Houses = p.Houses
+	}
+	if level >= HintLevelPoints {
+		out.Pattern, out.Digits, out.Links = p.Pattern, p.Digits, p.Links
+	}
+	if level >= HintLevelChanges {
+		out.Placements, out.Eliminations = p.Placements, p.Eliminations
+	}
+	return out
+}
+
+// Justifies reports whether the user step is a placement or an elimination of
+// the step.
+func (p PuzzleStepPayload) Justifies(step PuzzleUserStep) bool {
+	switch step.Type {
+	case UserStepSetDigit:
+		for _, placement := range p.Placements {
+			if placement.Point == step.Point && placement.Digit == step.Digit {
+				return true
+			}
+		}
+	case UserStepDeleteCandidate:
+		for _, elimination := range p.Eliminations {
+			if elimination.Point != step.Point {
+				continue
+			}
+			for _, digit := range elimination.Digits {
+				if digit == step.Digit {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+type StepPlacement struct {
+	Point Point `json:"point"`
+	Digit int8  `json:"digit"`
+}
+
+type StepCandidates struct {
+	Point  Point  `json:"point"`
+	Digits []int8 `json:"digits"`
+}
+
+type StepLink struct {
+	From  Point `json:"from"`
+	To    Point `json:"to"`
+	Digit int8  `json:"digit"`
+}
+
+// HintLevel is the amount of help revealed by a hint.
+type HintLevel int
+
+const (
+	// HintLevelStrategy names the strategy.
+	HintLevelStrategy HintLevel = iota + 1
+	// HintLevelHouses adds the houses to look at.
+	HintLevelHouses
+	// HintLevelPoints adds the cells of the pattern.
+	HintLevelPoints
+	// HintLevelChanges adds the exact eliminations or placement.
+	HintLevelChanges
+
+	MaxHintLevel = HintLevelChanges
+)
+
+// Errors: unknown.
+func (l HintLevel) Validate() error {
+	if l < HintLevelStrategy || l > MaxHintLevel {
+		return errors.Errorf("unknown hint level %d", l)
+	}
+	return nil
+}
+
+// SolveStatus is the result of the logical solver.
+type SolveStatus string
+
+const (
+	// SolveStatusProgress means that the solver made a step. Only for SolveOneStep.
+	SolveStatusProgress SolveStatus = "progress"
+	// SolveStatusSolved means that all cells are filled without repetitions.
+	SolveStatusSolved SolveStatus = "solved"
+	// SolveStatusStuck means that no strategy applies to the state.
+	SolveStatusStuck SolveStatus = "stuck"
+	// SolveStatusContradiction means that the state or its candidates cannot lead
+	// to a solution.
+	SolveStatusContradiction SolveStatus = "contradiction"
+)
+
+// SolveOutcome is the structured result of PuzzleGenerator.Solve and
+// PuzzleAssistant.SolveOneStep.
+type SolveOutcome struct {
+	Status SolveStatus
+	// Changed is true if the solver made at least one step.
+	Changed bool
+	// Step is the step made by SolveOneStep if Status is SolveStatusProgress.
+	Step PuzzleStep
+	// Candidates are the candidates after solving. If Status is SolveStatusStuck
+	// these are the remaining candidates.
+	Candidates string
+	// Contradiction is the broken cell or house if Status is
+	// SolveStatusContradiction.
+	Contradiction *PuzzleContradiction
+}
+
+// PuzzleContradiction describes the place where the state of the puzzle is broken:
+//  - Point without Digit: the empty cell has no candidates;
+//  - House and Digit: the digit is repeated in the house or has no place in it.
+type PuzzleContradiction struct {
+	Point  *Point `json:"point,omitempty"`
+	House  *House `json:"house,omitempty"`
+	Digit  int8   `json:"digit,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (c PuzzleContradiction) String() string {
+	return c.Reason
 }
 
 type PuzzleStrategy uint64
@@ -172,6 +532,53 @@ const (
 	ReflectMinorDiagonal
 )
 
+// PuzzleSymmetry is the symmetry of the cells with clues.
+type PuzzleSymmetry string
+
+const (
+	SymmetryNone PuzzleSymmetry = ""
+	// SymmetryRotational is the symmetry of the rotation by 180 degrees.
+	SymmetryRotational PuzzleSymmetry = "rotational"
+	// SymmetryRotational90 is the symmetry of the rotation by 90 degrees.
+	SymmetryRotational90 PuzzleSymmetry = "rotational_90"
+	// SymmetryMirror is the symmetry of the reflection between the left and the right sides.
+	SymmetryMirror PuzzleSymmetry = "mirror"
+	// SymmetryDiagonal is the symmetry of the reflection along the major diagonal.
+	SymmetryDiagonal PuzzleSymmetry = "diagonal"
+)
+
+// PuzzleSymmetries are all symmetries of the generator.
+var PuzzleSymmetries = []PuzzleSymmetry{
+	SymmetryNone, SymmetryRotational, SymmetryRotational90, SymmetryMirror, SymmetryDiagonal,
+}
+
+// PuzzleMeta is the information about the generated puzzle kept in Puzzle.Meta as JSON.
+type PuzzleMeta struct {
+	// Symmetry is the symmetry achieved by the generator.
+	Symmetry PuzzleSymmetry `json:"symmetry,omitempty"`
+	// Practice is the StrategyTarget the puzzle is generated for, the puzzle is added to its practice pool.
+	Practice string `json:"practice,omitempty"`
+}
+
+// ParsePuzzleMeta decodes Puzzle.Meta. The empty meta is valid.
+//
+// Errors: unknown.
+func ParsePuzzleMeta(s string) (PuzzleMeta, error) {
+	var meta PuzzleMeta
+	if s == "" {
+		return meta, nil
+	}
+	if err := json.Unmarshal([]byte(s), &meta); err != nil {
+		return PuzzleMeta{}, errors.Wrap(err, "failed to decode puzzle meta")
+	}
+	return meta, nil
+}
+
+func (m PuzzleMeta) String() string {
+	bts, _ := json.Marshal(m)
+	return string(bts)
+}
+
 type GeneratedPuzzle struct {
 	Seed       int64
 	Level      PuzzleLevel
@@ -179,6 +586,7 @@ type GeneratedPuzzle struct {
 	Clues      string
 	Candidates string
 	Solution   string
+	Canonical  string
 }
 
 type Puzzle struct {
@@ -190,6 +598,13 @@ type Puzzle struct {
 	Clues      string      `json:"clues" redis:"clues"`
 	Candidates string      `json:"candidates" redis:"candidates"`
 	Solution   string      `json:"solution" redis:"solution"`
+	// Canonical is the signature of the puzzle up to the transformations, see PuzzleGenerator.Canonical.
+	Canonical string `json:"canonical,omitempty" redis:"canonical"`
+	// Rating is the level measured by the logic solver for the custom puzzle. It is empty if the solver gets stuck.
+	Rating PuzzleLevel `json:"rating,omitempty" redis:"rating"`
+	// UserID and SessionID are the author of the custom puzzle.
+	UserID    int64 `json:"user_id,omitempty" redis:"user_id"`
+	SessionID int64 `json:"session_id,omitempty" redis:"session_id"`
 }
 
 type PuzzleGame struct {
@@ -200,9 +615,114 @@ type PuzzleGame struct {
 	IsNew           bool      `json:"is_new" redis:"is_new"`
 	State           string    `json:"state" redis:"state"`
 	StateCandidates string    `json:"state_candidates" redis:"state_candidates"`
+	StartCandidates string    `json:"start_candidates" redis:"start_candidates"`
 	IsWin           bool      `json:"is_win" redis:"is_win"`
+	// Hints counts the hints given for the game by level.
+	Hints HintCounter `json:"hints" redis:"hints"`
+
+	// PuzzleType and PuzzleLevel are copied from the puzzle for statistics.
+	PuzzleType  PuzzleType  `json:"puzzle_type" redis:"puzzle_type"`
+	PuzzleLevel PuzzleLevel `json:"puzzle_level" redis:"puzzle_level"`
+	CreatedAt   DateTime    `json:"created_at" redis:"created_at"`
+	// FinishedAt is the time of the win.
+	FinishedAt DateTime `json:"finished_at" redis:"finished_at"`
+	// Mistakes counts the digits placed against the solution.
+	Mistakes int `json:"mistakes" redis:"mistakes"`
+	// Daily is the date of the daily puzzle if the game is started as the daily one.
+	Daily string `json:"daily,omitempty" redis:"daily"`
+	// Race is the identifier of the race if the game is a board of the race.
+	Race string `json:"race,omitempty" redis:"race"`
+	// Shared is true if the owner invited other players with InviteCode.
+	Shared     bool   `json:"shared,omitempty" redis:"shared"`
+	InviteCode string `json:"-" redis:"invite_code"`
+	// Public is true if the owner lets anyone with the link watch the game.
+	Public bool `json:"public,omitempty" redis:"public"`
+	// Transform presents the puzzle of the game, the state is kept transformed. It is hidden from players.
+	Transform PuzzleTransform `json:"-" redis:"transform"`
+	// Version is incremented on every update of the game to detect concurrent edits.
+	Version int64 `json:"version" redis:"version"`
+
+	// StartedAt is the time of the first move.
+	StartedAt DateTime `json:"started_at" redis:"started_at"`
+	// ActiveAt is the time of the last activity if the timer is running.
+	ActiveAt DateTime `json:"active_at" redis:"active_at"`
+	// Elapsed is the active time counted up to ActiveAt. It is the solve time
+	// if IsWin is true.
+	Elapsed time.Duration `json:"elapsed" redis:"elapsed"`
 }
 
+// HintCounter counts hints by level; index 0 is HintLevelStrategy.
+// It is stored in the data store as comma-separated numbers.
+type HintCounter [MaxHintLevel]int
+
+// Add counts a hint of the level. Unknown levels are ignored.
+func (c *HintCounter) Add(level HintLevel) {
+	if level.Validate() != nil {
+		return
+	}
+	c[level-1]++
+}
+
+// Total returns the number of hints of all levels.
+func (c HintCounter) Total() (total int) {
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+func (c HintCounter) RedisArg() interface{} {
+	parts := make([]string, len(c))
+	for i, n := range c {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (c *HintCounter) RedisScan(src interface{}) error {
+	if c == nil {
+		return fmt.Errorf("nil pointer")
+	}
+	var str string
+	switch src := src.(type) {
+	case string:
+		str = src
+	case []uint8:
+		str = string(src)
+	default:
+		return fmt.Errorf("cannot convert from %T to %T", src, c)
+	}
+	*c = HintCounter{}
+	if str == "" {
+		return nil
+	}
+	for i, part := range strings.Split(str, ",") {
+		if i >= len(c) {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return fmt.Errorf("invalid hint counter %q", str)
+		}
+		c[i] = n
+	}
+	return nil
+}
+
+// Share makes the game shared and returns the invite code.
+func (g *PuzzleGame) Share() string {
+	if g.InviteCode == "" {
+		buf := make([]byte, 12)
+		rand.Read(buf)
+		g.InviteCode = base64.RawURLEncoding.EncodeToString(buf)
+	}
+	g.Shared = true
+	return g.InviteCode
+}
+
+// ValidateSession allows only the owner of the game. Members of shared games are checked with
+// PuzzleRepository.IsPuzzleGameMember.
+//
 // Errors: ErrorPuzzleGameNotAllowed.
 func (g *PuzzleGame) ValidateSession(session *Session) error {
 	if g.UserID > 0 {
@@ -217,12 +737,140 @@ func (g *PuzzleGame) ValidateSession(session *Session) error {
 	return nil
 }
 
+// GameRole is the permission of a session in a game. Every role has the permissions of the lower roles.
+type GameRole int
+
+const (
+	// GameRoleNone cannot see the game.
+	GameRoleNone GameRole = iota
+	// GameRoleSpectator watches the public game and cannot change it.
+	GameRoleSpectator
+	// GameRolePlayer is a member of the shared game.
+	GameRolePlayer
+	// GameRoleOwner started the game and manages who can play and watch it.
+	GameRoleOwner
+)
+
+// Role returns the role of the session in the game. member reports whether the session is a member of the shared
+// game, see PuzzleRepository.IsPuzzleGameMember.
+func (g *PuzzleGame) Role(session *Session, member bool) GameRole {
+	switch {
+	case g.ValidateSession(session) == nil:
+		return GameRoleOwner
+	case g.Shared && member:
+		return GameRolePlayer
+	case g.Public:
+		return GameRoleSpectator
+	}
+	return GameRoleNone
+}
+
+// TimerTick counts the active time up to now and keeps the timer running.
+// The timer starts on the first tick. A gap without activity counts at most idle.
+func (g *PuzzleGame) TimerTick(now time.Time, idle time.Duration) {
+	if g.IsWin {
+		return
+	}
+	if g.StartedAt.IsZero() {
+		g.StartedAt = DateTime{now}
+	}
+	g.Elapsed += g.activeSince(now, idle)
+	g.ActiveAt = DateTime{now}
+}
+
+// TimerPause counts the active time up to now and stops the timer.
+func (g *PuzzleGame) TimerPause(now time.Time, idle time.Duration) {
+	g.Elapsed += g.activeSince(now, idle)
+	g.ActiveAt = DateTime{}
+}
+
+// TimerResume starts the paused timer if the game is started.
+func (g *PuzzleGame) TimerResume(now time.Time) {
+	if g.IsWin || g.StartedAt.IsZero() || !g.ActiveAt.IsZero() {
+		return
+	}
+	g.ActiveAt = DateTime{now}
+}
+
+// TimerElapsed returns the active time up to now without changes of the game.
+func (g PuzzleGame) TimerElapsed(now time.Time, idle time.Duration) time.Duration {
+	return g.Elapsed + g.activeSince(now, idle)
+}
+
+// TimerRunning reports whether the timer is running.
+func (g PuzzleGame) TimerRunning() bool {
+	return !g.ActiveAt.IsZero()
+}
+
+// TimerPaused reports whether the started game is paused. The paused game must be resumed before the next move.
+func (g PuzzleGame) TimerPaused() bool {
+	return !g.IsWin && !g.StartedAt.IsZero() && g.ActiveAt.IsZero()
+}
+
+func (g PuzzleGame) activeSince(now time.Time, idle time.Duration) time.Duration {
+	if g.ActiveAt.IsZero() {
+		return 0
+	}
+	active := now.Sub(g.ActiveAt.Time)
+	switch {
+	case active < 0:
+		return 0
+	case active > idle:
+		return idle
+	}
+	return active
+}
+
 type PuzzleUserStep struct {
 	Type  UserStepType `json:"type"`
 	Point Point        `json:"point"`
 	Digit int8         `json:"digit"`
 }
 
+// PuzzleGameMove is a record of the move log of the game.
+// Step is set only if Type is MoveStep.
+type PuzzleGameMove struct {
+	Type      MoveType        `json:"type"`
+	Step      *PuzzleUserStep `json:"step,omitempty"`
+	CreatedAt DateTime        `json:"created_at"`
+}
+
+type MoveType string
+
+const (
+	MoveStep MoveType = "step"
+	MoveUndo MoveType = "undo"
+	MoveRedo MoveType = "redo"
+)
+
+// ReplayMoves plays the move log and returns the steps that make up the state of
+// the game and the undone steps that can be redone, the next one is the last.
+func ReplayMoves(moves []PuzzleGameMove) (applied []PuzzleUserStep, undone []PuzzleUserStep) {
+	for _, move := range moves {
+		switch move.Type {
+		case MoveStep:
+			if move.Step == nil {
+				continue
+			}
+			applied = append(applied, *move.Step)
+			undone = nil
+		case MoveUndo:
+			if len(applied) == 0 {
+				continue
+			}
+			undone = append(undone, applied[len(applied)-1])
+			applied = applied[:len(applied)-1]
+		case MoveRedo:
+			if len(undone) == 0 {
+				continue
+			}
+			applied = append(applied, undone[len(undone)-1])
+			undone = undone[:len(undone)-1]
+		}
+	}
+	return applied, undone
+}
+
 type UserStepType string
 
 const (
@@ -247,6 +895,13 @@ var (
 	ErrorPuzzleNotFound       = fmt.Errorf("puzzle not found")
 	ErrorPuzzleGameNotFound   = fmt.Errorf("puzzle game not found")
 	ErrorPuzzleGameNotAllowed = fmt.Errorf("puzzle game not allowed")
+	ErrorPuzzleGameConflict   = fmt.Errorf("puzzle game was changed concurrently")
+	// ErrorPuzzleDuplicate rejects the puzzle with the canonical form of a stored puzzle.
+	ErrorPuzzleDuplicate = fmt.Errorf("puzzle is a duplicate")
+	// ErrorPuzzleInvalid, ErrorPuzzleNoSolution and ErrorPuzzleMultipleSolutions reject custom puzzles.
+	ErrorPuzzleInvalid           = fmt.Errorf("puzzle is invalid")
+	ErrorPuzzleNoSolution        = fmt.Errorf("puzzle has no solution")
+	ErrorPuzzleMultipleSolutions = fmt.Errorf("puzzle has more than one solution")
 )
 
 type PuzzleType string
@@ -324,6 +979,35 @@ func (l PuzzleLevel) Strategies() PuzzleStrategy {
 	}
 }
 
+type HouseType string
+
+const (
+	HouseRow HouseType = "row"
+	HouseCol HouseType = "col"
+	HouseBox HouseType = "box"
+)
+
+// House is a row, a column or a box 3x3 of the puzzle.
+// Index is in the range [0,8]; boxes are numbered from left to right and from
+// top to bottom.
+type House struct {
+	Type  HouseType `json:"type"`
+	Index int       `json:"index"`
+}
+
+func (h House) String() string {
+	switch h.Type {
+	case HouseRow:
+		return fmt.Sprintf("row %s", string('a'+byte(h.Index)))
+	case HouseCol:
+		return fmt.Sprintf("column %d", h.Index+1)
+	case HouseBox:
+		return fmt.Sprintf("box %d", h.Index+1)
+	default:
+		return fmt.Sprintf("house %s %d", h.Type, h.Index)
+	}
+}
+
 type Point struct {
 	Row, Col int
 }
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHintCounter_Redis(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    HintCounter
+		wantErr bool
+	}{
+		{name: "empty", src: []uint8(""), want: HintCounter{}},
+		{name: "all levels", src: []uint8("3,0,2,1"), want: HintCounter{3, 0, 2, 1}},
+		{name: "string", src: "0,1,0,0", want: HintCounter{0, 1, 0, 0}},
+		{name: "invalid", src: []uint8("1,x"), wantErr: true},
+		{name: "unsupported", src: int64(1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got HintCounter
+			err := got.RedisScan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RedisScan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("RedisScan() got = %v, want = %v", got, tt.want)
+			}
+			var again HintCounter
+			if err := again.RedisScan(got.RedisArg().(string)); err != nil || again != got {
+				t.Errorf("RedisArg() round trip got = %v (%v), want = %v", again, err, got)
+			}
+		})
+	}
+}
+
+func TestReplayMoves(t *testing.T) {
+	step := func(digit int8) *PuzzleUserStep {
+		return &PuzzleUserStep{Type: UserStepSetDigit, Point: Point{Row: 0, Col: 0}, Digit: digit}
+	}
+	tests := []struct {
+		name        string
+		moves       []PuzzleGameMove
+		wantApplied []int8
+		wantUndone  []int8
+	}{
+		{name: "empty"},
+		{
+			name:        "steps",
+			moves:       []PuzzleGameMove{{Type: MoveStep, Step: step(1)}, {Type: MoveStep, Step: step(2)}},
+			wantApplied: []int8{1, 2},
+		},
+		{
+			name: "undo twice",
+			moves: []PuzzleGameMove{
+				{Type: MoveStep, Step: step(1)}, {Type: MoveStep, Step: step(2)},
+				{Type: MoveUndo}, {Type: MoveUndo}, {Type: MoveUndo},
+			},
+			wantUndone: []int8{2, 1},
+		},
+		{
+			name: "redo",
+			moves: []PuzzleGameMove{
+				{Type: MoveStep, Step: step(1)}, {Type: MoveStep, Step: step(2)},
+				{Type: MoveUndo}, {Type: MoveUndo}, {Type: MoveRedo},
+			},
+			wantApplied: []int8{1},
+			wantUndone:  []int8{2},
+		},
+		{
+			name: "step after undo drops redo",
+			moves: []PuzzleGameMove{
+				{Type: MoveStep, Step: step(1)}, {Type: MoveUndo},
+				{Type: MoveStep, Step: step(3)}, {Type: MoveRedo},
+			},
+			wantApplied: []int8{3},
+		},
+	}
+	digits := func(steps []PuzzleUserStep) (out []int8) {
+		for _, step := range steps {
+			out = append(out, step.Digit)
+		}
+		return out
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied, undone := ReplayMoves(tt.moves)
+			if got := digits(applied); !reflect.DeepEqual(got, tt.wantApplied) {
+				t.Errorf("ReplayMoves() got applied = %v, want = %v", got, tt.wantApplied)
+			}
+			if got := digits(undone); !reflect.DeepEqual(got, tt.wantUndone) {
+				t.Errorf("ReplayMoves() got undone = %v, want = %v", got, tt.wantUndone)
+			}
+		})
+	}
+}
+
+func TestPuzzleGame_Timer(t *testing.T) {
+	start := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time {
+		return start.Add(d)
+	}
+	const idle = 5 * time.Minute
+	game := PuzzleGame{}
+
+	game.TimerResume(at(0))
+	if game.TimerRunning() || game.TimerPaused() {
+		t.Fatalf("TimerResume() started the timer before the first move")
+	}
+	game.TimerTick(at(0), idle)
+	game.TimerTick(at(time.Minute), idle)
+	if got := game.TimerElapsed(at(90*time.Second), idle); got != 90*time.Second {
+		t.Errorf("TimerElapsed() got = %s, want = %s", got, 90*time.Second)
+	}
+	// idle gap
+	game.TimerTick(at(time.Hour), idle)
+	if want := time.Minute + idle; game.Elapsed != want {
+		t.Errorf("TimerTick() after idle got elapsed = %s, want = %s", game.Elapsed, want)
+	}
+	game.TimerPause(at(time.Hour+time.Minute), idle)
+	if game.TimerRunning() || !game.TimerPaused() {
+		t.Errorf("TimerPause() the timer is running")
+	}
+	if got, want := game.TimerElapsed(at(2*time.Hour), idle), 2*time.Minute+idle; got != want {
+		t.Errorf("TimerElapsed() when paused got = %s, want = %s", got, want)
+	}
+	game.TimerResume(at(2 * time.Hour))
+	game.TimerPause(at(2*time.Hour+time.Minute), idle)
+	game.IsWin = true
+	game.TimerTick(at(3*time.Hour), idle)
+	if want := 3*time.Minute + idle; game.Elapsed != want || game.TimerRunning() {
+		t.Errorf("TimerTick() after win got elapsed = %s (running %t), want = %s", game.Elapsed, game.TimerRunning(), want)
+	}
+	if !game.StartedAt.Equal(start) {
+		t.Errorf("StartedAt got = %s, want = %s", game.StartedAt, start)
+	}
+}
+
+func TestPuzzleGame_Role(t *testing.T) {
+	owner := &Session{CookieSession: CookieSession{SessionID: 1}}
+	other := &Session{CookieSession: CookieSession{SessionID: 2}}
+	tests := []struct {
+		name    string
+		game    PuzzleGame
+		session *Session
+		member  bool
+		want    GameRole
+	}{
+		{name: "owner", game: PuzzleGame{SessionID: 1}, session: owner, want: GameRoleOwner},
+		{name: "owner of public game", game: PuzzleGame{SessionID: 1, Public: true}, session: owner, want: GameRoleOwner},
+		{name: "member", game: PuzzleGame{SessionID: 1, Shared: true}, session: other, member: true, want: GameRolePlayer},
+		{name: "not member", game: PuzzleGame{SessionID: 1, Shared: true}, session: other, want: GameRoleNone},
+		{name: "member of not shared game", game: PuzzleGame{SessionID: 1}, session: other, member: true, want: GameRoleNone},
+		{name: "spectator", game: PuzzleGame{SessionID: 1, Public: true}, session: other, want: GameRoleSpectator},
+		{name: "member of public game", game: PuzzleGame{SessionID: 1, Shared: true, Public: true}, session: other, member: true, want: GameRolePlayer},
+		{name: "other user", game: PuzzleGame{UserID: 5, SessionID: 1}, session: owner, want: GameRoleNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.game.Role(tt.session, tt.member); got != tt.want {
+				t.Errorf("Role() got = %d, want = %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePuzzleMeta(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    PuzzleMeta
+		wantErr bool
+	}{
+		{name: "empty", in: "", want: PuzzleMeta{}},
+		{name: "empty object", in: "{}", want: PuzzleMeta{}},
+		{name: "symmetry", in: `{"symmetry":"rotational"}`, want: PuzzleMeta{Symmetry: SymmetryRotational}},
+		{name: "invalid", in: "{", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePuzzleMeta(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePuzzleMeta() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePuzzleMeta() = %+v, want = %+v", got, tt.want)
+			}
+		})
+	}
+	if got := (PuzzleMeta{}).String(); got != "{}" {
+		t.Errorf("String() = %s, want = {}", got)
+	}
+}
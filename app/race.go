@@ -0,0 +1,111 @@
+package app
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"sort"
+	"time"
+)
+
+var (
+	ErrorRaceNotFound = fmt.Errorf("race not found")
+	ErrorRaceStarted  = fmt.Errorf("race is already started")
+	ErrorRaceFull     = fmt.Errorf("race is full")
+)
+
+type CreateRaceParams struct {
+	Session           *Session
+	Type              PuzzleType
+	Level             PuzzleLevel
+	CandidatesAtStart bool
+}
+
+// Race is a competition of several players who solve the same puzzle on separate boards.
+type Race struct {
+	ID        uuid.UUID   `json:"id" redis:"-"`
+	SessionID int64       `json:"session_id,omitempty" redis:"session_id"`
+	UserID    int64       `json:"user_id,omitempty" redis:"user_id"`
+	PuzzleID  int64       `json:"puzzle_id" redis:"puzzle_id"`
+	Type      PuzzleType  `json:"type" redis:"type"`
+	Level     PuzzleLevel `json:"level" redis:"level"`
+	// CandidatesAtStart is the same for all boards of the race.
+	CandidatesAtStart bool     `json:"candidates_at_start" redis:"candidates_at_start"`
+	CreatedAt         DateTime `json:"created_at" redis:"created_at"`
+	// StartAt is the end of the countdown. It is zero until the owner starts the race.
+	StartAt DateTime `json:"start_at" redis:"start_at"`
+}
+
+// ValidateSession checks that the session is the owner of the race.
+//
+// Errors: ErrorPuzzleGameNotAllowed.
+func (r *Race) ValidateSession(session *Session) error {
+	if r.UserID > 0 {
+		if r.UserID != session.UserID {
+			return ErrorPuzzleGameNotAllowed
+		}
+	} else {
+		if r.SessionID != session.SessionID {
+			return ErrorPuzzleGameNotAllowed
+		}
+	}
+	return nil
+}
+
+// IsCountdown reports whether the owner has started the countdown.
+func (r *Race) IsCountdown() bool {
+	return !r.StartAt.IsZero()
+}
+
+// IsStarted reports whether the countdown is over at the moment now.
+func (r *Race) IsStarted(now time.Time) bool {
+	return r.IsCountdown() && !now.Before(r.StartAt.Time)
+}
+
+// RacePlayer is the progress of a player of the race.
+type RacePlayer struct {
+	GameID uuid.UUID `json:"game_id"`
+	Name   string    `json:"name"`
+	// Filled is the number of digits placed by the player.
+	Filled   int  `json:"filled"`
+	Mistakes int  `json:"mistakes"`
+	Finished bool `json:"finished,omitempty"`
+	// Elapsed is the time from the start of the race to the win.
+	Elapsed time.Duration `json:"elapsed,omitempty"`
+	// Rank is set by RankRacePlayers.
+	Rank int `json:"rank,omitempty"`
+}
+
+// RankRacePlayers orders the players and sets their ranks. Finished players are ranked by the time, the others by
+// the number of placed digits and then by the number of mistakes. Players with the same progress share the rank.
+func RankRacePlayers(players []RacePlayer) {
+	less := func(a, b RacePlayer) bool {
+		switch {
+		case a.Finished != b.Finished:
+			return a.Finished
+		case a.Finished:
+			return a.Elapsed < b.Elapsed
+		case a.Filled != b.Filled:
+			return a.Filled > b.Filled
+		}
+		return a.Mistakes < b.Mistakes
+	}
+	sort.SliceStable(players, func(i, j int) bool {
+		return less(players[i], players[j])
+	})
+	for i := range players {
+		players[i].Rank = i + 1
+		if i > 0 && !less(players[i-1], players[i]) {
+			players[i].Rank = players[i-1].Rank
+		}
+	}
+}
+
+// CountFilled returns the number of digits of the state that are not clues of the puzzle.
+func CountFilled(clues, state string) (filled int) {
+	for i := 0; i < len(clues) && i < len(state); i++ {
+		if clues[i] != state[i] && '1' <= state[i] && state[i] <= '9' {
+			filled++
+		}
+	}
+	return filled
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRankRacePlayers(t *testing.T) {
+	tests := []struct {
+		name      string
+		players   []RacePlayer
+		wantNames []string
+		wantRanks []int
+	}{
+		{
+			name: "finished first",
+			players: []RacePlayer{
+				{Name: "a", Filled: 50},
+				{Name: "b", Finished: true, Elapsed: 3 * time.Minute},
+				{Name: "c", Finished: true, Elapsed: 2 * time.Minute},
+			},
+			wantNames: []string{"c", "b", "a"},
+			wantRanks: []int{1, 2, 3},
+		},
+		{
+			name: "by progress",
+			players: []RacePlayer{
+				{Name: "a", Filled: 10, Mistakes: 2},
+				{Name: "b", Filled: 20},
+				{Name: "c", Filled: 10, Mistakes: 1},
+			},
+			wantNames: []string{"b", "c", "a"},
+			wantRanks: []int{1, 2, 3},
+		},
+		{
+			name: "same progress",
+			players: []RacePlayer{
+				{Name: "a", Filled: 10},
+				{Name: "b", Filled: 20},
+				{Name: "c", Filled: 10},
+			},
+			wantNames: []string{"b", "a", "c"},
+			wantRanks: []int{1, 2, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			RankRacePlayers(tt.players)
+			for i, player := range tt.players {
+				if player.Name != tt.wantNames[i] || player.Rank != tt.wantRanks[i] {
+					t.Errorf("RankRacePlayers() got %d: %s (%d), want = %s (%d)",
+						i, player.Name, player.Rank, tt.wantNames[i], tt.wantRanks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCountFilled(t *testing.T) {
+	if got := CountFilled("1.3.", "1234"); got != 2 {
+		t.Errorf("CountFilled() got = %d, want = %d", got, 2)
+	}
+	if got := CountFilled("1.3.", "1.3."); got != 0 {
+		t.Errorf("CountFilled() got = %d, want = %d", got, 0)
+	}
+}
@@ -1,6 +1,10 @@
 package app
 
-import "net/http"
+import (
+	"context"
+	"io"
+	"net/http"
+)
 
 type ServiceFrontend interface {
 	MiddlewareReqID(next http.Handler) http.Handler
@@ -18,10 +22,34 @@ type ServiceFrontend interface {
 	HandleSignup(w http.ResponseWriter, r *http.Request)
 	HandleLogout(w http.ResponseWriter, r *http.Request)
 	HandleSettings(w http.ResponseWriter, r *http.Request)
+	HandleStats(w http.ResponseWriter, r *http.Request)
+	HandleStatsJSON(w http.ResponseWriter, r *http.Request)
+	HandleDaily(w http.ResponseWriter, r *http.Request)
+	HandleLeaderboards(w http.ResponseWriter, r *http.Request)
 	HandleGameID(w http.ResponseWriter, r *http.Request)
+	HandleGameReplay(w http.ResponseWriter, r *http.Request)
+	HandleGameJoin(w http.ResponseWriter, r *http.Request)
+	HandleGameWatch(w http.ResponseWriter, r *http.Request)
+	HandleRace(w http.ResponseWriter, r *http.Request)
+	HandleDrill(w http.ResponseWriter, r *http.Request)
+	HandleDrillID(w http.ResponseWriter, r *http.Request)
+	HandleDrillAnswer(w http.ResponseWriter, r *http.Request)
 	HandleGameWs(w http.ResponseWriter, r *http.Request)
 }
 
 type ServiceGenerator interface {
-	Run() error
+	// Run does the generate jobs of the queue and queues the refills of the pools until the context is done. The
+	// attempts in progress are abandoned then, and the interrupted job is left in the queue for a retry.
+	Run(ctx context.Context) error
+	// Load adds the puzzles of the file with one puzzle per line to the pool. Every puzzle is checked for the unique
+	// solution and gets the level of the logic solver.
+	//
+	// Errors: ErrorPuzzleTypeUnknown, unknown.
+	Load(ctx context.Context, typ PuzzleType, file io.Reader) (*PuzzleLoadReport, error)
+	// BackfillCanonical computes the canonical form of the stored puzzles created before the canonical forms were
+	// indexed and adds them to the index, so the duplicates of these puzzles are rejected too. Custom puzzles are
+	// skipped. It is safe to run it again.
+	//
+	// Errors: unknown.
+	BackfillCanonical(ctx context.Context) (*PuzzleBackfillReport, error)
 }
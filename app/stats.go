@@ -0,0 +1,83 @@
+package app
+
+import (
+	"sort"
+	"time"
+)
+
+// PuzzleStats is the personal statistics of the user for one type and level of puzzles.
+type PuzzleStats struct {
+	Type    PuzzleType  `json:"type"`
+	Level   PuzzleLevel `json:"level"`
+	Started int         `json:"started"`
+	Won     int         `json:"won"`
+	// BestTime and AverageTime are calculated by won games with the tracked time.
+	BestTime    time.Duration `json:"best_time"`
+	AverageTime time.Duration `json:"average_time"`
+	Hints       int           `json:"hints"`
+	Mistakes    int           `json:"mistakes"`
+	// Streak is the number of the latest games won in a row.
+	// The latest unfinished game does not break the streak.
+	Streak int `json:"streak"`
+}
+
+// NewPuzzleStats aggregates games of the user ordered from the oldest one.
+// Games without a type or level are skipped.
+func NewPuzzleStats(games []*PuzzleGame) []PuzzleStats {
+	type key struct {
+		typ   PuzzleType
+		level PuzzleLevel
+	}
+	groups := make(map[key][]*PuzzleGame)
+	for _, game := range games {
+		if game.PuzzleType == "" || game.PuzzleLevel == "" {
+			continue
+		}
+		k := key{typ: game.PuzzleType, level: game.PuzzleLevel}
+		groups[k] = append(groups[k], game)
+	}
+
+	stats := make([]PuzzleStats, 0, len(groups))
+	for k, group := range groups {
+		s := PuzzleStats{Type: k.typ, Level: k.level, Started: len(group)}
+		var timed int
+		var total time.Duration
+		for _, game := range group {
+			s.Hints += game.Hints.Total()
+			s.Mistakes += game.Mistakes
+			if !game.IsWin {
+				continue
+			}
+			s.Won++
+			if game.Elapsed <= 0 {
+				continue
+			}
+			if timed == 0 || game.Elapsed < s.BestTime {
+				s.BestTime = game.Elapsed
+			}
+			timed++
+			total += game.Elapsed
+		}
+		if timed > 0 {
+			s.AverageTime = total / time.Duration(timed)
+		}
+		for i := len(group) - 1; i >= 0; i-- {
+			if !group[i].IsWin {
+				if i == len(group)-1 {
+					continue
+				}
+				break
+			}
+			s.Streak++
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Type != stats[j].Type {
+			return PuzzleTypeLess(stats[i].Type, stats[j].Type)
+		}
+		return PuzzleLevelLess(stats[i].Level, stats[j].Level)
+	})
+
+	return stats
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewPuzzleStats(t *testing.T) {
+	game := func(level PuzzleLevel, win bool, elapsed time.Duration, hints HintCounter, mistakes int) *PuzzleGame {
+		return &PuzzleGame{
+			PuzzleType:  PuzzleSudokuClassic,
+			PuzzleLevel: level,
+			IsWin:       win,
+			Elapsed:     elapsed,
+			Hints:       hints,
+			Mistakes:    mistakes,
+		}
+	}
+	tests := []struct {
+		name  string
+		games []*PuzzleGame
+		want  []PuzzleStats
+	}{
+		{
+			name:  "empty",
+			games: nil,
+			want:  []PuzzleStats{},
+		},
+		{
+			name: "skip games without type",
+			games: []*PuzzleGame{
+				{IsWin: true},
+			},
+			want: []PuzzleStats{},
+		},
+		{
+			name: "aggregate",
+			games: []*PuzzleGame{
+				game(PuzzleLevelNormal, true, 3*time.Minute, HintCounter{1, 0, 0, 0}, 0),
+				game(PuzzleLevelEasy, true, 2*time.Minute, HintCounter{}, 1),
+				game(PuzzleLevelEasy, false, 0, HintCounter{0, 2, 0, 1}, 2),
+				game(PuzzleLevelEasy, true, 4*time.Minute, HintCounter{}, 0),
+				game(PuzzleLevelEasy, true, 0, HintCounter{}, 0),
+				game(PuzzleLevelEasy, false, 0, HintCounter{}, 0),
+			},
+			want: []PuzzleStats{
+				{
+					Type:        PuzzleSudokuClassic,
+					Level:       PuzzleLevelEasy,
+					Started:     5,
+					Won:         3,
+					BestTime:    2 * time.Minute,
+					AverageTime: 3 * time.Minute,
+					Hints:       3,
+					Mistakes:    3,
+					Streak:      2,
+				},
+				{
+					Type:        PuzzleSudokuClassic,
+					Level:       PuzzleLevelNormal,
+					Started:     1,
+					Won:         1,
+					BestTime:    3 * time.Minute,
+					AverageTime: 3 * time.Minute,
+					Hints:       1,
+					Streak:      1,
+				},
+			},
+		},
+		{
+			name: "streak is broken",
+			games: []*PuzzleGame{
+				game(PuzzleLevelHard, true, time.Minute, HintCounter{}, 0),
+				game(PuzzleLevelHard, false, 0, HintCounter{}, 0),
+				game(PuzzleLevelHard, false, 0, HintCounter{}, 0),
+			},
+			want: []PuzzleStats{
+				{
+					Type:        PuzzleSudokuClassic,
+					Level:       PuzzleLevelHard,
+					Started:     3,
+					Won:         1,
+					BestTime:    time.Minute,
+					AverageTime: time.Minute,
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewPuzzleStats(tt.games); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewPuzzleStats() got = %+v, want = %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StrategyCount is the number of steps of the logical solution per strategy.
+type StrategyCount map[PuzzleStrategy]int
+
+// Strategies returns the mask of the used strategies.
+func (c StrategyCount) Strategies() PuzzleStrategy {
+	strategies := StrategyUnknown
+	for strategy, count := range c {
+		if count > 0 {
+			strategies |= strategy
+		}
+	}
+	return strategies
+}
+
+// CountStrategies solves the puzzle with the strategies and counts the steps of each strategy. The puzzle is solved in
+// place.
+//
+// Errors: unknown.
+func CountStrategies(generator PuzzleGenerator, strategies PuzzleStrategy) (StrategyCount, SolveStatus, error) {
+	chanSteps := make(chan PuzzleStep)
+	counted := make(chan StrategyCount)
+	go func() {
+		count := make(StrategyCount)
+		for step := range chanSteps {
+			count[step.Strategy()]++
+		}
+		counted <- count
+	}()
+	outcome, err := generator.Solve("", chanSteps, strategies)
+	count := <-counted
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	return count, outcome.Status, nil
+}
+
+// ParsePuzzleStrategy returns the strategy by its name. The case, spaces and punctuation of the name are ignored, so
+// "X-Wing", "x wing" and "xwing" are the same strategy.
+//
+// Errors: unknown.
+func ParsePuzzleStrategy(s string) (PuzzleStrategy, error) {
+	name := strategyName(s)
+	all := PuzzleLevelDemon.Strategies()
+	for strategy := StrategyNakedSingle; strategy <= all; strategy <<= 1 {
+		if all.Has(strategy) && strategyName(strategy.String()) == name {
+			return strategy, nil
+		}
+	}
+	return StrategyUnknown, errors.Errorf("unknown strategy %q", s)
+}
+
+// strategyName returns the name of the strategy in lower case without spaces and punctuation.
+func strategyName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return -1
+		}
+	}, s)
+}
+
+// StrategyTarget is the requirement to the strategies of the logical solution of the puzzle: "must need X-Wing",
+// "must use Hidden Triple at least twice", "must not need Pointing Pair". The solver tries the easiest strategy
+// first, so a strategy used by the solution is needed at that step.
+type StrategyTarget struct {
+	// Require is the minimal number of steps of each required strategy.
+	Require map[PuzzleStrategy]int
+	// Forbid are the strategies the solution must not need.
+	Forbid PuzzleStrategy
+}
+
+// ParseStrategyTarget decodes the target from the comma separated list of strategy names. A name is required once,
+// "name:N" is required N times and "-name" is forbidden, e.g. "hidden triple:2,-pointing pair". The empty target
+// requires nothing.
+//
+// Errors: unknown.
+func ParseStrategyTarget(s string) (StrategyTarget, error) {
+	target := StrategyTarget{Require: make(map[PuzzleStrategy]int)}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "-") {
+			strategy, err := ParsePuzzleStrategy(part[1:])
+			if err != nil {
+				return StrategyTarget{}, errors.WithStack(err)
+			}
+			target.Forbid |= strategy
+			continue
+		}
+		count := 1
+		if idx := strings.LastIndex(part, ":"); idx >= 0 {
+			n, err := strconv.Atoi(strings.TrimSpace(part[idx+1:]))
+			if err != nil || n < 1 {
+				return StrategyTarget{}, errors.Errorf("invalid count of strategy %q", part)
+			}
+			part, count = part[:idx], n
+		}
+		strategy, err := ParsePuzzleStrategy(part)
+		if err != nil {
+			return StrategyTarget{}, errors.WithStack(err)
+		}
+		target.Require[strategy] = count
+	}
+	for strategy := range target.Require {
+		if target.Forbid.Has(strategy) {
+			return StrategyTarget{}, errors.Errorf("strategy %s is required and forbidden", strategy)
+		}
+	}
+	return target, nil
+}
+
+// String returns the target in the format of ParseStrategyTarget with sorted strategies and short names, so the equal
+// targets have the same string.
+func (t StrategyTarget) String() string {
+	var required []PuzzleStrategy
+	for strategy, count := range t.Require {
+		if count > 0 {
+			required = append(required, strategy)
+		}
+	}
+	sort.Slice(required, func(i, j int) bool {
+		return required[i] < required[j]
+	})
+	var parts []string
+	for _, strategy := range required {
+		part := strategyName(strategy.String())
+		if count := t.Require[strategy]; count > 1 {
+			part = fmt.Sprintf("%s:%d", part, count)
+		}
+		parts = append(parts, part)
+	}
+	for strategy := StrategyNakedSingle; strategy <= t.Forbid; strategy <<= 1 {
+		if t.Forbid.Has(strategy) {
+			parts = append(parts, "-"+strategyName(strategy.String()))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Strategies returns the strategies the solver may use to reach the target: all known strategies except the
+// forbidden ones.
+func (t StrategyTarget) Strategies() PuzzleStrategy {
+	return PuzzleLevelDemon.Strategies() &^ t.Forbid
+}
+
+// Satisfied reports whether the logical solution with the counted steps reaches the target. The solution must be
+// found by Strategies.
+func (t StrategyTarget) Satisfied(count StrategyCount) bool {
+	if count.Strategies()&t.Forbid != 0 {
+		return false
+	}
+	for strategy, need := range t.Require {
+		if count[strategy] < need {
+			return false
+		}
+	}
+	return true
+}
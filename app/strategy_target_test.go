@@ -0,0 +1,88 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStrategyTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         string
+		want       StrategyTarget
+		wantString string
+		wantErr    bool
+	}{
+		{name: "empty", in: "", want: StrategyTarget{Require: map[PuzzleStrategy]int{}}},
+		{
+			name:       "need",
+			in:         "X-Wing",
+			want:       StrategyTarget{Require: map[PuzzleStrategy]int{StrategyXWing: 1}},
+			wantString: "xwing",
+		},
+		{
+			name:       "count and forbid",
+			in:         " -pointing pair, hidden triple:2 ,xwing",
+			want:       StrategyTarget{Require: map[PuzzleStrategy]int{StrategyHiddenTriple: 2, StrategyXWing: 1}, Forbid: StrategyPointingPair},
+			wantString: "hiddentriple:2,xwing,-pointingpair",
+		},
+		{
+			name:       "slash",
+			in:         "Box/Line Reduction Pair,-NakedQuad,-HiddenQuad",
+			want:       StrategyTarget{Require: map[PuzzleStrategy]int{StrategyBoxLineReductionPair: 1}, Forbid: StrategyNakedQuad | StrategyHiddenQuad},
+			wantString: "boxlinereductionpair,-nakedquad,-hiddenquad",
+		},
+		{name: "unknown strategy", in: "swordfish", wantErr: true},
+		{name: "invalid count", in: "xwing:0", wantErr: true},
+		{name: "required and forbidden", in: "xwing,-xwing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStrategyTarget(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStrategyTarget() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseStrategyTarget() = %+v, want = %+v", got, tt.want)
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("String() = %q, want = %q", got.String(), tt.wantString)
+			}
+			again, err := ParseStrategyTarget(got.String())
+			if err != nil || !reflect.DeepEqual(again, got) {
+				t.Errorf("String() round trip got = %+v (%v), want = %+v", again, err, got)
+			}
+		})
+	}
+}
+
+func TestStrategyTarget_Satisfied(t *testing.T) {
+	target := StrategyTarget{
+		Require: map[PuzzleStrategy]int{StrategyHiddenTriple: 2},
+		Forbid:  StrategyPointingPair,
+	}
+	tests := []struct {
+		name  string
+		count StrategyCount
+		want  bool
+	}{
+		{name: "reached", count: StrategyCount{StrategyNakedSingle: 30, StrategyHiddenTriple: 2}, want: true},
+		{name: "not enough", count: StrategyCount{StrategyNakedSingle: 30, StrategyHiddenTriple: 1}},
+		{name: "not used", count: StrategyCount{StrategyNakedSingle: 30}},
+		{name: "forbidden", count: StrategyCount{StrategyHiddenTriple: 3, StrategyPointingPair: 1}},
+		{name: "forbidden is not counted", count: StrategyCount{StrategyHiddenTriple: 3, StrategyPointingPair: 0}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := target.Satisfied(tt.count); got != tt.want {
+				t.Errorf("Satisfied() = %t, want = %t", got, tt.want)
+			}
+		})
+	}
+	if got := target.Strategies(); got.Has(StrategyPointingPair) || !got.Has(StrategyXWing) {
+		t.Errorf("Strategies() = %b, want all except Pointing Pair", got)
+	}
+}
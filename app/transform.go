@@ -0,0 +1,140 @@
+package app
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+// PuzzleTransformOp is a transformation of PuzzleGenerator.
+type PuzzleTransformOp string
+
+const (
+	TransformSwapLines    PuzzleTransformOp = "l"
+	TransformSwapBigLines PuzzleTransformOp = "b"
+	TransformRotate       PuzzleTransformOp = "r"
+	TransformReflect      PuzzleTransformOp = "f"
+	TransformSwapDigits   PuzzleTransformOp = "d"
+)
+
+// PuzzleTransformStep is one call of the transformation of PuzzleGenerator.
+type PuzzleTransformStep struct {
+	Op PuzzleTransformOp
+	// Dir is the direction of TransformSwapLines and TransformSwapBigLines.
+	Dir DirectionType
+	// A and B are the lines or the digits to swap. A is the RotationType or the ReflectionType.
+	A, B int
+}
+
+// PuzzleTransform is the transformation of the stored puzzle to present it in the game, so one puzzle looks different
+// in different games. The state of the game is kept transformed. The empty transform keeps the puzzle as it is.
+type PuzzleTransform []PuzzleTransformStep
+
+// Apply transforms the puzzle step by step.
+//
+// Errors: unknown.
+func (t PuzzleTransform) Apply(generator PuzzleGenerator) error {
+	for _, step := range t {
+		var err error
+		switch step.Op {
+		case TransformSwapLines:
+			err = generator.SwapLines(step.Dir, step.A, step.B)
+		case TransformSwapBigLines:
+			err = generator.SwapBigLines(step.Dir, step.A, step.B)
+		case TransformRotate:
+			err = generator.Rotate(RotationType(step.A))
+		case TransformReflect:
+			err = generator.Reflect(ReflectionType(step.A))
+		case TransformSwapDigits:
+			err = generator.SwapDigits(uint8(step.A), uint8(step.B))
+		default:
+			err = errors.Errorf("unknown transform %q", step.Op)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to transform %s", step)
+		}
+	}
+	return nil
+}
+
+func (s PuzzleTransformStep) String() string {
+	return fmt.Sprintf("%s%d:%d:%d", s.Op, s.Dir, s.A, s.B)
+}
+
+// TransformPuzzle returns the copy of the puzzle with the transformed clues, candidates and solution.
+//
+// Errors: ErrorPuzzleTypeUnknown, unknown.
+func TransformPuzzle(library PuzzleLibrary, puzzle *Puzzle, transform PuzzleTransform) (*Puzzle, error) {
+	if len(transform) == 0 {
+		return puzzle, nil
+	}
+	out := *puzzle
+	clues, err := library.GetGenerator(puzzle.Type, puzzle.Clues)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := transform.Apply(clues); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	solution, err := library.GetGenerator(puzzle.Type, puzzle.Solution)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := transform.Apply(solution); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	out.Clues, out.Candidates, out.Solution = clues.String(), clues.GetCandidates(), solution.String()
+	return &out, nil
+}
+
+func (t PuzzleTransform) RedisArg() interface{} {
+	parts := make([]string, len(t))
+	for i, step := range t {
+		parts[i] = step.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (t *PuzzleTransform) RedisScan(src interface{}) error {
+	if t == nil {
+		return fmt.Errorf("nil pointer")
+	}
+	var str string
+	switch src := src.(type) {
+	case string:
+		str = src
+	case []uint8:
+		str = string(src)
+	default:
+		return fmt.Errorf("cannot convert from %T to %T", src, t)
+	}
+	*t = nil
+	if str == "" {
+		return nil
+	}
+	for _, part := range strings.Split(str, ",") {
+		if len(part) < 2 {
+			return fmt.Errorf("invalid puzzle transform %q", str)
+		}
+		args := strings.Split(part[1:], ":")
+		if len(args) != 3 {
+			return fmt.Errorf("invalid puzzle transform %q", str)
+		}
+		var nums [3]int
+		for i, arg := range args {
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("invalid puzzle transform %q", str)
+			}
+			nums[i] = n
+		}
+		*t = append(*t, PuzzleTransformStep{
+			Op:  PuzzleTransformOp(part[:1]),
+			Dir: DirectionType(nums[0]),
+			A:   nums[1],
+			B:   nums[2],
+		})
+	}
+	return nil
+}
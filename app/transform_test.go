@@ -0,0 +1,49 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPuzzleTransform_Redis(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    PuzzleTransform
+		wantErr bool
+	}{
+		{name: "empty", in: "", want: nil},
+		{
+			name: "steps",
+			in:   "l1:3:5,b0:2:0,r0:1:0,f0:3:0,d0:9:4",
+			want: PuzzleTransform{
+				{Op: TransformSwapLines, Dir: Vertical, A: 3, B: 5},
+				{Op: TransformSwapBigLines, Dir: Horizontal, A: 2, B: 0},
+				{Op: TransformRotate, A: int(RotateTo90)},
+				{Op: TransformReflect, A: int(ReflectMajorDiagonal)},
+				{Op: TransformSwapDigits, A: 9, B: 4},
+			},
+		},
+		{name: "no args", in: "l1:3", wantErr: true},
+		{name: "empty step", in: "l1:3:5,", wantErr: true},
+		{name: "not a number", in: "lx:3:5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got PuzzleTransform
+			err := got.RedisScan([]byte(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RedisScan() error = %v, wantErr = %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RedisScan() = %+v, want = %+v", got, tt.want)
+			}
+			if arg := got.RedisArg(); arg != tt.in {
+				t.Errorf("RedisArg() = %v, want = %s", arg, tt.in)
+			}
+		})
+	}
+}
@@ -116,6 +116,7 @@ type UserPreferences struct {
 	PuzzleType        PuzzleType  `json:"puzzle_type" redis:"puzzle_type"`
 	PuzzleLevel       PuzzleLevel `json:"puzzle_level" redis:"puzzle_level"`
 	CandidatesAtStart bool        `json:"candidates_at_start" redis:"candidates_at_start"`
+	Symmetric         bool        `json:"symmetric" redis:"symmetric"`
 	UseHighlights     bool        `json:"use_highlights" redis:"use_highlights"`
 	ShowCandidates    bool        `json:"show_candidates" redis:"show_candidates"`
 	ShowWrongs        bool        `json:"show_wrongs" redis:"show_wrongs"`
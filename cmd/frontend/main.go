@@ -47,7 +47,18 @@ func main() {
 	pages.Path(app.EndpointSignup).Methods(http.MethodGet, http.MethodPost).HandlerFunc(srv.HandleSignup)
 	logoutPage.Path(app.EndpointLogout).Methods(http.MethodGet).HandlerFunc(srv.HandleLogout)
 	authPages.Path(app.EndpointSettings).Methods(http.MethodGet, http.MethodPost).HandlerFunc(srv.HandleSettings)
+	authPages.Path(app.EndpointStats).Methods(http.MethodGet).HandlerFunc(srv.HandleStats)
+	authPages.Path(app.EndpointStatsJSON).Methods(http.MethodGet).HandlerFunc(srv.HandleStatsJSON)
+	pages.Path(app.EndpointDaily).Methods(http.MethodGet).HandlerFunc(srv.HandleDaily)
+	pages.Path(app.EndpointLeaderboards).Methods(http.MethodGet).HandlerFunc(srv.HandleLeaderboards)
 	pages.Path(app.EndpointGameID{}.MuxPath()).Methods(http.MethodGet).HandlerFunc(srv.HandleGameID)
+	pages.Path(app.EndpointGameReplay{}.MuxPath()).Methods(http.MethodGet).HandlerFunc(srv.HandleGameReplay)
+	pages.Path(app.EndpointGameJoin{}.MuxPath()).Methods(http.MethodGet).HandlerFunc(srv.HandleGameJoin)
+	pages.Path(app.EndpointGameWatch{}.MuxPath()).Methods(http.MethodGet).HandlerFunc(srv.HandleGameWatch)
+	pages.Path(app.EndpointRace{}.MuxPath()).Methods(http.MethodGet).HandlerFunc(srv.HandleRace)
+	pages.Path(app.EndpointDrill).Methods(http.MethodGet, http.MethodPost).HandlerFunc(srv.HandleDrill)
+	pages.Path(app.EndpointDrillID{}.MuxPath()).Methods(http.MethodGet).HandlerFunc(srv.HandleDrillID)
+	pages.Path(app.EndpointDrillID{}.MuxPath()).Methods(http.MethodPost).HandlerFunc(srv.HandleDrillAnswer)
 	pages.Path(app.EndpointGameWs).Methods(http.MethodGet).HandlerFunc(srv.HandleGameWs)
 
 	mwChainError := func(next http.Handler) http.Handler {
@@ -1,18 +1,58 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"github.com/cnblvr/puzzles/app"
 	"github.com/cnblvr/puzzles/internal/generator"
 	"github.com/rs/zerolog/log"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
+	load := flag.String("load", "", "load the puzzles of the file with one puzzle per line into the pool and exit")
+	loadType := flag.String("type", app.PuzzleSudokuClassic.String(), "type of the loaded puzzles")
+	backfill := flag.Bool("backfill", false, "add the canonical forms of the stored puzzles to the index of duplicates and exit")
+	flag.Parse()
+
+	// SIGTERM of docker or Ctrl+C stops the generation, the service exits after the workers stop
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
 	srv, err := generator.NewService()
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create generator service")
 	}
 
+	if *load != "" {
+		file, err := os.Open(*load)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open puzzles")
+		}
+		defer file.Close()
+		report, err := srv.Load(ctx, app.PuzzleType(*loadType), file)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load puzzles")
+		}
+		log.Info().Int("read", report.Read).Int("invalid", report.Invalid).Int("unrated", report.Unrated).
+			Int("duplicates", report.Duplicates).Interface("created", report.Created).Msg("puzzles loaded")
+		return
+	}
+
+	if *backfill {
+		report, err := srv.BackfillCanonical(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to backfill canonical forms")
+		}
+		log.Info().Int("read", report.Read).Int("indexed", report.Indexed).Int("duplicates", report.Duplicates).
+			Msg("canonical forms backfilled")
+		return
+	}
+
 	log.Info().Str("name", "generator").Msgf("service started...")
-	if err := srv.Run(); err != nil {
+	if err := srv.Run(ctx); err != nil {
 		log.Fatal().Err(err).Msg("failed to run service")
 	}
 }
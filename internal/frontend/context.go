@@ -55,6 +55,18 @@ func FromContextServiceFrontendOrNil(ctx context.Context) *service {
 	return srv
 }
 
+func NewContextWsConnection(ctx context.Context, conn *wsConnection) context.Context {
+	return context.WithValue(ctx, "ws_connection", conn)
+}
+
+func FromContextWsConnectionOrNil(ctx context.Context) *wsConnection {
+	conn, ok := ctx.Value("ws_connection").(*wsConnection)
+	if !ok {
+		return nil
+	}
+	return conn
+}
+
 func NewContextNotification(ctx context.Context, notification *app.CookieNotification) context.Context {
 	return context.WithValue(ctx, "notification", notification)
 }
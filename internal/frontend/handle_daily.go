@@ -0,0 +1,89 @@
+package frontend
+
+import (
+	"github.com/cnblvr/puzzles/app"
+	"github.com/cnblvr/puzzles/internal/frontend/templates"
+	"net/http"
+	"time"
+)
+
+type RenderDataDaily struct {
+	Date         string
+	Streak       *app.DailyStreak
+	Leaderboards []preparedDailyLeaderboard
+	ErrorMessage string
+}
+
+type preparedDailyLeaderboard struct {
+	Level   string
+	Entries []preparedDailyLeaderboardEntry
+}
+
+type preparedDailyLeaderboardEntry struct {
+	app.DailyLeaderboardEntry
+	Elapsed string
+	Current bool
+}
+
+// dailyLevels are the levels of daily puzzles.
+var dailyLevels = []listItem{
+	{ID: string(app.PuzzleLevelEasy), Name: "Easy"},
+	{ID: string(app.PuzzleLevelNormal), Name: "Normal"},
+	{ID: string(app.PuzzleLevelHard), Name: "Hard"},
+	{ID: string(app.PuzzleLevelHarder), Name: "Harder"},
+}
+
+// HandleDaily shows the leaderboards of the daily puzzles. The date is today or the one from the query parameter
+// 'date'.
+func (srv *service) HandleDaily(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+	renderData := RenderDataDaily{
+		Date: app.DailyDate(time.Now()),
+	}
+
+	if date := r.URL.Query().Get("date"); date != "" {
+		if _, err := time.Parse(app.DailyDateLayout, date); err != nil {
+			srv.setCookieNotificationToResponse(w, app.NotificationWarning, "Incorrect date.")
+			http.Redirect(w, r, app.EndpointDaily, http.StatusSeeOther)
+			return
+		}
+		renderData.Date = date
+	}
+	log = log.With().Str("date", renderData.Date).Logger()
+
+	if session.UserID > 0 {
+		streak, err := srv.puzzleRepository.GetUserDailyStreak(ctx, session.UserID)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to get daily streak")
+		} else {
+			renderData.Streak = streak
+		}
+	}
+
+	for _, level := range dailyLevels {
+		entries, err := srv.puzzleRepository.GetDailyLeaderboard(ctx, renderData.Date,
+			app.PuzzleSudokuClassic, app.PuzzleLevel(level.ID), app.DefaultDailyLeaderboardSize)
+		if err != nil {
+			log.Error().Err(err).Str("puzzle_level", level.ID).Msg("failed to get daily leaderboard")
+			renderData.ErrorMessage = "Internal Server Error."
+			break
+		}
+		leaderboard := preparedDailyLeaderboard{Level: level.Name}
+		for _, entry := range entries {
+			entry.Username = srv.username(ctx, entry.UserID)
+			leaderboard.Entries = append(leaderboard.Entries, preparedDailyLeaderboardEntry{
+				DailyLeaderboardEntry: entry,
+				Elapsed:               formatStatsDuration(entry.Elapsed),
+				Current:               entry.UserID == session.UserID,
+			})
+		}
+		renderData.Leaderboards = append(renderData.Leaderboards, leaderboard)
+	}
+
+	srv.executeTemplate(ctx, w, templates.PageDaily, func(params *templates.Params) {
+		params.Header.Title = "Daily puzzles"
+		params.Header.CssInternal = append(params.Header.CssInternal, cssStats)
+		params.Data = renderData
+	})
+}
@@ -0,0 +1,250 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/cnblvr/puzzles/internal/frontend/static"
+	"github.com/cnblvr/puzzles/internal/frontend/templates"
+	"github.com/pkg/errors"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// drillPuzzles is the number of random puzzles searched for the position of the strategy before the drill is given up.
+const drillPuzzles = 10
+
+type PostDrill struct {
+	Strategy app.PuzzleStrategy
+}
+
+func (p PostDrill) Parse(r *http.Request) PostDrill {
+	p.Strategy, _ = app.ParsePuzzleStrategy(r.PostFormValue("strategy"))
+	return p
+}
+
+func (p *PostDrill) Validate() string {
+	if p.Strategy == app.StrategyUnknown || !app.DrillStrategies.Has(p.Strategy) {
+		return "Strategy is not chosen."
+	}
+	return ""
+}
+
+type RenderDataDrill struct {
+	Strategies   []listItem
+	ErrorMessage string
+}
+
+// HandleDrill shows the strategies of the drills and creates the drill of the chosen strategy.
+func (srv *service) HandleDrill(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+	renderData := RenderDataDrill{}
+
+	post := PostDrill{}
+	if r.Method == http.MethodPost {
+		post = post.Parse(r)
+	}
+	for strategy := app.StrategyNakedSingle; strategy <= app.DrillStrategies; strategy <<= 1 {
+		if app.DrillStrategies.Has(strategy) {
+			renderData.Strategies = append(renderData.Strategies, listItem{
+				ID:      strategy.String(),
+				Name:    strategy.String(),
+				Default: strategy == post.Strategy,
+			})
+		}
+	}
+
+	if r.Method == http.MethodPost {
+		func() {
+			renderData.ErrorMessage = post.Validate()
+			if renderData.ErrorMessage != "" {
+				return
+			}
+			log = log.With().Stringer("strategy", post.Strategy).Logger()
+
+			position, err := srv.findDrill(ctx, app.PuzzleSudokuClassic, post.Strategy)
+			switch {
+			case errors.Is(err, app.ErrorPuzzlePoolEmpty), errors.Is(err, app.ErrorDrillNotFound):
+				log.Warn().Err(err).Msg("drill not found")
+				renderData.ErrorMessage = "No position of this strategy is found yet. Try again later."
+				return
+			case errors.Is(err, context.DeadlineExceeded):
+				log.Warn().Err(err).Msg("drill search timed out")
+				renderData.ErrorMessage = "The search of the position takes too long. Try again later."
+				return
+			case err == nil:
+			default:
+				log.Error().Err(err).Msg("failed to find drill")
+				renderData.ErrorMessage = "Internal Server Error."
+				return
+			}
+			drill, err := srv.puzzleRepository.CreateDrill(ctx, app.CreateDrillParams{
+				Session:       session,
+				DrillPosition: *position,
+			})
+			if err != nil {
+				log.Error().Err(err).Msg("failed to create drill")
+				renderData.ErrorMessage = "Internal Server Error."
+				return
+			}
+			http.Redirect(w, r, app.EndpointDrillID{}.Path(drill.ID), http.StatusSeeOther)
+		}()
+		if renderData.ErrorMessage == "" {
+			return
+		}
+	}
+
+	srv.executeTemplate(ctx, w, templates.PageDrill, func(params *templates.Params) {
+		params.Header.Title = "Drills"
+		params.Data = renderData
+	})
+}
+
+// findDrill returns a random position of the strategy from the solutions of random puzzles. The puzzles of the
+// practice pool of the strategy are preferred. The search is limited by app.DefaultDrillSearchTimeout.
+//
+// Errors: app.ErrorPuzzlePoolEmpty, app.ErrorDrillNotFound, context.Canceled, context.DeadlineExceeded, unknown.
+func (srv *service) findDrill(ctx context.Context, typ app.PuzzleType, strategy app.PuzzleStrategy) (*app.DrillPosition, error) {
+	ctx, cancel := context.WithTimeout(ctx, app.DefaultDrillSearchTimeout)
+	defer cancel()
+	practice := &app.StrategyTarget{Require: map[app.PuzzleStrategy]int{strategy: 1}}
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < drillPuzzles; i++ {
+		puzzle, err := srv.puzzleRepository.GetRandomPuzzle(ctx, typ, strategy.Level(), practice)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		positions, err := app.FindDrills(ctx, srv.puzzleLibrary, puzzle, strategy)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if len(positions) > 0 {
+			return &positions[rnd.Intn(len(positions))], nil
+		}
+	}
+	return nil, errors.WithStack(app.ErrorDrillNotFound)
+}
+
+type RenderDataDrillID struct {
+	DrillID    string
+	Strategy   string
+	State      string
+	Candidates string
+	// Single is true if the answer is the digit to set, otherwise it is the candidates to delete.
+	Single bool
+}
+
+// HandleDrillID shows the position of the drill.
+func (srv *service) HandleDrillID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	drill, ok := srv.drillFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	srv.executeTemplate(ctx, w, templates.PageDrillID, func(params *templates.Params) {
+		params.Header.Title = "Drill: " + drill.Strategy.String()
+		params.Header.CssExternal = append(params.Header.CssExternal, static.CssSudoku)
+		params.Data = RenderDataDrillID{
+			DrillID:    drill.ID.String(),
+			Strategy:   drill.Strategy.String(),
+			State:      drill.State,
+			Candidates: drill.Candidates,
+			Single:     drill.Answer.Set != nil,
+		}
+		params.Footer.JsExternal = append(params.Footer.JsExternal, static.JsDrill)
+	})
+}
+
+type drillAnswerReply struct {
+	Correct bool `json:"correct"`
+	// Counted is false if the drill was answered before, so the answer is not counted in the stats.
+	Counted          bool            `json:"counted"`
+	Answer           app.DrillAnswer `json:"answer"`
+	CandidateChanges json.RawMessage `json:"candidateChanges"`
+	Description      string          `json:"description"`
+}
+
+// HandleDrillAnswer checks the answer of the player against the step of the drill and returns the step.
+func (srv *service) HandleDrillAnswer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := FromContextLogger(ctx)
+
+	var answer app.DrillAnswer
+	if err := json.NewDecoder(r.Body).Decode(&answer); err != nil {
+		log.Warn().Err(err).Msg("failed to decode drill answer")
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	drill, ok := srv.drillFromRequest(w, r)
+	if !ok {
+		return
+	}
+	log = log.With().Stringer("drill_id", drill.ID).Logger()
+
+	rpl := drillAnswerReply{
+		Correct:          drill.Answer.Equal(answer),
+		Counted:          true,
+		Answer:           drill.Answer,
+		CandidateChanges: json.RawMessage(drill.Changes),
+		Description:      drill.Description,
+	}
+	switch err := srv.puzzleRepository.AnswerDrill(ctx, drill, rpl.Correct); {
+	case errors.Is(err, app.ErrorDrillAnswered):
+		rpl.Counted = false
+	case err == nil:
+	default:
+		log.Error().Err(err).Msg("failed to answer drill")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rpl); err != nil {
+		log.Error().Err(err).Msg("failed to encode drill answer")
+	}
+}
+
+// drillFromRequest returns the drill of the path if it belongs to the session. Otherwise, the error is written to
+// the response.
+func (srv *service) drillFromRequest(w http.ResponseWriter, r *http.Request) (*app.Drill, bool) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+
+	redirect := func(msg string) {
+		if r.Method == http.MethodGet {
+			srv.setCookieNotificationToResponse(w, app.NotificationError, msg)
+			http.Redirect(w, r, app.EndpointDrill, http.StatusSeeOther)
+			return
+		}
+		http.Error(w, msg, http.StatusNotFound)
+	}
+
+	drillID, err := app.EndpointDrillID{}.MuxParse(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("incorrect drill id")
+		redirect("Incorrect drill id.")
+		return nil, false
+	}
+	drill, err := srv.puzzleRepository.GetDrill(ctx, drillID)
+	switch {
+	case errors.Is(err, app.ErrorDrillNotFound):
+		log.Info().Stringer("drill_id", drillID).Msg("drill not found")
+		redirect("Drill not found.")
+		return nil, false
+	case err == nil:
+	default:
+		log.Error().Err(err).Stringer("drill_id", drillID).Msg("failed to get drill")
+		redirect("Internal server error.")
+		return nil, false
+	}
+	if err := drill.ValidateSession(session); err != nil {
+		log.Info().Stringer("drill_id", drillID).Msg("drill is not available")
+		redirect("This drill is not available to you.")
+		return nil, false
+	}
+	return drill, true
+}
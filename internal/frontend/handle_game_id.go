@@ -13,6 +13,11 @@ type RenderDataGameID struct {
 	UseHighlights  bool
 	ShowCandidates bool
 	ShowWrongs     bool
+	// IsOwner allows inviting other players to the game.
+	IsOwner bool
+	// CanPublish allows the owner to give the spectator link of the game, Public is the current state.
+	CanPublish bool
+	Public     bool
 }
 
 func (srv *service) HandleGameID(w http.ResponseWriter, r *http.Request) {
@@ -48,12 +53,26 @@ func (srv *service) HandleGameID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := game.ValidateSession(session); err != nil {
-		log.Info().Err(err).Send()
+	role, err := srv.gameRole(ctx, game, session)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get role in puzzle game")
+		srv.setCookieNotificationToResponse(w, app.NotificationError, "Internal server error.")
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+	switch role {
+	case app.GameRoleNone:
+		log.Info().Msg("puzzle game is not available")
 		srv.setCookieNotificationToResponse(w, app.NotificationError, "This game is not available to you.")
 		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
 		return
+	case app.GameRoleSpectator:
+		http.Redirect(w, r, app.EndpointGameWatch{}.Path(gameID), http.StatusSeeOther)
+		return
 	}
+	renderData.IsOwner = role == app.GameRoleOwner && !game.IsWin
+	renderData.CanPublish = role == app.GameRoleOwner
+	renderData.Public = game.Public
 
 	if session.UserID > 0 {
 		up, err := srv.userRepository.GetUserPreferences(ctx, session.UserID)
@@ -0,0 +1,73 @@
+package frontend
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// HandleGameJoin adds the player to the shared game by the invite link and redirects to the game.
+func (srv *service) HandleGameJoin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+
+	gameID, code, err := app.EndpointGameJoin{}.MuxParse(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("incorrect invite link")
+		srv.setCookieNotificationToResponse(w, app.NotificationWarning, "Incorrect invite link.")
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+	log = log.With().Stringer("game_id", gameID).Logger()
+
+	game, err := srv.puzzleRepository.GetPuzzleGame(ctx, gameID)
+	if err != nil {
+		msg := "Internal server error."
+		if errors.Is(err, app.ErrorPuzzleGameNotFound) {
+			log.Error().Msg("puzzle game not found")
+			msg = "Game not found."
+		} else {
+			log.Error().Err(err).Msg("failed to get puzzle game")
+		}
+		srv.setCookieNotificationToResponse(w, app.NotificationError, msg)
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+
+	if game.ValidateSession(session) != nil {
+		if !game.Shared || subtle.ConstantTimeCompare([]byte(game.InviteCode), []byte(code)) != 1 {
+			log.Info().Msg("invalid invite code")
+			srv.setCookieNotificationToResponse(w, app.NotificationError, "The invite link is not valid.")
+			http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+			return
+		}
+		if err := srv.puzzleRepository.AddPuzzleGameMember(ctx, gameID, session); err != nil {
+			log.Error().Err(err).Msg("failed to add puzzle game member")
+			srv.setCookieNotificationToResponse(w, app.NotificationError, "Internal server error.")
+			http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+			return
+		}
+		log.Info().Msg("joined shared game")
+		srv.notify(&app.Session{CookieSession: app.CookieSession{UserID: game.UserID, SessionID: game.SessionID}}, app.NotificationSuccess,
+			srv.playerName(ctx, session)+" joined your game.")
+	}
+
+	http.Redirect(w, r, app.EndpointGameID{}.Path(gameID), http.StatusSeeOther)
+}
+
+// gameRole returns the role of the session in the game. Membership is checked only for shared games.
+//
+// Errors: unknown.
+func (srv *service) gameRole(ctx context.Context, game *app.PuzzleGame, session *app.Session) (app.GameRole, error) {
+	member := false
+	if game.Shared && game.ValidateSession(session) != nil {
+		var err error
+		member, err = srv.puzzleRepository.IsPuzzleGameMember(ctx, game.ID, session)
+		if err != nil {
+			return app.GameRoleNone, errors.WithStack(err)
+		}
+	}
+	return game.Role(session, member), nil
+}
@@ -0,0 +1,73 @@
+package frontend
+
+import (
+	"github.com/cnblvr/puzzles/app"
+	"github.com/cnblvr/puzzles/internal/frontend/static"
+	"github.com/cnblvr/puzzles/internal/frontend/templates"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+type RenderDataGameReplay struct {
+	GameID   string
+	PuzzleID int64
+}
+
+func (srv *service) HandleGameReplay(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+	renderData := RenderDataGameReplay{}
+
+	gameID, err := app.EndpointGameReplay{}.MuxParse(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("incorrect game_id")
+		srv.setCookieNotificationToResponse(w, app.NotificationWarning, "Incorrect game id.")
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+	log = log.With().Stringer("game_id", gameID).Logger()
+	renderData.GameID = gameID.String()
+
+	game, err := srv.puzzleRepository.GetPuzzleGame(ctx, gameID)
+	if err != nil {
+		msg := "Internal server error."
+		if errors.Is(err, app.ErrorPuzzleGameNotFound) {
+			log.Error().Msg("puzzle game not found")
+			msg = "Game not found."
+		} else {
+			log.Error().Err(err).Msg("failed to get puzzle game")
+		}
+		srv.setCookieNotificationToResponse(w, app.NotificationError, msg)
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+
+	// spectators of the public game watch the replay too
+	switch role, err := srv.gameRole(ctx, game, session); {
+	case err != nil:
+		log.Error().Err(err).Msg("failed to get role in puzzle game")
+		srv.setCookieNotificationToResponse(w, app.NotificationError, "Internal server error.")
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	case role == app.GameRoleNone:
+		log.Info().Msg("puzzle game is not available")
+		srv.setCookieNotificationToResponse(w, app.NotificationError, "This game is not available to you.")
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+
+	if !game.IsWin {
+		srv.setCookieNotificationToResponse(w, app.NotificationWarning, "The replay is available after the win.")
+		http.Redirect(w, r, app.EndpointGameID{}.Path(gameID), http.StatusSeeOther)
+		return
+	}
+
+	renderData.PuzzleID = game.PuzzleID
+
+	srv.executeTemplate(ctx, w, templates.PageGameReplay, func(params *templates.Params) {
+		params.Header.Title = "Game replay"
+		params.Header.CssExternal = append(params.Header.CssExternal, static.CssSudoku)
+		params.Data = renderData
+		params.Footer.JsExternal = append(params.Footer.JsExternal, static.JsWs, static.JsSudoku)
+	})
+}
@@ -0,0 +1,74 @@
+package frontend
+
+import (
+	"github.com/cnblvr/puzzles/app"
+	"github.com/cnblvr/puzzles/internal/frontend/static"
+	"github.com/cnblvr/puzzles/internal/frontend/templates"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+type RenderDataGameWatch struct {
+	GameID string
+}
+
+// HandleGameWatch shows the public game to spectators. The board is updated live, and the finished game is shown as
+// the replay.
+func (srv *service) HandleGameWatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+	renderData := RenderDataGameWatch{}
+
+	gameID, err := app.EndpointGameWatch{}.MuxParse(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("incorrect game_id")
+		srv.setCookieNotificationToResponse(w, app.NotificationWarning, "Incorrect game id.")
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+	log = log.With().Stringer("game_id", gameID).Logger()
+	renderData.GameID = gameID.String()
+
+	game, err := srv.puzzleRepository.GetPuzzleGame(ctx, gameID)
+	if err != nil {
+		msg := "Internal server error."
+		if errors.Is(err, app.ErrorPuzzleGameNotFound) {
+			log.Error().Msg("puzzle game not found")
+			msg = "Game not found."
+		} else {
+			log.Error().Err(err).Msg("failed to get puzzle game")
+		}
+		srv.setCookieNotificationToResponse(w, app.NotificationError, msg)
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+
+	role, err := srv.gameRole(ctx, game, session)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get role in puzzle game")
+		srv.setCookieNotificationToResponse(w, app.NotificationError, "Internal server error.")
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+	switch {
+	case role == app.GameRoleNone:
+		log.Info().Msg("puzzle game is not public")
+		srv.setCookieNotificationToResponse(w, app.NotificationError, "This game is not available to you.")
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	case game.IsWin:
+		http.Redirect(w, r, app.EndpointGameReplay{}.Path(gameID), http.StatusSeeOther)
+		return
+	case role >= app.GameRolePlayer:
+		// players of the game play it instead of watching
+		http.Redirect(w, r, app.EndpointGameID{}.Path(gameID), http.StatusSeeOther)
+		return
+	}
+
+	srv.executeTemplate(ctx, w, templates.PageGameWatch, func(params *templates.Params) {
+		params.Header.Title = "Watch the game"
+		params.Header.CssExternal = append(params.Header.CssExternal, static.CssSudoku)
+		params.Data = renderData
+		params.Footer.JsExternal = append(params.Footer.JsExternal, static.JsWs, static.JsSudoku)
+	})
+}
@@ -7,11 +7,16 @@ import (
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
 	"net/http"
+	"time"
 )
 
+// websocketMessage is the envelope of all messages. A reply to the request of the client has the Method of the
+// request. A message pushed by the server has the Event instead.
 type websocketMessage struct {
-	Method    string          `json:"method"`
+	Method    string          `json:"method,omitempty"`
+	Event     string          `json:"event,omitempty"`
 	Echo      string          `json:"echo,omitempty"`
 	Error     string          `json:"error,omitempty"`
 	ErrorCode uint16          `json:"errorCode,omitempty"`
@@ -32,13 +37,18 @@ func (srv *service) HandleGameWs(w http.ResponseWriter, r *http.Request) {
 		log.Error().Err(err).Msg("failed to upgrade client")
 		return
 	}
-	defer conn.Close()
 	log.Info().Msg("ws connection opened")
+	wsConn := newWsConnection(conn, session, srv.playerName(ctx, session), log)
+	defer wsConn.close()
+	go wsConn.writeLoop()
+	defer srv.leaveGames(log, wsConn)
+	srv.gameHub.subscribe(wsTopicNotifications(session), wsConn)
 	for {
 		ctx := context.Background()
 		ctx = NewContextLogger(ctx, log)
 		ctx = NewContextSession(ctx, session)
 		ctx = NewContextServiceFrontend(ctx, srv)
+		ctx = NewContextWsConnection(ctx, wsConn)
 		var req websocketMessage
 		mType, reqBts, err := conn.ReadMessage()
 		if err != nil {
@@ -75,17 +85,58 @@ func (srv *service) HandleGameWs(w http.ResponseWriter, r *http.Request) {
 				resp.Error, resp.ErrorCode = status.Error(), app.StatusUnknown.GetCode()
 			}
 		}
-		respBts, err := json.Marshal(resp)
+		wsConn.write(resp)
+	}
+}
+
+// leaveGames unsubscribes the closed connection from all topics and pauses timers of the games nobody plays anymore.
+func (srv *service) leaveGames(log zerolog.Logger, wsConn *wsConnection) {
+	ctx := context.Background()
+	for topic := range wsConn.topics {
+		srv.gameHub.unsubscribe(topic, wsConn)
+	}
+	for gameID := range wsConn.games {
+		srv.gameHub.publish(wsTopicGame(gameID), wsConn, "presence", wsPresenceEvent{ID: wsConn.id, Name: wsConn.name})
+		if srv.gameHub.subscribers(wsTopicGame(gameID)) > 0 {
+			continue
+		}
+		game, err := srv.puzzleRepository.GetPuzzleGame(ctx, gameID)
 		if err != nil {
-			log.Error().Err(err).Msg("failed to marshal response")
-			return
+			log.Error().Err(err).Stringer("game_id", gameID).Msg("failed to get puzzle game")
+			continue
 		}
-		log.Debug().Msgf("ws response: %s", respBts)
-		if err := conn.WriteMessage(websocket.TextMessage, respBts); err != nil {
-			log.Error().Err(err).Msg("failed to write message")
-			return
+		if !game.TimerRunning() {
+			continue
+		}
+		game.TimerPause(time.Now(), app.DefaultGameIdleTimeout)
+		if err := srv.puzzleRepository.UpdatePuzzleGame(ctx, game); err != nil {
+			log.Error().Err(err).Stringer("game_id", gameID).Msg("failed to update puzzle game")
+		}
+	}
+}
+
+// username returns the username of the user or an empty string if the user is not found.
+func (srv *service) username(ctx context.Context, userID int64) string {
+	user, err := srv.userRepository.GetUser(ctx, userID)
+	if err != nil {
+		log := FromContextLogger(ctx)
+		log.Warn().Err(err).Int64("user_id", userID).Msg("failed to get user")
+		return ""
+	}
+	return user.Username
+}
+
+// playerName returns the username of the session or "anonymous".
+func (srv *service) playerName(ctx context.Context, session *app.Session) string {
+	if session.UserID > 0 {
+		user, err := srv.userRepository.GetUser(ctx, session.UserID)
+		if err == nil {
+			return user.Username
 		}
+		log := FromContextLogger(ctx)
+		log.Warn().Err(err).Msg("failed to get user")
 	}
+	return "anonymous"
 }
 
 func websocketRequestExecute(ctx context.Context, method string, reqBody []byte) ([]byte, error) {
@@ -102,12 +153,15 @@ func websocketRequestExecute(ctx context.Context, method string, reqBody []byte)
 		if status := mw.GameMiddleware(ctx); status != nil {
 			return nil, status
 		}
+		if status := mw.validateGameRole(mw.RequiredGameRole()); status != nil {
+			return nil, status
+		}
 	}
 	if status := reqObj.Validate(ctx); status != nil {
 		return nil, status
 	}
 	respObj, status := reqObj.Execute(ctx)
-	if err != nil {
+	if status != nil {
 		return nil, status
 	}
 	respBody, err := json.Marshal(respObj)
@@ -121,10 +175,14 @@ type wsGameMiddleware struct {
 	GameID uuid.UUID `json:"game_id"`
 	puzzle *app.Puzzle
 	game   *app.PuzzleGame
+	// role is the role of the session in the game.
+	role app.GameRole
 }
 
 type wsGameMiddlewareInterface interface {
 	GameMiddleware(ctx context.Context) app.Status
+	RequiredGameRole() app.GameRole
+	validateGameRole(required app.GameRole) app.Status
 }
 
 func (m *wsGameMiddleware) GameMiddleware(ctx context.Context) app.Status {
@@ -144,6 +202,82 @@ func (m *wsGameMiddleware) GameMiddleware(ctx context.Context) app.Status {
 	default:
 		return app.StatusInternalServerError.WithError(errors.WithStack(err))
 	}
+	// all methods work with the puzzle as it is presented in the game
+	m.puzzle, err = app.TransformPuzzle(srv.puzzleLibrary, m.puzzle, m.game.Transform)
+	if err != nil {
+		return app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	m.role, err = srv.gameRole(ctx, m.game, FromContextSession(ctx))
+	if err != nil {
+		return app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	if m.role == app.GameRoleNone {
+		return app.StatusUnauthorized.WithMessage("game is not available").WithError(errors.WithStack(app.ErrorPuzzleGameNotAllowed))
+	}
+	switch err := srv.validateRaceStarted(ctx, m.game); {
+	case err == nil:
+	case errors.Is(err, app.ErrorPuzzleGameNotAllowed):
+		return app.StatusBadRequest.WithMessage("race is not started").WithError(errors.WithStack(err))
+	default:
+		return app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	if wsConn := FromContextWsConnectionOrNil(ctx); wsConn != nil {
+		// spectators get the changes of the game, but they do not keep the timer running
+		if m.role >= app.GameRolePlayer {
+			wsConn.games[m.GameID] = struct{}{}
+		}
+		srv.gameHub.subscribe(wsTopicGame(m.GameID), wsConn)
+	}
 
 	return nil
 }
+
+// RequiredGameRole returns the lowest role allowed to send the request. Requests that only read the game override it
+// with app.GameRoleSpectator.
+func (m *wsGameMiddleware) RequiredGameRole() app.GameRole {
+	return app.GameRolePlayer
+}
+
+// validateGameRole rejects the request if the role of the session in the game is lower than required.
+func (m *wsGameMiddleware) validateGameRole(required app.GameRole) app.Status {
+	if m.role >= required {
+		return nil
+	}
+	if m.role == app.GameRoleSpectator {
+		return app.StatusUnauthorized.WithMessage("spectators cannot change the game")
+	}
+	return app.StatusUnauthorized.WithMessage("not allowed in this game")
+}
+
+// validateGameNotPaused rejects the change of the paused game, so the time of the solution cannot be spent off the
+// timer. The game is resumed by the request resume.
+func (m *wsGameMiddleware) validateGameNotPaused() app.Status {
+	if m.game.TimerPaused() {
+		return app.StatusBadRequest.WithMessage("game is paused")
+	}
+	return nil
+}
+
+// updateGame applies the change to the game and saves the game if the change returns true. If another player changes
+// the game at the same time, the game is read again and the change is applied to the new version.
+func (m *wsGameMiddleware) updateGame(ctx context.Context, change func(game *app.PuzzleGame) bool) app.Status {
+	srv := FromContextServiceFrontendOrNil(ctx)
+
+	for attempt := 1; ; attempt++ {
+		if !change(m.game) {
+			return nil
+		}
+		err := srv.puzzleRepository.UpdatePuzzleGame(ctx, m.game)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, app.ErrorPuzzleGameConflict) && attempt < wsMaxConflictAttempts:
+		default:
+			return app.StatusInternalServerError.WithError(errors.WithStack(err))
+		}
+		m.game, err = srv.puzzleRepository.GetPuzzleGame(ctx, m.game.ID)
+		if err != nil {
+			return app.StatusInternalServerError.WithError(errors.WithStack(err))
+		}
+	}
+}
@@ -1,44 +1,113 @@
 package frontend
 
 import (
+	"context"
 	"fmt"
 	"github.com/cnblvr/puzzles/app"
+	"github.com/cnblvr/puzzles/internal/frontend/static"
 	"github.com/cnblvr/puzzles/internal/frontend/templates"
 	"github.com/pkg/errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type PostHome struct {
 	PuzzleType        app.PuzzleType
 	Level             app.PuzzleLevel
 	CandidatesAtStart bool
+	// Symmetric asks for the random puzzle with symmetric clues.
+	Symmetric bool
+	// Daily is true if the daily puzzle of the type and level is chosen instead of a random one.
+	Daily bool
+	// Race is true if a race lobby of the type and level is created.
+	Race bool
+	// CustomPuzzle is the puzzle typed or pasted by the user for the custom level.
+	CustomPuzzle string
+	// Practice asks for the random puzzle of the practice pool of the strategy instead of the pool of the level.
+	Practice app.PuzzleStrategy
 }
 
 func (p PostHome) Parse(r *http.Request) PostHome {
 	p.PuzzleType = app.PuzzleType(r.PostFormValue("puzzle_type"))
 	p.Level = app.PuzzleLevel(r.PostFormValue("puzzle_level"))
 	p.CandidatesAtStart, _ = strconv.ParseBool(r.PostFormValue("candidates_at_start"))
+	p.Symmetric, _ = strconv.ParseBool(r.PostFormValue("symmetric"))
+	p.Daily, _ = strconv.ParseBool(r.PostFormValue("daily"))
+	p.Race, _ = strconv.ParseBool(r.PostFormValue("race"))
+	p.CustomPuzzle = r.PostFormValue("custom_puzzle")
+	p.Practice, _ = app.ParsePuzzleStrategy(r.PostFormValue("practice"))
 	return p
 }
 
+// practiceTarget returns the strategy target of the practice pool of the chosen strategy or nil.
+func (p PostHome) practiceTarget() *app.StrategyTarget {
+	if p.Practice == app.StrategyUnknown {
+		return nil
+	}
+	return &app.StrategyTarget{Require: map[app.PuzzleStrategy]int{p.Practice: 1}}
+}
+
+// playableTypes and playableLevels are the pools of random puzzles that the home page offers.
+var (
+	playableTypes  = []app.PuzzleType{app.PuzzleSudokuClassic}
+	playableLevels = []app.PuzzleLevel{app.PuzzleLevelEasy, app.PuzzleLevelNormal, app.PuzzleLevelHard, app.PuzzleLevelHarder}
+)
+
+func isPlayableType(typ app.PuzzleType) bool {
+	for _, playable := range playableTypes {
+		if typ == playable {
+			return true
+		}
+	}
+	return false
+}
+
+func isPlayableLevel(level app.PuzzleLevel) bool {
+	for _, playable := range playableLevels {
+		if level == playable {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *PostHome) Validate() string {
-	switch p.PuzzleType {
-	case app.PuzzleSudokuClassic:
-	case app.PuzzleJigsaw, app.PuzzleWindoku, app.PuzzleSudokuX, app.PuzzleKakuro:
-		return fmt.Sprintf("The puzzle type '%s' is not yet supported.", p.PuzzleType)
-	case "":
-		return "Puzzle type is not chosen."
-	default:
-		return fmt.Sprintf("The puzzle type '%s' is not supported.", p.PuzzleType)
+	if !isPlayableType(p.PuzzleType) {
+		switch p.PuzzleType {
+		case app.PuzzleJigsaw, app.PuzzleWindoku, app.PuzzleSudokuX, app.PuzzleKakuro:
+			return fmt.Sprintf("The puzzle type '%s' is not yet supported.", p.PuzzleType)
+		case "":
+			return "Puzzle type is not chosen."
+		default:
+			return fmt.Sprintf("The puzzle type '%s' is not supported.", p.PuzzleType)
+		}
 	}
 
 	switch p.Level {
-	case app.PuzzleLevelEasy, app.PuzzleLevelNormal, app.PuzzleLevelHard, app.PuzzleLevelHarder:
+	case app.PuzzleLevelCustom:
+		if p.Daily || p.Race {
+			return "The daily puzzle and the race are not available for the custom level."
+		}
+		if strings.TrimSpace(p.CustomPuzzle) == "" {
+			return "Type or paste your puzzle to play the custom level."
+		}
 	case app.PuzzleLevelUnknown:
 		return "Puzzle level is not chosen."
 	default:
-		return fmt.Sprintf("The puzzle level '%s' is not supported.", p.Level)
+		if !isPlayableLevel(p.Level) {
+			return fmt.Sprintf("The puzzle level '%s' is not supported.", p.Level)
+		}
+	}
+
+	if p.Practice != app.StrategyUnknown {
+		if !app.DrillStrategies.Has(p.Practice) {
+			return fmt.Sprintf("The strategy '%s' cannot be practiced.", p.Practice)
+		}
+		if p.Daily || p.Race || p.Level == app.PuzzleLevelCustom {
+			return "Only random puzzles can be practiced."
+		}
 	}
 
 	return ""
@@ -63,9 +132,19 @@ func (srv *service) HandleHome(w http.ResponseWriter, r *http.Request) {
 			{ID: string(app.PuzzleLevelHarder), Name: "Harder"},
 			{ID: string(app.PuzzleLevelInsane), Name: "Insane", Disabled: true},
 			{ID: string(app.PuzzleLevelDemon), Name: "Demon", Disabled: true},
-			{ID: string(app.PuzzleLevelCustom), Name: "Custom", Disabled: true},
+			{ID: string(app.PuzzleLevelCustom), Name: "Custom"},
 		},
 		CandidatesAtStart: app.DefaultCandidatesAtStart,
+		Symmetric:         app.DefaultSymmetric,
+		DailyDate:         app.DailyDate(time.Now()),
+	}
+	for strategy := app.StrategyNakedSingle; strategy <= app.DrillStrategies; strategy <<= 1 {
+		if app.DrillStrategies.Has(strategy) {
+			renderData.PracticeStrategies = append(renderData.PracticeStrategies, listItem{
+				ID:   strategy.String(),
+				Name: strategy.String(),
+			})
+		}
 	}
 
 	var up *app.UserPreferences
@@ -86,6 +165,13 @@ func (srv *service) HandleHome(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			renderData.CandidatesAtStart = up.CandidatesAtStart
+			renderData.Symmetric = up.Symmetric
+		}
+		streak, err := srv.puzzleRepository.GetUserDailyStreak(ctx, session.UserID)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to get daily streak")
+		} else {
+			renderData.DailyStreak = streak.Actual(renderData.DailyDate)
 		}
 	}
 
@@ -96,21 +182,119 @@ func (srv *service) HandleHome(w http.ResponseWriter, r *http.Request) {
 		)
 		func() {
 			post := PostHome{}.Parse(r)
+			renderData.CustomPuzzle = post.CustomPuzzle
 			renderData.ErrorMessage = post.Validate()
 			if renderData.ErrorMessage != "" {
 				return
 			}
 			log = log.With().Stringer("puzzle_type", post.PuzzleType).Stringer("puzzle_level", post.Level).Logger()
 
-			puzzle, game, err := srv.puzzleRepository.CreateRandomPuzzleGame(ctx, app.CreateRandomPuzzleGameParams{
-				Session: session,
-				Type:    post.PuzzleType,
-				Level:   post.Level,
-			})
+			if post.Race {
+				race, err := srv.puzzleRepository.CreateRace(ctx, app.CreateRaceParams{
+					Session:           session,
+					Type:              post.PuzzleType,
+					Level:             post.Level,
+					CandidatesAtStart: post.CandidatesAtStart,
+				})
+				switch {
+				case errors.Is(err, app.ErrorPuzzlePoolEmpty):
+					log.Error().Err(err).Send()
+					srv.requestPuzzles(ctx, app.GenerateJob{Type: post.PuzzleType, Level: post.Level, Reason: app.GenerateJobReasonEmpty})
+					renderData.ErrorMessage = msgYourPuzzlePoolEmpty
+					renderData.EmptyPool = &post
+					return
+				case err == nil:
+				default:
+					log.Error().Err(err).Msg("failed to create race")
+					renderData.ErrorMessage = msgInternalServerError
+					return
+				}
+				http.Redirect(w, r, app.EndpointRace{}.Path(race.ID), http.StatusSeeOther)
+				return
+			}
+
+			var (
+				puzzle *app.Puzzle
+				game   *app.PuzzleGame
+				err    error
+			)
+			switch {
+			case post.Level == app.PuzzleLevelCustom:
+				var custom *app.CustomPuzzle
+				checkCtx, cancel := context.WithTimeout(ctx, app.DefaultCustomPuzzleCheckTimeout)
+				custom, err = app.CheckCustomPuzzle(checkCtx, srv.puzzleLibrary, post.PuzzleType, post.CustomPuzzle)
+				cancel()
+				switch {
+				case err == nil:
+				case errors.Is(err, app.ErrorPuzzleInvalid):
+					renderData.ErrorMessage = "The puzzle must have 81 cells: digits 1-9 for clues, 0 or . for empty cells."
+					return
+				case errors.Is(err, app.ErrorPuzzleNoSolution):
+					renderData.ErrorMessage = "The puzzle has no solution."
+					return
+				case errors.Is(err, app.ErrorPuzzleMultipleSolutions):
+					renderData.ErrorMessage = "The puzzle has more than one solution. Add more clues."
+					return
+				case errors.Is(err, context.DeadlineExceeded):
+					renderData.ErrorMessage = "The puzzle takes too long to check. Add more clues."
+					return
+				default:
+					log.Error().Err(err).Msg("failed to check custom puzzle")
+					renderData.ErrorMessage = msgInternalServerError
+					return
+				}
+				log.Info().Stringer("rating", custom.Rating).Msg("custom puzzle checked")
+				puzzle, game, err = srv.puzzleRepository.CreateCustomPuzzleGame(ctx, app.CreateCustomPuzzleGameParams{
+					Session:      session,
+					Type:         post.PuzzleType,
+					CustomPuzzle: *custom,
+				})
+				if err == nil {
+					msg := fmt.Sprintf("Your puzzle is rated as %s.", custom.Rating)
+					if custom.Rating == app.PuzzleLevelUnknown {
+						msg = "Your puzzle is harder than the strategies of the solver."
+					}
+					srv.setCookieNotificationToResponse(w, app.NotificationSuccess, msg)
+				}
+			case post.Daily:
+				puzzle, game, err = srv.puzzleRepository.CreateDailyPuzzleGame(ctx, app.CreateDailyPuzzleGameParams{
+					Session: session,
+					Date:    renderData.DailyDate,
+					Type:    post.PuzzleType,
+					Level:   post.Level,
+				})
+			default:
+				puzzle, game, err = srv.puzzleRepository.CreateRandomPuzzleGame(ctx, app.CreateRandomPuzzleGameParams{
+					Session:   session,
+					Type:      post.PuzzleType,
+					Level:     post.Level,
+					Symmetric: post.Symmetric,
+					Practice:  post.practiceTarget(),
+				})
+				// the random puzzle is transformed for the new game, so the players of one puzzle see different boards
+				if err == nil && game.State == "" {
+					puzzle, err = srv.transformPuzzleGame(puzzle, game)
+				}
+				if err == nil && post.Practice == app.StrategyUnknown {
+					srv.refillLowPool(ctx, session, post.PuzzleType, post.Level)
+				}
+			}
 			switch {
+			case errors.Is(err, app.ErrorPuzzlePoolEmpty) && post.Practice != app.StrategyUnknown:
+				// the practice pool of the strategy may be not filled by the schedule of the generators at all
+				log.Warn().Err(err).Stringer("practice", post.Practice).Send()
+				srv.requestPuzzles(ctx, app.GenerateJob{
+					Type:     post.PuzzleType,
+					Practice: post.practiceTarget().String(),
+					Reason:   app.GenerateJobReasonEmpty,
+				})
+				renderData.ErrorMessage = "No puzzles of this strategy yet. They are being generated, try again later."
+				return
 			case errors.Is(err, app.ErrorPuzzlePoolEmpty):
 				log.Error().Err(err).Send()
+				srv.requestPuzzles(ctx, app.GenerateJob{Type: post.PuzzleType, Level: post.Level, Reason: app.GenerateJobReasonEmpty})
 				renderData.ErrorMessage = msgYourPuzzlePoolEmpty
+				renderData.EmptyPool = &post
 				return
 			case err == nil:
 			default:
@@ -118,22 +302,27 @@ func (srv *service) HandleHome(w http.ResponseWriter, r *http.Request) {
 				renderData.ErrorMessage = msgInternalServerError
 				return
 			}
-			game.State = puzzle.Clues
-			if post.CandidatesAtStart {
-				game.StateCandidates = puzzle.Candidates
-			} else {
-				game.StateCandidates = "{}"
-			}
-			if err := srv.puzzleRepository.UpdatePuzzleGame(ctx, game); err != nil {
-				log.Error().Err(err).Msg("failed to update puzzle game")
-				renderData.ErrorMessage = msgInternalServerError
-				return
+			// The daily puzzle game may be started before and keeps its state.
+			if game.State == "" {
+				game.State = puzzle.Clues
+				if post.CandidatesAtStart {
+					game.StateCandidates = puzzle.Candidates
+				} else {
+					game.StateCandidates = "{}"
+				}
+				game.StartCandidates = game.StateCandidates
+				if err := srv.puzzleRepository.UpdatePuzzleGame(ctx, game); err != nil {
+					log.Error().Err(err).Msg("failed to update puzzle game")
+					renderData.ErrorMessage = msgInternalServerError
+					return
+				}
 			}
 
 			if up != nil {
 				up.PuzzleType = post.PuzzleType
 				up.PuzzleLevel = post.Level
 				up.CandidatesAtStart = post.CandidatesAtStart
+				up.Symmetric = post.Symmetric
 				if err := srv.userRepository.SetUserPreferences(ctx, up); err != nil {
 					log.Warn().Err(err).Msg("failed to set user preferences")
 				}
@@ -150,9 +339,61 @@ func (srv *service) HandleHome(w http.ResponseWriter, r *http.Request) {
 	srv.executeTemplate(r.Context(), w, templates.PageHome, func(params *templates.Params) {
 		params.Header.Title = "Home"
 		params.Data = renderData
+		if renderData.EmptyPool != nil {
+			// the page waits for the "poolRefill" event
+			params.Footer.JsExternal = append(params.Footer.JsExternal, static.JsWs)
+		}
 	})
 }
 
+// requestPuzzles queues the refill of app.DefaultRefillPuzzles puzzles of the pool of the job for the generators. The
+// request is skipped if the refill of the pool is already queued. Errors are only logged, the pool is checked by the
+// generators anyway.
+func (srv *service) requestPuzzles(ctx context.Context, job app.GenerateJob) {
+	log := FromContextLogger(ctx).With().Str("pool", job.Pool()).Logger()
+	job.Count = app.DefaultRefillPuzzles
+	queued, err := srv.generateJobRepository.PushGenerateJob(ctx, job)
+	switch {
+	case errors.Is(err, app.ErrorGenerateJobQueued):
+		log.Debug().Msg("refill of puzzle pool is already queued")
+	case err == nil:
+		log.Info().Str("job_id", queued.ID).Str("reason", job.Reason).Msg("refill of puzzle pool queued")
+	default:
+		log.Error().Err(err).Msg("failed to queue refill of puzzle pool")
+	}
+}
+
+// refillLowPool requests the refill of the pool if the player has less than app.DefaultRefillThreshold unsolved
+// puzzles of the type and level.
+func (srv *service) refillLowPool(ctx context.Context, session *app.Session, typ app.PuzzleType, level app.PuzzleLevel) {
+	log := FromContextLogger(ctx)
+	unsolved, err := srv.puzzleRepository.GetUnsolvedPuzzleCount(ctx, typ, level, session.UserID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get count of unsolved puzzles")
+		return
+	}
+	if unsolved < app.DefaultRefillThreshold {
+		srv.requestPuzzles(ctx, app.GenerateJob{Type: typ, Level: level, Reason: app.GenerateJobReasonLow})
+	}
+}
+
+// transformPuzzleGame sets a random transform to the new game and returns the puzzle as it is presented in the game.
+//
+// Errors: app.ErrorPuzzleTypeUnknown, unknown.
+func (srv *service) transformPuzzleGame(puzzle *app.Puzzle, game *app.PuzzleGame) (*app.Puzzle, error) {
+	creator, err := srv.puzzleLibrary.GetCreator(puzzle.Type)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	clues, err := srv.puzzleLibrary.GetGenerator(puzzle.Type, puzzle.Clues)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// the symmetric puzzle stays symmetric on the board
+	game.Transform = creator.NewRandomTransform(clues.Symmetry())
+	return app.TransformPuzzle(srv.puzzleLibrary, puzzle, game.Transform)
+}
+
 type listItem struct {
 	ID       string
 	Name     string
@@ -164,5 +405,15 @@ type RenderDataHome struct {
 	PuzzleTypes       []listItem
 	PuzzleLevels      []listItem
 	CandidatesAtStart bool
-	ErrorMessage      string
+	Symmetric         bool
+	// PracticeStrategies are the strategies of the practice pools.
+	PracticeStrategies []listItem
+	DailyDate          string
+	// DailyStreak is the current daily streak of the logged user.
+	DailyStreak  int
+	ErrorMessage string
+	// EmptyPool is the chosen type and level if their pool is empty.
+	EmptyPool *PostHome
+	// CustomPuzzle keeps the entered puzzle of the custom level after an error.
+	CustomPuzzle string
 }
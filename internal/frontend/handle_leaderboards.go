@@ -0,0 +1,134 @@
+package frontend
+
+import (
+	"github.com/cnblvr/puzzles/app"
+	"github.com/cnblvr/puzzles/internal/frontend/templates"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type RenderDataLeaderboards struct {
+	Level        string
+	Period       string
+	PuzzleID     int64
+	Levels       []listItem
+	Periods      []listItem
+	Leaderboards []preparedLeaderboard
+	ErrorMessage string
+}
+
+type preparedLeaderboard struct {
+	Title   string
+	Solved  bool
+	Entries []preparedLeaderboardEntry
+}
+
+type preparedLeaderboardEntry struct {
+	app.LeaderboardEntry
+	Elapsed string
+	Current bool
+}
+
+var leaderboardPeriods = []listItem{
+	{ID: string(app.LeaderboardDay), Name: "Today"},
+	{ID: string(app.LeaderboardWeek), Name: "This week"},
+	{ID: string(app.LeaderboardAllTime), Name: "All time"},
+}
+
+// HandleLeaderboards shows the leaderboards of the fastest solves and of the most solved puzzles. Users and anonymous
+// sessions are shown in separate leaderboards. Query parameters:
+//  - level and period choose the leaderboard of the level (normal and day by default);
+//  - puzzle shows the leaderboard of the puzzle instead.
+func (srv *service) HandleLeaderboards(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+	query := r.URL.Query()
+	renderData := RenderDataLeaderboards{
+		Level:   string(app.DefaultPuzzleLevel),
+		Period:  string(app.LeaderboardDay),
+		Levels:  dailyLevels,
+		Periods: leaderboardPeriods,
+	}
+
+	var boards []app.Leaderboard
+	var titles []string
+	if puzzle := query.Get("puzzle"); puzzle != "" {
+		puzzleID, err := strconv.ParseInt(puzzle, 10, 64)
+		if err != nil || puzzleID <= 0 {
+			srv.setCookieNotificationToResponse(w, app.NotificationWarning, "Incorrect puzzle id.")
+			http.Redirect(w, r, app.EndpointLeaderboards, http.StatusSeeOther)
+			return
+		}
+		renderData.PuzzleID = puzzleID
+		boards = []app.Leaderboard{
+			{Kind: app.LeaderboardPuzzle, PuzzleID: puzzleID},
+			{Kind: app.LeaderboardPuzzle, PuzzleID: puzzleID, Anonymous: true},
+		}
+		titles = []string{"Fastest solves", "Fastest anonymous solves"}
+	} else {
+		if level := query.Get("level"); level != "" {
+			renderData.Level = level
+		}
+		if period := query.Get("period"); period != "" {
+			renderData.Period = period
+		}
+		if !isListItem(dailyLevels, renderData.Level) || app.LeaderboardPeriod(renderData.Period).Validate() != nil {
+			srv.setCookieNotificationToResponse(w, app.NotificationWarning, "Incorrect leaderboard.")
+			http.Redirect(w, r, app.EndpointLeaderboards, http.StatusSeeOther)
+			return
+		}
+		now := time.Now()
+		for _, anonymous := range []bool{false, true} {
+			boards = append(boards, app.Leaderboard{
+				Kind:      app.LeaderboardLevel,
+				Type:      app.PuzzleSudokuClassic,
+				Level:     app.PuzzleLevel(renderData.Level),
+				Period:    app.LeaderboardPeriod(renderData.Period),
+				At:        now,
+				Anonymous: anonymous,
+			})
+		}
+		boards = append(boards,
+			app.Leaderboard{Kind: app.LeaderboardSolved},
+			app.Leaderboard{Kind: app.LeaderboardSolved, Anonymous: true},
+		)
+		titles = []string{"Fastest solves", "Fastest anonymous solves", "Most solved", "Most solved anonymously"}
+	}
+
+	for i, board := range boards {
+		entries, err := srv.puzzleRepository.GetLeaderboard(ctx, board, app.DefaultLeaderboardSize)
+		if err != nil {
+			log.Error().Err(err).Str("kind", string(board.Kind)).Msg("failed to get leaderboard")
+			renderData.ErrorMessage = "Internal Server Error."
+			break
+		}
+		leaderboard := preparedLeaderboard{Title: titles[i], Solved: board.Kind == app.LeaderboardSolved}
+		for _, entry := range entries {
+			if entry.UserID > 0 {
+				entry.Name = srv.username(ctx, entry.UserID)
+			}
+			leaderboard.Entries = append(leaderboard.Entries, preparedLeaderboardEntry{
+				LeaderboardEntry: entry,
+				Elapsed:          formatStatsDuration(entry.Elapsed),
+				Current:          entry.UserID > 0 && entry.UserID == session.UserID,
+			})
+		}
+		renderData.Leaderboards = append(renderData.Leaderboards, leaderboard)
+	}
+
+	srv.executeTemplate(ctx, w, templates.PageLeaderboards, func(params *templates.Params) {
+		params.Header.Title = "Leaderboards"
+		params.Header.CssInternal = append(params.Header.CssInternal, cssStats)
+		params.Data = renderData
+	})
+}
+
+func isListItem(list []listItem, id string) bool {
+	for _, item := range list {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
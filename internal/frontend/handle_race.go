@@ -0,0 +1,98 @@
+package frontend
+
+import (
+	"github.com/cnblvr/puzzles/app"
+	"github.com/cnblvr/puzzles/internal/frontend/static"
+	"github.com/cnblvr/puzzles/internal/frontend/templates"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+type RenderDataRace struct {
+	RaceID string
+	// GameID is the board of the player in the race.
+	GameID string
+	// IsOwner allows starting the race.
+	IsOwner        bool
+	MaxPlayers     int
+	UseHighlights  bool
+	ShowCandidates bool
+	ShowWrongs     bool
+}
+
+// HandleRace joins the player to the race and shows the lobby. The board of the player is shown after the countdown.
+func (srv *service) HandleRace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+	renderData := RenderDataRace{
+		MaxPlayers:     app.DefaultRaceMaxPlayers,
+		UseHighlights:  app.DefaultUseHighlights,
+		ShowCandidates: app.DefaultShowCandidates,
+		ShowWrongs:     app.DefaultShowWrongs,
+	}
+
+	raceID, err := app.EndpointRace{}.MuxParse(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("incorrect race id")
+		srv.setCookieNotificationToResponse(w, app.NotificationWarning, "Incorrect race id.")
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+	log = log.With().Stringer("race_id", raceID).Logger()
+	renderData.RaceID = raceID.String()
+
+	race, err := srv.puzzleRepository.GetRace(ctx, raceID)
+	if err != nil {
+		msg := "Internal server error."
+		if errors.Is(err, app.ErrorRaceNotFound) {
+			log.Info().Msg("race not found")
+			msg = "Race not found."
+		} else {
+			log.Error().Err(err).Msg("failed to get race")
+		}
+		srv.setCookieNotificationToResponse(w, app.NotificationError, msg)
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+	renderData.IsOwner = race.ValidateSession(session) == nil
+
+	if session.UserID > 0 {
+		up, err := srv.userRepository.GetUserPreferences(ctx, session.UserID)
+		if err == nil {
+			renderData.UseHighlights = up.UseHighlights
+			renderData.ShowCandidates = up.ShowCandidates
+			renderData.ShowWrongs = up.ShowWrongs
+		}
+	}
+
+	game, err := srv.puzzleRepository.JoinRace(ctx, raceID, session, srv.playerName(ctx, session))
+	if err != nil {
+		msg := "Internal server error."
+		switch {
+		case errors.Is(err, app.ErrorRaceStarted):
+			log.Info().Msg("race is already started")
+			msg = "The race has already started."
+		case errors.Is(err, app.ErrorRaceFull):
+			log.Info().Msg("race is full")
+			msg = "The race is full."
+		default:
+			log.Error().Err(err).Msg("failed to join race")
+		}
+		srv.setCookieNotificationToResponse(w, app.NotificationError, msg)
+		http.Redirect(w, r, app.EndpointHome, http.StatusSeeOther)
+		return
+	}
+	renderData.GameID = game.ID.String()
+	// the players in the lobby see the new player
+	if rpl, status := srv.raceReply(ctx, race); status == nil {
+		srv.gameHub.publish(wsTopicRace(raceID), nil, "race", rpl)
+	}
+
+	srv.executeTemplate(ctx, w, templates.PageRace, func(params *templates.Params) {
+		params.Header.Title = "Race"
+		params.Header.CssExternal = append(params.Header.CssExternal, static.CssSudoku)
+		params.Header.CssInternal = append(params.Header.CssInternal, cssStats)
+		params.Data = renderData
+		params.Footer.JsExternal = append(params.Footer.JsExternal, static.JsWs, static.JsSudoku)
+	})
+}
@@ -0,0 +1,99 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/cnblvr/puzzles/internal/frontend/templates"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+type RenderDataStats struct {
+	Stats []preparedPuzzleStats
+	// DrillStats is the accuracy in the drills of every strategy.
+	DrillStats   []app.DrillStats
+	ErrorMessage string
+}
+
+type preparedPuzzleStats struct {
+	app.PuzzleStats
+	BestTime    string
+	AverageTime string
+}
+
+func (srv *service) HandleStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+	renderData := RenderDataStats{}
+
+	games, err := srv.puzzleRepository.GetUserPuzzleGames(ctx, session.UserID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user puzzle games")
+		renderData.ErrorMessage = "Internal Server Error."
+	}
+	for _, s := range app.NewPuzzleStats(games) {
+		renderData.Stats = append(renderData.Stats, preparedPuzzleStats{
+			PuzzleStats: s,
+			BestTime:    formatStatsDuration(s.BestTime),
+			AverageTime: formatStatsDuration(s.AverageTime),
+		})
+	}
+	renderData.DrillStats, err = srv.puzzleRepository.GetUserDrillStats(ctx, session.UserID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user drill stats")
+		renderData.ErrorMessage = "Internal Server Error."
+	}
+
+	srv.executeTemplate(ctx, w, templates.PageStats, func(params *templates.Params) {
+		params.Header.Title = "Statistics"
+		params.Header.CssInternal = append(params.Header.CssInternal, cssStats)
+		params.Data = renderData
+	})
+}
+
+// HandleStatsJSON returns the same statistics as HandleStats. Times are in milliseconds.
+func (srv *service) HandleStatsJSON(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log, session := FromContextLogger(ctx), FromContextSession(ctx)
+
+	games, err := srv.puzzleRepository.GetUserPuzzleGames(ctx, session.UserID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user puzzle games")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	type statsJSON struct {
+		app.PuzzleStats
+		BestTime    int64 `json:"best_time"`
+		AverageTime int64 `json:"average_time"`
+	}
+	resp := make([]statsJSON, 0)
+	for _, s := range app.NewPuzzleStats(games) {
+		resp = append(resp, statsJSON{
+			PuzzleStats: s,
+			BestTime:    s.BestTime.Milliseconds(),
+			AverageTime: s.AverageTime.Milliseconds(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Error().Err(err).Msg("failed to encode stats")
+	}
+}
+
+const cssStats template.CSS = `table.stats { margin: 10px auto; border-collapse: collapse; }
+        table.stats th, table.stats td { padding: 5px 10px; border: 1px solid #b0f0b0; text-align: center; }`
+
+func formatStatsDuration(d time.Duration) string {
+	if d <= 0 {
+		return "—"
+	}
+	d = d.Round(time.Second)
+	if h := d / time.Hour; h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, d%time.Hour/time.Minute, d%time.Minute/time.Second)
+	}
+	return fmt.Sprintf("%d:%02d", d/time.Minute, d%time.Minute/time.Second)
+}
@@ -19,14 +19,16 @@ import (
 )
 
 type service struct {
-	config           app.Config
-	templates        *template.Template
-	userRepository   app.UserRepository
-	puzzleRepository app.PuzzleRepository
-	puzzleLibrary    app.PuzzleLibrary
-	gameWebsocket    websocket.Upgrader
-	secCookie        *securecookie.SecureCookie
-	passwordPepper   []byte
+	config                app.Config
+	templates             *template.Template
+	userRepository        app.UserRepository
+	puzzleRepository      app.PuzzleRepository
+	generateJobRepository app.GenerateJobRepository
+	puzzleLibrary         app.PuzzleLibrary
+	gameWebsocket         websocket.Upgrader
+	gameHub               *wsHub
+	secCookie             *securecookie.SecureCookie
+	passwordPepper        []byte
 }
 
 func NewService() (app.ServiceFrontend, error) {
@@ -70,10 +72,42 @@ func NewService() (app.ServiceFrontend, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create puzzle game repository")
 	}
+	srv.generateJobRepository, err = repository.NewRedisGenerateJobRepository(func() (redis.Conn, error) {
+		address, password, db, err := srv.config.RedisPuzzleConn()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return redis.Dial(
+			"tcp", address,
+			redis.DialPassword(password),
+			redis.DialDatabase(db),
+		)
+	}, srv.config.Debug())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create generate job repository")
+	}
 
 	srv.puzzleLibrary = &puzzle_library.PuzzleLibrary{}
 
 	srv.gameWebsocket = websocket.Upgrader{}
+	srv.gameHub = newWsHub()
+	eventRepository, err := repository.NewRedisEventRepository(func() (redis.Conn, error) {
+		address, password, db, err := srv.config.RedisPuzzleConn()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return redis.Dial(
+			"tcp", address,
+			redis.DialPassword(password),
+			redis.DialDatabase(db),
+		)
+	}, srv.config.Debug())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create event repository")
+	}
+	if err := srv.gameHub.fanOut(eventRepository); err != nil {
+		return nil, errors.Wrap(err, "failed to subscribe to events")
+	}
 
 	hashKey, blockKey, err := srv.config.SecCookieSecrets()
 	if err != nil {
@@ -97,6 +131,9 @@ func (srv *service) executeTemplate(ctx context.Context, w http.ResponseWriter,
 			Title: "unknown page",
 			Navigation: []templates.Navigation{
 				{Label: "Home", Path: app.EndpointHome, Weight: 0},
+				{Label: "Daily", Path: app.EndpointDaily, Weight: 10},
+				{Label: "Leaderboards", Path: app.EndpointLeaderboards, Weight: 20},
+				{Label: "Drills", Path: app.EndpointDrill, Weight: 30},
 			},
 			Notification: FromContextNotificationOrNil(ctx),
 		},
@@ -110,6 +147,7 @@ func (srv *service) executeTemplate(ctx context.Context, w http.ResponseWriter,
 		)
 	} else {
 		params.Header.Navigation = append(params.Header.Navigation,
+			templates.Navigation{Label: "Stats", Path: app.EndpointStats, Weight: 980},
 			templates.Navigation{Label: "Settings", Path: app.EndpointSettings, Weight: 981},
 			templates.Navigation{Label: "Log out", Path: app.EndpointLogout, Weight: 993},
 		)
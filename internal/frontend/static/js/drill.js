@@ -0,0 +1,150 @@
+'use strict';
+
+class Drill {
+    #_object;
+    #_result;
+    #drillID;
+    #single = false;
+    #answered = false;
+
+    constructor(param) {
+        if (!param)
+            throw 'drill: parameters not defined';
+        if (!param.selector || typeof param.selector !== 'string')
+            throw 'drill: required parameter \'selector\' is not defined or not string';
+        if (!param.drillID || typeof param.drillID !== 'string')
+            throw 'drill: required parameter \'drillID\' is not defined or not string';
+        if (!param.state || typeof param.state !== 'string')
+            throw 'drill: required parameter \'state\' is not defined or not string';
+        this.#drillID = param.drillID;
+        this.#_object = document.querySelector(param.selector);
+        if (!this.#_object)
+            throw 'drill: object by parameter \'selector\' not found';
+        if (param.single) {
+            if (typeof param.single !== 'boolean')
+                throw 'drill: parameter \'single\' is not boolean';
+            this.#single = param.single;
+        }
+        let _check = document.querySelector(param.checkSelector);
+        if (!_check)
+            throw 'drill: object by parameter \'checkSelector\' not found';
+        _check.addEventListener('click', () => this.#check());
+        this.#_result = document.querySelector(param.resultSelector);
+        if (!this.#_result)
+            throw 'drill: object by parameter \'resultSelector\' not found';
+
+        let candidates = param.candidates ? JSON.parse(param.candidates) : {};
+        this.#_object.classList.add('sudoku');
+        for (let row = 0; row < 9; row++) {
+            let _row = document.createElement('div');
+            _row.classList.add('sud-row');
+            for (let col = 0; col < 9; col++) {
+                let point = this.#stringifyPoint(row, col);
+                let _cell = document.createElement('div');
+                _cell.classList.add('sud-cll');
+                _cell.dataset.point = point;
+                let digit = param.state[row * 9 + col];
+                let _dgt = document.createElement('div');
+                _dgt.classList.add('sud-dgt');
+                _cell.appendChild(_dgt);
+                let _cnd = document.createElement('div');
+                _cnd.classList.add('sud-cnd');
+                let cands = (candidates.base && candidates.base[point]) || [];
+                for (let idx = 1; idx <= 9; idx++) {
+                    let _cndItem = document.createElement('div');
+                    _cndItem.textContent = '' + idx;
+                    if (cands.includes(idx)) {
+                        _cndItem.addEventListener('click', () => this.#mark(_cndItem));
+                    } else {
+                        _cndItem.classList.add('hidden');
+                    }
+                    _cnd.appendChild(_cndItem);
+                }
+                _cell.appendChild(_cnd);
+                if ('1' <= digit && digit <= '9') {
+                    _dgt.textContent = digit;
+                    _cell.classList.add('is-dgt', 'hint');
+                } else {
+                    _cell.classList.add('is-cnd');
+                }
+                _row.appendChild(_cell);
+            }
+            this.#_object.appendChild(_row);
+        }
+    }
+
+    // marks the candidate to set for the singles or toggles the candidate to delete for other strategies
+    #mark(_cndItem) {
+        if (this.#answered) return;
+        if (this.#single) {
+            let isMarked = _cndItem.classList.contains('step-digit');
+            this.#_object.querySelectorAll('.sud-cnd .step-digit').forEach((_div) => _div.classList.remove('step-digit'));
+            if (!isMarked) _cndItem.classList.add('step-digit');
+            return;
+        }
+        _cndItem.classList.toggle('step-elimination');
+    }
+
+    #check() {
+        if (this.#answered) return;
+        let answer = {};
+        if (this.#single) {
+            let _marked = this.#_object.querySelector('.sud-cnd .step-digit');
+            if (!_marked) {
+                this.#_result.textContent = 'Choose the candidate to set.';
+                return;
+            }
+            answer.set = {point: _marked.closest('.sud-cll').dataset.point, digit: parseInt(_marked.textContent)};
+        } else {
+            let del = {};
+            this.#_object.querySelectorAll('.sud-cnd .step-elimination').forEach((_div) => {
+                let point = _div.closest('.sud-cll').dataset.point;
+                (del[point] = del[point] || []).push(parseInt(_div.textContent));
+            });
+            if (Object.keys(del).length === 0) {
+                this.#_result.textContent = 'Choose the candidates to delete.';
+                return;
+            }
+            answer.del = Object.keys(del).map((point) => ({point: point, digits: del[point]}));
+        }
+        fetch('/drill/' + this.#drillID, {
+            method: 'POST',
+            headers: {'Content-Type': 'application/json'},
+            body: JSON.stringify(answer),
+        }).then((response) => {
+            if (!response.ok) throw response.statusText;
+            return response.json();
+        }).then((body) => this.#showResult(body)).catch((err) => {
+            this.#_result.textContent = 'Failed to check the answer: ' + err;
+        });
+    }
+
+    // shows the step of the drill: the expected candidates are marked and the wrong marks of the player are red
+    #showResult(body) {
+        this.#answered = true;
+        let expected = new Set();
+        if (body.answer.set) {
+            expected.add(body.answer.set.point + ':' + body.answer.set.digit);
+            this.#_object.querySelector('.sud-cll[data-point="' + body.answer.set.point + '"]').classList.add('step-placement');
+        }
+        (body.answer.del || []).forEach((candidates) => {
+            candidates.digits.forEach((digit) => expected.add(candidates.point + ':' + digit));
+        });
+        let cls = this.#single ? 'step-digit' : 'step-elimination';
+        this.#_object.querySelectorAll('.sud-cnd div').forEach((_div) => {
+            let key = _div.closest('.sud-cll').dataset.point + ':' + _div.textContent;
+            if (_div.classList.contains(cls) && !expected.has(key)) {
+                _div.classList.remove(cls);
+                _div.classList.add('wrong');
+            }
+            if (expected.has(key)) _div.classList.add(cls);
+        });
+        let msg = body.correct ? 'Correct!' : 'Not quite. The step is: ' + body.description + '.';
+        if (!body.counted) msg += ' The drill was answered before, so this answer is not counted.';
+        this.#_result.textContent = msg;
+    }
+
+    #stringifyPoint(row, col) {
+        return String.fromCharCode((row)+'a'.charCodeAt(0)) + (col+1);
+    }
+}
@@ -8,6 +8,18 @@ class Sudoku {
     #ws;
     #cndMode = false;
     #_hint = undefined;
+    #hintLevel = 1;
+    #replay = undefined;
+    #_timer = undefined;
+    #timer = {elapsed: 0, running: false, syncedAt: 0};
+    #shared = false;
+    #waitStart = false;
+    #_invite = undefined;
+    #_public = undefined;
+    #spectator = false;
+    // the game is paused and its board is requested again after the resume
+    #paused = false;
+    #loaded = false;
 
     #_option_useHighlights = undefined;
     #_option_showCandidates = undefined;
@@ -40,6 +52,63 @@ class Sudoku {
             if (!this.#_hint)
                 throw 'sudoku: object by parameter \'hintSelector\' not found';
         }
+        if (param.spectator) {
+            if (typeof param.spectator !== 'boolean')
+                throw 'sudoku: parameter \'spectator\' is not boolean';
+            this.#spectator = param.spectator;
+        }
+        if (param.timerSelector) {
+            this.#_timer = document.querySelector(param.timerSelector);
+            if (!this.#_timer)
+                throw 'sudoku: object by parameter \'timerSelector\' not found';
+            setInterval(() => this.#drawTimer(), 1000);
+            document.addEventListener('visibilitychange', () => {
+                // the timer belongs to the players, spectators only watch it
+                if (this.#isWin || this.#spectator) return;
+                this.#ws.send(document.visibilityState === 'hidden' ? 'pause' : 'resume', {
+                    game_id: this.#gameID,
+                });
+            });
+        }
+        if (param.waitStart) {
+            if (typeof param.waitStart !== 'boolean')
+                throw 'sudoku: parameter \'waitStart\' is not boolean';
+            this.#waitStart = param.waitStart;
+        }
+        if (param.inviteSelector) {
+            this.#_invite = document.querySelector(param.inviteSelector);
+            if (!this.#_invite)
+                throw 'sudoku: object by parameter \'inviteSelector\' not found';
+            this.#_invite.addEventListener('click', () => {
+                this.#ws.send('share', {
+                    game_id: this.#gameID,
+                });
+            });
+        }
+        if (param.publicSelector) {
+            this.#_public = document.querySelector(param.publicSelector);
+            if (!this.#_public)
+                throw 'sudoku: object by parameter \'publicSelector\' not found';
+            this.#_public.addEventListener('click', () => {
+                this.#ws.send('setPublic', {
+                    game_id: this.#gameID,
+                    public: this.#_public.dataset.public !== 'true',
+                });
+            });
+        }
+        if (param.replay) {
+            let _play = document.querySelector(param.replay.playSelector);
+            let _speed = document.querySelector(param.replay.speedSelector);
+            if (!_play || !_speed)
+                throw 'sudoku: objects by parameter \'replay\' not found';
+            this.#replay = {puzzle: '', frames: [], total: 0, index: 0, paused: false, timer: undefined, _speed: _speed};
+            _play.addEventListener('click', (e) => {
+                this.#replay.paused = !this.#replay.paused;
+                _play.textContent = this.#replay.paused ? 'play' : 'pause';
+                if (this.#replay.paused) clearTimeout(this.#replay.timer);
+                else this.#replayNext();
+            });
+        }
         if (param.options) {
             if (param.options.useHighlights) {
                 this.#_option_useHighlights = document.querySelector(param.options.useHighlights);
@@ -148,9 +217,16 @@ class Sudoku {
                     if (this.#isWin) return;
                     this.#ws.send('getHint', {
                         game_id: this.#gameID,
+                        hintLevel: this.#hintLevel,
                     });
-                }).title = 'use this button to get a hint if you don\'t known how to proceed';
+                }).title = 'use this button to get a hint if you don\'t known how to proceed; press it again for more details';
             }
+            createBtn('↶', (e) => {
+                this.#undo();
+            }).title = 'press [Ctrl]+[Z] to undo the move';
+            createBtn('↷', (e) => {
+                this.#redo();
+            }).title = 'press [Ctrl]+[Y] to redo the move';
         }
 
         if (param.allowEditing) {
@@ -192,6 +268,12 @@ class Sudoku {
                     case 'KeyC':
                         this.#toggleCandidateMode();
                         break;
+                    case 'KeyZ':
+                        if (e.ctrlKey) this.#undo();
+                        break;
+                    case 'KeyY':
+                        if (e.ctrlKey) this.#redo();
+                        break;
                     case 'ShiftLeft':
                     case 'ShiftRight':
                         this.#toggleCandidateMode(false);
@@ -213,49 +295,169 @@ class Sudoku {
         }
 
         this.#_object.addEventListener('apiReady', () => {
+            if (this.#replay) {
+                this.#ws.send('getReplay', {
+                    game_id: this.#gameID,
+                    from: 0,
+                });
+                return;
+            }
+            if (this.#waitStart) return;
             this.#ws.send('getPuzzle', {
                 game_id: this.#gameID,
             });
         }, {once: true});
 
+        this.#_object.addEventListener('api_getReplay', (e) => {
+            let body = e.detail.body;
+            let first = this.#replay.frames.length === 0;
+            this.#replay.puzzle = body.puzzle;
+            this.#replay.total = body.total;
+            this.#replay.frames = this.#replay.frames.concat(body.frames);
+            if (first) {
+                this.#drawState({puzzle: body.puzzle, state_puzzle: body.state_puzzle, state_candidates: body.state_candidates, wrongsCandidates: {}});
+                this.#replay.timer = setTimeout(() => this.#replayNext(), 1000);
+                return;
+            }
+            this.#replayNext();
+        });
+
+        // the server pauses the game when the connection is lost, so the game is resumed after the reconnection
+        this.#_object.addEventListener('apiReady', () => {
+            if (this.#loaded) this.#resume();
+        });
+
         this.#_object.addEventListener('api_getPuzzle', (e) => {
             let body = e.detail.body;
-            let puzzle = body.is_new ? body.puzzle : body.state_puzzle;
-            let candidates = body.state_candidates;
-            this.#_object.querySelectorAll('.sud-row').forEach((_row, row) => {
-                _row.querySelectorAll('.sud-cll').forEach((_cell, col) => {
-                    this.#placeDigit(_cell, '0', true);
-                    let d = puzzle[row * 9 + col];
-                    if ('1' <= d && d <= '9') {
-                        this.#placeDigit(_cell, d, true);
-                        if (body.puzzle[row * 9 + col] !== '.')
-                            _cell.classList.add('hint');
-                    }
-                    if (candidates.base) {
-                        this.#setCandidatesFor(_cell, candidates.base[this.#stringifyPoint(row, col)]);
-                    }
+            this.#loaded = true;
+            this.#shared = !!body.shared;
+            this.#paused = !!body.paused;
+            // only the clues of the paused game are sent
+            this.#drawState(this.#paused ? {puzzle: body.puzzle, is_new: true, state_candidates: {}} : body);
+            this.#setTimer(body.timer);
+            this.#resume();
+        });
+
+        this.#_object.addEventListener('api_pause', (e) => {
+            this.#setTimer(e.detail.body);
+        });
+
+        this.#_object.addEventListener('api_resume', (e) => {
+            this.#setTimer(e.detail.body);
+            if (this.#paused && e.detail.body.running) {
+                this.#paused = false;
+                this.#ws.send('getPuzzle', {
+                    game_id: this.#gameID,
                 });
-            });
-            if (!body.is_new) {
-                this.#deleteWrongs();
-                this.#setWrongs(body.wrongs, body.wrongsCandidates);
             }
-            if (body.is_win) this.#isWin = true;
+        });
+
+        this.#_object.addEventListener('api_undo', (e) => {
+            this.#hintLevel = 1;
+            this.#deleteStep();
+            this.#drawState(e.detail.body);
+        });
+
+        this.#_object.addEventListener('api_redo', (e) => {
+            this.#hintLevel = 1;
+            this.#deleteStep();
+            this.#drawState(e.detail.body);
+        });
+
+        // the game is changed by another player or rebuilt after a conflict
+        this.#_object.addEventListener('event_gameUpdate', (e) => {
+            let body = e.detail.body;
+            this.#hintLevel = 1;
+            this.#deleteStep();
+            this.#isWin = false;
+            this.#drawState(body);
+            this.#setTimer(body.timer);
+            if (body.is_win) {
+                this._showHint(this.#spectator ?
+                    'The puzzle is solved. <a href="/game/' + this.#gameID + '/replay">Watch the replay</a>.' :
+                    '<a href="/game/' + this.#gameID + '/replay">Watch the replay</a> of your solve.');
+            }
+        });
+
+        this.#_object.addEventListener('api_share', (e) => {
+            this.#shared = true;
+            let link = location.origin + e.detail.body.invite;
+            this._showHint('Invite link: <a href="' + link + '">' + link + '</a>');
+        });
+
+        this.#_object.addEventListener('api_setPublic', (e) => {
+            let body = e.detail.body;
+            this.#_public.dataset.public = body.public ? 'true' : 'false';
+            this.#_public.textContent = body.public ? 'hide from spectators' : 'spectator link';
+            if (!body.public) {
+                this._showHint('Spectators cannot watch the game anymore.');
+                return;
+            }
+            let link = location.origin + body.watch;
+            this._showHint('Spectator link: <a href="' + link + '">' + link + '</a>');
+        });
+
+        // the selected cell of another player of the shared game
+        this.#_object.addEventListener('event_presence', (e) => {
+            let body = e.detail.body;
+            if (!body.id) return;
+            this.#_object.querySelectorAll('.sud-cll.presence').forEach((_cell) => {
+                if (_cell.dataset.presence !== body.id) return;
+                _cell.classList.remove('presence');
+                delete _cell.dataset.presence;
+                _cell.removeAttribute('title');
+            });
+            if (!body.point) return;
+            let p = this.#parsePoints([body.point])[0];
+            let _cell = this.#_object.querySelectorAll('.sud-row').item(p.row).querySelectorAll('.sud-cll').item(p.col);
+            if (!_cell) return;
+            _cell.classList.add('presence');
+            _cell.dataset.presence = body.id;
+            _cell.title = body.name;
         });
 
         this.#_object.addEventListener('api_makeStep', (e) => {
             let body = e.detail.body;
+            this.#hintLevel = 1;
+            this.#deleteStep();
             this.#deleteWrongs();
             if (body.win) {
                 this.#isWin = true;
+                this.#setTimer({elapsed: body.elapsed, running: false});
                 alert('win'); // TODO
+                this._showHint('<a href="/game/' + this.#gameID + '/replay">Watch the replay</a> of your solve.');
                 return;
             }
             this.#setWrongs(body.wrongs, body.wrongsCandidates);
+            // the server starts the timer on the first move
+            if (!this.#timer.running) this.#setTimer({elapsed: this.#timerElapsed(), running: true});
         });
 
         this.#_object.addEventListener('api_getHint', (e) => {
             let body = e.detail.body;
+            switch (body.status) {
+                case 'mistakes':
+                    let msg = 'Fix your mistakes first.';
+                    if (body.wrongs) msg += ' Wrong digits: ' + body.wrongs.join(', ') + '.';
+                    if (body.removedCandidates && body.removedCandidates.base) {
+                        let removed = [];
+                        for (let point in body.removedCandidates.base) {
+                            removed.push(point + ' (' + body.removedCandidates.base[point].join(', ') + ')');
+                        }
+                        msg += ' Correct candidates were removed: ' + removed.join(', ') + '.';
+                    }
+                    this._showHint(msg);
+                    return;
+                case 'solved':
+                    this._showHint('All cells are filled.');
+                    return;
+                case 'stuck':
+                    this._showHint('No known strategy applies to your candidates.');
+                    return;
+                case 'contradiction':
+                    if (body.contradiction) this._showHint('Something is wrong: ' + body.contradiction.reason + '.');
+                    return;
+            }
             if (body.strategy) {
                 let url = undefined, paragraph = undefined;
                 switch (body.strategy) {
@@ -279,16 +481,33 @@ class Sudoku {
                     case 'X-Wing':
                         url = 'https://www.sudokuwiki.org/X_Wing_Strategy'; break;
                 }
+                let msg = 'Try to use the ' + body.strategy + ' strategy.';
                 if (url) {
-                    let msg = 'Try to use the ';
+                    msg = 'Try to use the ';
                     if (paragraph) msg += ' \''+ body.strategy +'\' strategy in <a href="' + url + '" target="_blank">§' + paragraph + '</a>.';
                     else msg += ' <a href="' + url + '" target="_blank">' + body.strategy + '</a> strategy.'
-                    this._showHint(msg);
                 }
+                let payload = body.payload || {};
+                if (payload.houses) msg += ' Look at ' + payload.houses.map(this.#stringifyHouse).join(', ') + '.';
+                if (payload.pattern) msg += ' Cells: ' + payload.pattern.join(', ') + '.';
+                this.#deleteStep();
+                this.#setStep(payload);
+                if (body.description) msg += ' The step ' + body.description + '.';
+                this._showHint(msg);
+                if (body.hintLevel) this.#hintLevel = Math.min(body.hintLevel + 1, 4);
             }
         });
     }
 
+    #stringifyHouse(house) {
+        switch (house.type) {
+            case 'row': return 'row ' + String.fromCharCode(97 + house.index);
+            case 'col': return 'column ' + (house.index + 1);
+            case 'box': return 'box ' + (house.index + 1);
+        }
+        return house.type + ' ' + house.index;
+    }
+
     #hintTimeout = undefined;
     _showHint(hintMsg) {
         if (!this.#_hint) return;
@@ -311,6 +530,15 @@ class Sudoku {
         this.#ws = ws;
     }
 
+    // loads the game which is created with the parameter 'waitStart', e.g. after the countdown of the race
+    start() {
+        if (!this.#waitStart) return;
+        this.#waitStart = false;
+        this.#ws.send('getPuzzle', {
+            game_id: this.#gameID,
+        });
+    }
+
     #placeDigit(_cell, digit, notMakeStep) {
         if (this.#isWin) return;
         if (!_cell || _cell.classList.contains('hint')) return;
@@ -389,6 +617,12 @@ class Sudoku {
             _cell.classList.add('active');
             this.#_setHighlights(_cell.querySelector('.sud-dgt').textContent, _cell);
         } else this.#_resetHighlights();
+        if (this.#shared) {
+            this.#ws.send('presence', {
+                game_id: this.#gameID,
+                point: isAlready ? undefined : this.#stringifyPoint(this.#getIndex(_cell.parentElement), this.#getIndex(_cell)),
+            });
+        }
     }
 
     #_isUseHighlights() {
@@ -485,6 +719,152 @@ class Sudoku {
         });
     }
 
+    // draws the state of the game from the reply of getPuzzle, undo or redo
+    #drawState(body) {
+        let puzzle = body.is_new ? body.puzzle : body.state_puzzle;
+        let candidates = body.state_candidates;
+        this.#_object.querySelectorAll('.sud-row').forEach((_row, row) => {
+            _row.querySelectorAll('.sud-cll').forEach((_cell, col) => {
+                this.#placeDigit(_cell, '0', true);
+                let d = puzzle[row * 9 + col];
+                if ('1' <= d && d <= '9') {
+                    this.#placeDigit(_cell, d, true);
+                    if (body.puzzle[row * 9 + col] !== '.')
+                        _cell.classList.add('hint');
+                }
+                if (candidates.base) {
+                    this.#setCandidatesFor(_cell, candidates.base[this.#stringifyPoint(row, col)] || []);
+                } else {
+                    this.#setCandidatesFor(_cell, []);
+                }
+            });
+        });
+        if (!body.is_new) {
+            this.#deleteWrongs();
+            this.#setWrongs(body.wrongs, body.wrongsCandidates);
+        }
+        if (body.is_win) this.#isWin = true;
+    }
+
+    // draws the next frame of the replay and schedules the following one
+    #replayNext() {
+        let replay = this.#replay;
+        if (replay.paused) return;
+        if (replay.index >= replay.frames.length) {
+            if (replay.frames.length < replay.total) {
+                this.#ws.send('getReplay', {
+                    game_id: this.#gameID,
+                    from: replay.frames.length,
+                });
+            } else {
+                this._showHint('The end of the replay.');
+            }
+            return;
+        }
+        let frame = replay.frames[replay.index++];
+        this.#drawState({puzzle: replay.puzzle, state_puzzle: frame.state_puzzle, state_candidates: frame.state_candidates, wrongsCandidates: {}});
+        this.#deleteStep();
+        let msg = replay.index + '/' + replay.total + ': ' + frame.type;
+        if (frame.step) {
+            this.#setStep({pattern: [frame.step.point]});
+            msg += ' ' + frame.step.type.replace('_', ' ') + ' ' + frame.step.digit + ' in ' + frame.step.point;
+            if (frame.strategy) msg += ' (' + frame.strategy + (frame.justified ? '' : ' was possible') + ')';
+        }
+        this._showHint(msg);
+        if (replay.index < replay.frames.length || replay.frames.length < replay.total) {
+            let next = replay.frames[replay.index];
+            let delay = Math.min(next ? next.delay : 1000, 3000) / parseFloat(replay._speed.value);
+            replay.timer = setTimeout(() => this.#replayNext(), delay);
+        } else {
+            this._showHint(msg + '. The end of the replay.');
+        }
+    }
+
+    // resumes the timer of the player if the page is visible
+    #resume() {
+        if (!this.#_timer || this.#isWin || this.#spectator || document.visibilityState === 'hidden') return;
+        this.#ws.send('resume', {
+            game_id: this.#gameID,
+        });
+    }
+
+    #setTimer(timer) {
+        if (!timer) return;
+        this.#timer = {elapsed: timer.elapsed || 0, running: timer.running, syncedAt: Date.now()};
+        this.#drawTimer();
+    }
+
+    #timerElapsed() {
+        let elapsed = this.#timer.elapsed;
+        if (this.#timer.running) elapsed += Date.now() - this.#timer.syncedAt;
+        return elapsed;
+    }
+
+    #drawTimer() {
+        if (!this.#_timer) return;
+        let seconds = Math.floor(this.#timerElapsed() / 1000);
+        let pad = (n) => (n < 10 ? '0' : '') + n;
+        let text = pad(Math.floor(seconds / 60) % 60) + ':' + pad(seconds % 60);
+        if (seconds >= 3600) text = Math.floor(seconds / 3600) + ':' + text;
+        this.#_timer.textContent = text;
+    }
+
+    #undo() {
+        if (this.#isWin) return;
+        this.#ws.send('undo', {
+            game_id: this.#gameID,
+        });
+    }
+
+    #redo() {
+        if (this.#isWin) return;
+        this.#ws.send('redo', {
+            game_id: this.#gameID,
+        });
+    }
+
+    #deleteStep() {
+        this.#_object.querySelectorAll('.sud-cll').forEach((_cell) => {
+            _cell.classList.remove('step-house', 'step-pattern', 'step-placement');
+        });
+        this.#_object.querySelectorAll('.sud-cnd div').forEach((_cnd) => {
+            _cnd.classList.remove('step-digit', 'step-elimination');
+        });
+    }
+
+    #setStep(payload) {
+        let inHouse = (house, row, col) => {
+            switch (house.type) {
+                case 'row': return house.index === row;
+                case 'col': return house.index === col;
+                case 'box': return house.index === Math.floor(row/3)*3 + Math.floor(col/3);
+            }
+            return false;
+        };
+        let pattern = this.#parsePoints(payload.pattern || []);
+        this.#_object.querySelectorAll('.sud-row').forEach((_row, row) => {
+            _row.querySelectorAll('.sud-cll').forEach((_cell, col) => {
+                let _cnds = _cell.querySelectorAll('.sud-cnd div');
+                (payload.houses || []).forEach((house) => {
+                    if (inHouse(house, row, col)) _cell.classList.add('step-house');
+                });
+                pattern.forEach((p) => {
+                    if (p.row !== row || p.col !== col) return;
+                    _cell.classList.add('step-pattern');
+                    (payload.digits || []).forEach((digit) => _cnds[digit - 1].classList.add('step-digit'));
+                });
+                let point = this.#stringifyPoint(row, col);
+                (payload.placements || []).forEach((placement) => {
+                    if (placement.point === point) _cell.classList.add('step-placement');
+                });
+                (payload.eliminations || []).forEach((elimination) => {
+                    if (elimination.point !== point) return;
+                    elimination.digits.forEach((digit) => _cnds[digit - 1].classList.add('step-elimination'));
+                });
+            });
+        });
+    }
+
     #apiMakeStep(type, point, digit) {
         this.#ws.send('makeStep', {
             game_id: this.#gameID,
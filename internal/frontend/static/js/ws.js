@@ -3,7 +3,7 @@
 class WS {
     #ws;
     #debug = false;
-    #sudoku;
+    #target;
 
     constructor(param) {
         if (!param)
@@ -16,9 +16,10 @@ class WS {
             if (param.debug === true)
                 this.#debug = true
         }
-        if (!param.sudoku)
-            throw 'ws: required parameter \'sudoku\' is not defined';
-        this.#sudoku = param.sudoku;
+        // the target receives replies as 'api_<method>' events and pushed events as 'event_<event>' ones
+        this.#target = param.sudoku || param.target;
+        if (!this.#target)
+            throw 'ws: required parameter \'sudoku\' or \'target\' is not defined';
         this.#connect(param.url);
     }
 
@@ -36,7 +37,7 @@ class WS {
         this.#ws = new WebSocket(url);
         this.#ws.onopen = (e) => {
             if (this.#debug) console.log('ws: open connection');
-            this.#sudoku.dispatchEvent(new CustomEvent('apiReady'));
+            this.#target.dispatchEvent(new CustomEvent('apiReady'));
         }
         this.#ws.onclose = (e) => {
             if (this.#debug) console.log('ws: close connection');
@@ -47,16 +48,31 @@ class WS {
         this.#ws.onmessage = (e) => {
             if (this.#debug) console.log('ws: receive message:', e.data);
             let msg = JSON.parse(e.data);
+            if (msg.event) {
+                if (msg.event === 'notification') this.#notify(msg.body);
+                this.#target.dispatchEvent(new CustomEvent('event_'+msg.event, {detail: msg}));
+                return;
+            }
             if (!msg.method) return;
             if (msg.errorCode) {
                 console.error('api method:', msg.method, 'error:', msg.error, 'error code: ', msg.errorCode);
                 return;
             }
-            this.#sudoku.dispatchEvent(new CustomEvent('api_'+msg.method, {detail: msg}));
+            this.#target.dispatchEvent(new CustomEvent('api_'+msg.method, {detail: msg}));
         }
         this.#ws.onerror = (e) => {
             console.error('ws: error '+e.code+':', e.reason, e);
             this.#ws.close();
         }
     }
+
+    // shows the pushed notification like the notification of the page
+    #notify(body) {
+        let _notification = document.createElement('div');
+        _notification.classList.add('notification');
+        if (body.type) _notification.classList.add(body.type);
+        _notification.textContent = body.message;
+        document.body.prepend(_notification);
+        setTimeout(() => _notification.remove(), 3000);
+    }
 }
\ No newline at end of file
@@ -17,6 +17,7 @@ var JS embed.FS
 const (
 	JsSudoku = "sudoku"
 	JsWs     = "ws"
+	JsDrill  = "drill"
 )
 
 // Favicon is a website icon file.
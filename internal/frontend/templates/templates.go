@@ -11,12 +11,20 @@ import (
 var FS embed.FS
 
 const (
-	PageHome     = "page_home"
-	PageError    = "page_error"
-	PageLogin    = "page_login"
-	PageSignup   = "page_signup"
-	PageSettings = "page_settings"
-	PageGameID   = "page_game_id"
+	PageHome         = "page_home"
+	PageError        = "page_error"
+	PageLogin        = "page_login"
+	PageSignup       = "page_signup"
+	PageSettings     = "page_settings"
+	PageStats        = "page_stats"
+	PageDaily        = "page_daily"
+	PageLeaderboards = "page_leaderboards"
+	PageGameID       = "page_game_id"
+	PageGameReplay   = "page_game_replay"
+	PageGameWatch    = "page_game_watch"
+	PageRace         = "page_race"
+	PageDrill        = "page_drill"
+	PageDrillID      = "page_drill_id"
 )
 
 func CommonTemplates() []string {
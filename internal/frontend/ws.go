@@ -30,7 +30,8 @@ func wsGetIncoming(method string) (wsIncomingRequest, error) {
 	if !ok {
 		return nil, fmt.Errorf("method not allowed")
 	}
-	return req, nil
+	// every message is decoded into a new request
+	return reflect.New(reflect.TypeOf(req).Elem()).Interface().(wsIncomingRequest), nil
 }
 
 var wsPool = wsMessagesPool{
@@ -2,6 +2,7 @@ package frontend
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/cnblvr/puzzles/app"
 	"github.com/pkg/errors"
 )
@@ -12,10 +13,18 @@ func init() {
 
 type wsGetHintRequest struct {
 	wsGameMiddleware
+	// HintLevel is the amount of help; app.HintLevelStrategy if omitted.
+	HintLevel app.HintLevel `json:"hintLevel"`
 }
 
 func (r *wsGetHintRequest) Validate(ctx context.Context) app.Status {
-	return nil
+	if r.HintLevel == 0 {
+		r.HintLevel = app.HintLevelStrategy
+	}
+	if err := r.HintLevel.Validate(); err != nil {
+		return app.StatusBadRequest.WithMessage("invalid .hintLevel").WithError(errors.WithStack(err))
+	}
+	return r.validateGameNotPaused()
 }
 
 func (r *wsGetHintRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
@@ -27,15 +36,87 @@ func (r *wsGetHintRequest) Execute(ctx context.Context) (wsIncomingReply, app.St
 		return nil, app.StatusBadRequest.WithError(errors.WithStack(err))
 	}
 
-	_, step, err := statePuzzle.SolveOneStep(r.game.StateCandidates, r.puzzle.Level.Strategies())
+	// The solver relies on the user's state and candidates, so mistakes must be
+	// fixed before looking for the next logical step.
+	wrongs, removedCandidates, err := statePuzzle.GetMistakes(r.puzzle.Solution, r.game.StateCandidates)
+	if err != nil {
+		return nil, app.StatusBadRequest.WithMessage("wrong format candidates").WithError(errors.WithStack(err))
+	}
+	if len(wrongs) > 0 || removedCandidates != emptyCandidates {
+		rpl.Status = wsHintStatusMistakes
+		rpl.Wrongs = wrongs
+		if removedCandidates != emptyCandidates {
+			rpl.RemovedCandidates = json.RawMessage(removedCandidates)
+		}
+		// the mistakes are checked against the solution, so they are counted as the hint of the cells
+		if status := r.countHint(ctx, app.HintLevelPoints); status != nil {
+			return nil, status
+		}
+		return rpl, nil
+	}
+
+	outcome, err := statePuzzle.SolveOneStep(r.game.StateCandidates, r.puzzle.Level.Strategies())
 	if err != nil {
-		return nil, app.StatusUnknown.WithMessage("TODO").WithError(errors.New("TODO failed to solve")) // TODO
+		return nil, app.StatusBadRequest.WithMessage("wrong format candidates").WithError(errors.WithStack(err))
+	}
+	rpl.Status = string(outcome.Status)
+	switch outcome.Status {
+	case app.SolveStatusProgress:
+		rpl.fillStep(outcome.Step, r.HintLevel)
+		if status := r.countHint(ctx, r.HintLevel); status != nil {
+			return nil, status
+		}
+	case app.SolveStatusContradiction:
+		rpl.Contradiction = outcome.Contradiction
+	case app.SolveStatusSolved, app.SolveStatusStuck:
+	default:
+		return nil, app.StatusInternalServerError.WithError(errors.Errorf("unknown solve status %q", outcome.Status))
 	}
-	rpl.Strategy = step.Strategy().String()
 
 	return rpl, nil
 }
 
+// countHint counts the hint of the level in the game, so the hint is never shown without being counted.
+func (m *wsGameMiddleware) countHint(ctx context.Context, level app.HintLevel) app.Status {
+	return m.updateGame(ctx, func(game *app.PuzzleGame) bool {
+		game.Hints.Add(level)
+		return true
+	})
+}
+
+// wsHintStatusMistakes is the status of the hint when the user must fix the
+// mistakes first. Other statuses are app.SolveStatus.
+const wsHintStatusMistakes = "mistakes"
+
+// emptyCandidates is the encoded candidates without any digit.
+const emptyCandidates = `{}`
+
 type wsGetHintReply struct {
-	Strategy string `json:"strategy,omitempty"`
+	Status string `json:"status"`
+	// if Status is app.SolveStatusProgress, by hint level
+	HintLevel        app.HintLevel          `json:"hintLevel,omitempty"`
+	Strategy         string                 `json:"strategy,omitempty"`
+	Payload          *app.PuzzleStepPayload `json:"payload,omitempty"`
+	CandidateChanges json.RawMessage        `json:"candidateChanges,omitempty"`
+	Description      string                 `json:"description,omitempty"`
+
+	// if Status is app.SolveStatusContradiction
+	Contradiction *app.PuzzleContradiction `json:"contradiction,omitempty"`
+	// if Status is wsHintStatusMistakes
+	Wrongs            []app.Point     `json:"wrongs,omitempty"`
+	RemovedCandidates json.RawMessage `json:"removedCandidates,omitempty"`
+}
+
+// fillStep reveals the step up to the hint level.
+func (r *wsGetHintReply) fillStep(step app.PuzzleStep, level app.HintLevel) {
+	r.HintLevel = level
+	r.Strategy = step.Strategy().String()
+	if level >= app.HintLevelHouses {
+		payload := step.Payload().ForLevel(level)
+		r.Payload = &payload
+	}
+	if level >= app.HintLevelChanges {
+		r.CandidateChanges = json.RawMessage(step.CandidateChanges())
+		r.Description = step.Description()
+	}
 }
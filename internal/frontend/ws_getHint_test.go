@@ -2,11 +2,13 @@ package frontend
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/cnblvr/puzzles/app"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestWsGetHint(t *testing.T) {
@@ -15,12 +17,30 @@ func TestWsGetHint(t *testing.T) {
 		req              wsGetHintRequest
 		getPuzzleAndGame func(ctx context.Context, id uuid.UUID) (*app.Puzzle, *app.PuzzleGame, error)
 		getAssistant     func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error)
+		wantValidateSts  app.Status
 		wantRpl          wsIncomingReply
-		wantSts          app.Status
+		// updateErrs are returned by UpdatePuzzleGame one by one, then it succeeds
+		updateErrs []error
+		wantSts    app.Status
+		wantHints  app.HintCounter
 	}{
+		{
+			name:             "unknown hint level",
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware(), HintLevel: app.MaxHintLevel + 1},
+			getPuzzleAndGame: mockGetPuzzleAndGame(),
+			wantValidateSts:  app.StatusBadRequest,
+		},
+		{
+			name: "paused game",
+			req:  wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware()},
+			getPuzzleAndGame: func(ctx context.Context, id uuid.UUID) (*app.Puzzle, *app.PuzzleGame, error) {
+				return &app.Puzzle{ID: 1}, &app.PuzzleGame{ID: id, StartedAt: app.DateTime{Time: time.Now()}}, nil
+			},
+			wantValidateSts: app.StatusBadRequest,
+		},
 		{
 			name:             "unknown puzzle type",
-			req:              wsGetHintRequest{mockWsGameMiddleware()},
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware()},
 			getPuzzleAndGame: mockGetPuzzleAndGame(),
 			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
 				return nil, app.ErrorPuzzleTypeUnknown
@@ -28,49 +48,205 @@ func TestWsGetHint(t *testing.T) {
 			wantSts: app.StatusBadRequest,
 		},
 		{
-			name:             "solve one step error",
-			req:              wsGetHintRequest{mockWsGameMiddleware()},
+			name:             "wrong format candidates",
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware()},
+			getPuzzleAndGame: mockGetPuzzleAndGame(),
+			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
+				return mockPuzzleAssistant{
+					getMistakes: mockGetMistakesNone(),
+					solveOneStep: func(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
+						return app.SolveOutcome{}, errors.Errorf("any error")
+					},
+				}, nil
+			},
+			wantSts: app.StatusBadRequest,
+		},
+		{
+			name:             "mistakes",
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware()},
 			getPuzzleAndGame: mockGetPuzzleAndGame(),
 			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
 				return mockPuzzleAssistant{
-					solveOneStep: func(candidatesIn string, strategies app.PuzzleStrategy) (candidatesChanges string, step app.PuzzleStep, err error) {
-						return "", nil, errors.Errorf("any error")
+					getMistakes: func(solution string, candidates string) ([]app.Point, string, error) {
+						return []app.Point{{Row: 0, Col: 0}}, `{"base":{"b2":[5]}}`, nil
 					},
 				}, nil
 			},
-			wantSts: app.StatusUnknown,
+			wantRpl: &wsGetHintReply{
+				Status:            wsHintStatusMistakes,
+				Wrongs:            []app.Point{{Row: 0, Col: 0}},
+				RemovedCandidates: json.RawMessage(`{"base":{"b2":[5]}}`),
+			},
+			wantHints: app.HintCounter{0, 0, 1, 0},
 		},
 		{
 			name:             "success",
-			req:              wsGetHintRequest{mockWsGameMiddleware()},
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware()},
 			getPuzzleAndGame: mockGetPuzzleAndGame(),
 			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
 				return mockPuzzleAssistant{
-					solveOneStep: func(candidatesIn string, strategies app.PuzzleStrategy) (candidatesChanges string, step app.PuzzleStep, err error) {
-						return "", mockPuzzleStep{
-							strategy: func() app.PuzzleStrategy {
-								return app.StrategyNakedSingle
+					getMistakes: mockGetMistakesNone(),
+					solveOneStep: func(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
+						return app.SolveOutcome{
+							Status:  app.SolveStatusProgress,
+							Changed: true,
+							Step: mockPuzzleStep{
+								strategy: func() app.PuzzleStrategy {
+									return app.StrategyNakedSingle
+								},
 							},
 						}, nil
 					},
 				}, nil
 			},
 			wantRpl: &wsGetHintReply{
-				Strategy: app.StrategyNakedSingle.String(),
+				Status:    string(app.SolveStatusProgress),
+				HintLevel: app.HintLevelStrategy,
+				Strategy:  app.StrategyNakedSingle.String(),
+			},
+			wantHints: app.HintCounter{1, 0, 0, 0},
+		},
+		{
+			name:             "success hint level points",
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware(), HintLevel: app.HintLevelPoints},
+			getPuzzleAndGame: mockGetPuzzleAndGame(),
+			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
+				return mockPuzzleAssistant{
+					getMistakes:  mockGetMistakesNone(),
+					solveOneStep: mockSolveOneStepHiddenSingle(),
+				}, nil
+			},
+			wantRpl: &wsGetHintReply{
+				Status:    string(app.SolveStatusProgress),
+				HintLevel: app.HintLevelPoints,
+				Strategy:  app.StrategyHiddenSingle.String(),
+				Payload: &app.PuzzleStepPayload{
+					Houses:  []app.House{{Type: app.HouseRow, Index: 0}},
+					Pattern: []app.Point{{Row: 0, Col: 4}},
+					Digits:  []int8{5},
+				},
+			},
+			wantHints: app.HintCounter{0, 0, 1, 0},
+		},
+		{
+			name:             "success hint level changes",
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware(), HintLevel: app.HintLevelChanges},
+			getPuzzleAndGame: mockGetPuzzleAndGame(),
+			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
+				return mockPuzzleAssistant{
+					getMistakes:  mockGetMistakesNone(),
+					solveOneStep: mockSolveOneStepHiddenSingle(),
+				}, nil
+			},
+			wantRpl: &wsGetHintReply{
+				Status:    string(app.SolveStatusProgress),
+				HintLevel: app.HintLevelChanges,
+				Strategy:  app.StrategyHiddenSingle.String(),
+				Payload: &app.PuzzleStepPayload{
+					Houses:       []app.House{{Type: app.HouseRow, Index: 0}},
+					Pattern:      []app.Point{{Row: 0, Col: 4}},
+					Digits:       []int8{5},
+					Placements:   []app.StepPlacement{{Point: app.Point{Row: 0, Col: 4}, Digit: 5}},
+					Eliminations: []app.StepCandidates{{Point: app.Point{Row: 0, Col: 7}, Digits: []int8{5}}},
+				},
+				CandidateChanges: json.RawMessage(`{"del":{"a5":[3,5,7],"a8":[5]}}`),
+				Description:      "set 5 in point a5",
+			},
+			wantHints: app.HintCounter{0, 0, 0, 1},
+		},
+		{
+			name:             "conflict is retried",
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware()},
+			getPuzzleAndGame: mockGetPuzzleAndGame(),
+			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
+				return mockPuzzleAssistant{
+					getMistakes:  mockGetMistakesNone(),
+					solveOneStep: mockSolveOneStepHiddenSingle(),
+				}, nil
+			},
+			updateErrs: []error{app.ErrorPuzzleGameConflict},
+			wantRpl: &wsGetHintReply{
+				Status:    string(app.SolveStatusProgress),
+				HintLevel: app.HintLevelStrategy,
+				Strategy:  app.StrategyHiddenSingle.String(),
+			},
+			wantHints: app.HintCounter{1, 0, 0, 0},
+		},
+		{
+			name:             "failed to count hint",
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware()},
+			getPuzzleAndGame: mockGetPuzzleAndGame(),
+			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
+				return mockPuzzleAssistant{
+					getMistakes:  mockGetMistakesNone(),
+					solveOneStep: mockSolveOneStepHiddenSingle(),
+				}, nil
+			},
+			updateErrs: []error{errors.Errorf("any error")},
+			wantSts:    app.StatusInternalServerError,
+		},
+		{
+			name:             "stuck",
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware()},
+			getPuzzleAndGame: mockGetPuzzleAndGame(),
+			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
+				return mockPuzzleAssistant{
+					getMistakes: mockGetMistakesNone(),
+					solveOneStep: func(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
+						return app.SolveOutcome{Status: app.SolveStatusStuck, Candidates: `{}`}, nil
+					},
+				}, nil
+			},
+			wantRpl: &wsGetHintReply{
+				Status: string(app.SolveStatusStuck),
+			},
+		},
+		{
+			name:             "contradiction",
+			req:              wsGetHintRequest{wsGameMiddleware: mockWsGameMiddleware()},
+			getPuzzleAndGame: mockGetPuzzleAndGame(),
+			getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
+				return mockPuzzleAssistant{
+					getMistakes: mockGetMistakesNone(),
+					solveOneStep: func(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
+						return app.SolveOutcome{
+							Status:        app.SolveStatusContradiction,
+							Contradiction: &app.PuzzleContradiction{Point: &app.Point{Row: 1, Col: 2}, Reason: "cell b3 has no candidates"},
+						}, nil
+					},
+				}, nil
+			},
+			wantRpl: &wsGetHintReply{
+				Status:        string(app.SolveStatusContradiction),
+				Contradiction: &app.PuzzleContradiction{Point: &app.Point{Row: 1, Col: 2}, Reason: "cell b3 has no candidates"},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			var gotHints app.HintCounter
+			updateErrs := tt.updateErrs
 			ctx := mockService(mockPuzzleRepository{
 				getPuzzleAndGame: tt.getPuzzleAndGame,
+				getPuzzleGame: func(ctx context.Context, id uuid.UUID) (*app.PuzzleGame, error) {
+					return &app.PuzzleGame{ID: id}, nil
+				},
+				updatePuzzleGame: func(ctx context.Context, game *app.PuzzleGame) error {
+					if len(updateErrs) > 0 {
+						err := updateErrs[0]
+						updateErrs = updateErrs[1:]
+						return err
+					}
+					gotHints = game.Hints
+					return nil
+				},
 			}, mockPuzzleLibrary{
 				getAssistant: tt.getAssistant,
 			})
 			if !checkStatus(t, "wsGetHint.GameMiddleware", tt.req.GameMiddleware(ctx), nil) {
 				return
 			}
-			if !checkStatus(t, "wsGetHint.Validate", tt.req.Validate(ctx), nil) {
+			if !checkStatus(t, "wsGetHint.Validate", tt.req.Validate(ctx), tt.wantValidateSts) || tt.wantValidateSts != nil {
 				return
 			}
 			rpl, status := tt.req.Execute(ctx)
@@ -82,6 +258,40 @@ func TestWsGetHint(t *testing.T) {
 					rpl, tt.wantRpl)
 				return
 			}
+			if gotHints != tt.wantHints {
+				t.Errorf("wsGetHint.Execute() got hints = %v, want = %v",
+					gotHints, tt.wantHints)
+				return
+			}
 		})
 	}
 }
+
+func mockSolveOneStepHiddenSingle() func(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
+	return func(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
+		return app.SolveOutcome{
+			Status:  app.SolveStatusProgress,
+			Changed: true,
+			Step: mockPuzzleStep{
+				strategy: func() app.PuzzleStrategy {
+					return app.StrategyHiddenSingle
+				},
+				payload: func() app.PuzzleStepPayload {
+					return app.PuzzleStepPayload{
+						Houses:       []app.House{{Type: app.HouseRow, Index: 0}},
+						Pattern:      []app.Point{{Row: 0, Col: 4}},
+						Digits:       []int8{5},
+						Placements:   []app.StepPlacement{{Point: app.Point{Row: 0, Col: 4}, Digit: 5}},
+						Eliminations: []app.StepCandidates{{Point: app.Point{Row: 0, Col: 7}, Digits: []int8{5}}},
+					}
+				},
+				candidateChanges: func() string {
+					return `{"del":{"a5":[3,5,7],"a8":[5]}}`
+				},
+				description: func() string {
+					return "set 5 in point a5"
+				},
+			},
+		}, nil
+	}
+}
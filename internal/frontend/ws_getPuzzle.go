@@ -15,6 +15,10 @@ type wsGetPuzzleRequest struct {
 	wsGameMiddleware
 }
 
+func (r *wsGetPuzzleRequest) RequiredGameRole() app.GameRole {
+	return app.GameRoleSpectator
+}
+
 func (r *wsGetPuzzleRequest) Validate(ctx context.Context) app.Status {
 	return nil
 }
@@ -24,10 +28,17 @@ func (r *wsGetPuzzleRequest) Execute(ctx context.Context) (wsIncomingReply, app.
 	srv, log := FromContextServiceFrontendOrNil(ctx), FromContextLogger(ctx)
 
 	rpl.Puzzle = r.puzzle.Clues
-	rpl.StatePuzzle = r.game.State
-	rpl.StateCandidates = json.RawMessage(r.game.StateCandidates)
 	rpl.IsNew = r.game.IsNew
 	rpl.IsWin = r.game.IsWin
+	rpl.Shared = r.game.Shared
+	rpl.Timer = newWsTimerReply(r.game)
+	// the board of the paused game is hidden until the game is resumed
+	if r.game.TimerPaused() {
+		rpl.Paused = true
+		return rpl, nil
+	}
+	rpl.StatePuzzle = r.game.State
+	rpl.StateCandidates = json.RawMessage(r.game.StateCandidates)
 
 	statePuzzle, err := srv.puzzleLibrary.GetAssistant(r.puzzle.Type, rpl.StatePuzzle)
 	if err != nil {
@@ -49,9 +60,13 @@ func (r *wsGetPuzzleRequest) Execute(ctx context.Context) (wsIncomingReply, app.
 
 // TODO handle and test
 type wsGetPuzzleReply struct {
-	Puzzle string `json:"puzzle"`
-	IsNew  bool   `json:"is_new,omitempty"`
-	IsWin  bool   `json:"is_win,omitempty"`
+	Puzzle string       `json:"puzzle"`
+	IsNew  bool         `json:"is_new,omitempty"`
+	IsWin  bool         `json:"is_win,omitempty"`
+	Shared bool         `json:"shared,omitempty"`
+	Timer  wsTimerReply `json:"timer"`
+	// Paused is true if the game is paused. The state of the game is not sent then.
+	Paused bool `json:"paused,omitempty"`
 
 	// if IsNew is false
 	StatePuzzle      string          `json:"state_puzzle,omitempty"`
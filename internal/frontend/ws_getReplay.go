@@ -0,0 +1,161 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	wsAddIncoming("getReplay", (*wsGetReplayRequest)(nil))
+}
+
+// wsReplayPageSize is the maximum number of frames in the reply of getReplay.
+const wsReplayPageSize = 50
+
+type wsGetReplayRequest struct {
+	wsGameMiddleware
+	// From is the index of the first frame.
+	From int `json:"from"`
+}
+
+func (r *wsGetReplayRequest) RequiredGameRole() app.GameRole {
+	return app.GameRoleSpectator
+}
+
+func (r *wsGetReplayRequest) Validate(ctx context.Context) app.Status {
+	if r.From < 0 {
+		return app.StatusBadRequest.WithMessage("invalid .from")
+	}
+	return nil
+}
+
+func (r *wsGetReplayRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	rpl := &wsGetReplayReply{
+		Puzzle: r.puzzle.Clues,
+		Frames: make([]wsReplayFrame, 0),
+	}
+	srv := FromContextServiceFrontendOrNil(ctx)
+
+	moves, err := srv.puzzleRepository.GetPuzzleGameMoves(ctx, r.game.ID)
+	if err != nil {
+		return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+
+	type snapshot struct {
+		state, candidates string
+	}
+	current := snapshot{state: r.puzzle.Clues, candidates: r.game.StartCandidates}
+	if current.candidates == "" {
+		current.candidates = emptyCandidates
+	}
+	rpl.StatePuzzle, rpl.StateCandidates = current.state, json.RawMessage(current.candidates)
+	// done are the snapshots before the applied steps, undone are the snapshots
+	// after the undone steps.
+	var done, undone []snapshot
+	var prev *app.PuzzleGameMove
+	for i := range moves {
+		move := moves[i]
+		frame := wsReplayFrame{Type: move.Type, Step: move.Step}
+		if prev != nil {
+			frame.Delay = move.CreatedAt.Sub(prev.CreatedAt.Time).Milliseconds()
+		}
+		prev = &moves[i]
+		inPage := r.From <= rpl.Total && rpl.Total < r.From+wsReplayPageSize
+
+		switch move.Type {
+		case app.MoveStep:
+			if move.Step == nil {
+				continue
+			}
+			if inPage {
+				frame.Strategy, frame.Justified, err = r.tagStep(ctx, current.state, current.candidates, *move.Step)
+				if err != nil {
+					return nil, app.StatusInternalServerError.WithMessage("failed to replay moves").WithError(errors.WithStack(err))
+				}
+			}
+			statePuzzle, err := srv.puzzleLibrary.GetAssistant(r.puzzle.Type, current.state)
+			if err != nil {
+				return nil, app.StatusBadRequest.WithError(errors.WithStack(err))
+			}
+			candidates, _, err := statePuzzle.MakeUserStep(current.candidates, *move.Step)
+			if err != nil {
+				return nil, app.StatusInternalServerError.WithMessage("failed to replay moves").WithError(errors.WithStack(err))
+			}
+			done, undone = append(done, current), nil
+			current = snapshot{state: statePuzzle.String(), candidates: candidates}
+		case app.MoveUndo:
+			if len(done) == 0 {
+				continue
+			}
+			undone = append(undone, current)
+			current, done = done[len(done)-1], done[:len(done)-1]
+		case app.MoveRedo:
+			if len(undone) == 0 {
+				continue
+			}
+			done = append(done, current)
+			current, undone = undone[len(undone)-1], undone[:len(undone)-1]
+		default:
+			continue
+		}
+
+		if inPage {
+			frame.StatePuzzle, frame.StateCandidates = current.state, json.RawMessage(current.candidates)
+			rpl.Frames = append(rpl.Frames, frame)
+		}
+		rpl.Total++
+	}
+
+	return rpl, nil
+}
+
+// tagStep finds the logical step for the state before the user step.
+// justified is true if the user step is a placement or an elimination of this
+// logical step.
+func (r *wsGetReplayRequest) tagStep(ctx context.Context, state, candidates string, step app.PuzzleUserStep) (strategy string, justified bool, err error) {
+	srv := FromContextServiceFrontendOrNil(ctx)
+
+	statePuzzle, err := srv.puzzleLibrary.GetAssistant(r.puzzle.Type, state)
+	if err != nil {
+		return "", false, errors.WithStack(err)
+	}
+	// the solver finds the candidates itself if the user doesn't keep them
+	if candidates == emptyCandidates {
+		candidates = ""
+	}
+	outcome, err := statePuzzle.SolveOneStep(candidates, r.puzzle.Level.Strategies())
+	if err != nil {
+		return "", false, errors.WithStack(err)
+	}
+	if outcome.Status != app.SolveStatusProgress {
+		return "", false, nil
+	}
+	return outcome.Step.Strategy().String(), outcome.Step.Payload().Justifies(step), nil
+}
+
+type wsGetReplayReply struct {
+	// Puzzle are the clues.
+	Puzzle string `json:"puzzle"`
+	// StatePuzzle and StateCandidates are the state at the start of the game.
+	StatePuzzle     string          `json:"state_puzzle"`
+	StateCandidates json.RawMessage `json:"state_candidates"`
+	Frames          []wsReplayFrame `json:"frames"`
+	// Total is the number of frames in the replay.
+	Total int `json:"total"`
+}
+
+// wsReplayFrame is a move of the game with the state after it.
+type wsReplayFrame struct {
+	Type app.MoveType        `json:"type"`
+	Step *app.PuzzleUserStep `json:"step,omitempty"`
+	// Delay is the time since the previous move in milliseconds.
+	Delay int64 `json:"delay"`
+	// Strategy is the logical strategy for the state before the step.
+	Strategy string `json:"strategy,omitempty"`
+	// Justified is true if the step follows from Strategy.
+	Justified       bool            `json:"justified,omitempty"`
+	StatePuzzle     string          `json:"state_puzzle"`
+	StateCandidates json.RawMessage `json:"state_candidates"`
+}
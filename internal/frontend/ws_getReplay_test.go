@@ -0,0 +1,95 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/google/uuid"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWsGetReplay(t *testing.T) {
+	start := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) app.DateTime {
+		return app.DateTime{Time: start.Add(time.Duration(sec) * time.Second)}
+	}
+	step := func(digit int8) *app.PuzzleUserStep {
+		return &app.PuzzleUserStep{Type: app.UserStepSetDigit, Point: app.Point{Row: 0, Col: 0}, Digit: digit}
+	}
+	moves := []app.PuzzleGameMove{
+		{Type: app.MoveStep, Step: step(1), CreatedAt: at(0)},
+		{Type: app.MoveStep, Step: step(2), CreatedAt: at(2)},
+		{Type: app.MoveUndo, CreatedAt: at(3)},
+		{Type: app.MoveRedo, CreatedAt: at(5)},
+	}
+	ctx := mockService(mockPuzzleRepository{
+		getPuzzleAndGame: func(ctx context.Context, id uuid.UUID) (*app.Puzzle, *app.PuzzleGame, error) {
+			return &app.Puzzle{ID: 1, Clues: "s", Level: app.PuzzleLevelEasy}, &app.PuzzleGame{ID: id, IsWin: true}, nil
+		},
+		getPuzzleGameMoves: func(ctx context.Context, gameID uuid.UUID) ([]app.PuzzleGameMove, error) {
+			return moves, nil
+		},
+	}, mockPuzzleLibrary{
+		getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
+			// the state is the list of placed digits
+			state := puzzle
+			return &mockPuzzleAssistant{
+				string: func() string {
+					return state
+				},
+				makeUserStep: func(candidatesIn string, step app.PuzzleUserStep) (string, string, error) {
+					state += string('0' + byte(step.Digit))
+					return candidatesIn, `{}`, nil
+				},
+				solveOneStep: func(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
+					if candidatesIn != "" {
+						t.Errorf("SolveOneStep() got candidates = %q, want simple candidates", candidatesIn)
+					}
+					return app.SolveOutcome{
+						Status: app.SolveStatusProgress,
+						Step: mockPuzzleStep{
+							strategy: func() app.PuzzleStrategy {
+								return app.StrategyNakedSingle
+							},
+							payload: func() app.PuzzleStepPayload {
+								return app.PuzzleStepPayload{
+									Placements: []app.StepPlacement{{Point: app.Point{Row: 0, Col: 0}, Digit: 1}},
+								}
+							},
+						},
+					}, nil
+				},
+			}, nil
+		},
+	})
+	req := &wsGetReplayRequest{wsGameMiddleware: mockWsGameMiddleware()}
+	if !checkStatus(t, "wsGetReplay.GameMiddleware", req.GameMiddleware(ctx), nil) {
+		return
+	}
+	if !checkStatus(t, "wsGetReplay.Validate", req.Validate(ctx), nil) {
+		return
+	}
+	rpl, status := req.Execute(ctx)
+	if !checkStatus(t, "wsGetReplay.Execute", status, nil) {
+		return
+	}
+	want := &wsGetReplayReply{
+		Puzzle:          "s",
+		StatePuzzle:     "s",
+		StateCandidates: json.RawMessage(`{}`),
+		Frames: []wsReplayFrame{
+			{Type: app.MoveStep, Step: step(1), Strategy: "Naked Single", Justified: true,
+				StatePuzzle: "s1", StateCandidates: json.RawMessage(`{}`)},
+			{Type: app.MoveStep, Step: step(2), Delay: 2000, Strategy: "Naked Single",
+				StatePuzzle: "s12", StateCandidates: json.RawMessage(`{}`)},
+			{Type: app.MoveUndo, Delay: 1000, StatePuzzle: "s1", StateCandidates: json.RawMessage(`{}`)},
+			{Type: app.MoveRedo, Delay: 2000, StatePuzzle: "s12", StateCandidates: json.RawMessage(`{}`)},
+		},
+		Total: 4,
+	}
+	if !reflect.DeepEqual(rpl, want) {
+		t.Errorf("wsGetReplay.Execute() got = %+v, want = %+v", rpl, want)
+	}
+}
@@ -0,0 +1,299 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+const (
+	// wsOutboundQueueSize is the number of messages waiting to be written to a connection. A connection that does
+	// not read its messages fast enough is closed when the queue is full.
+	wsOutboundQueueSize = 64
+
+	// wsWriteTimeout is the time to write one message to a connection.
+	wsWriteTimeout = 10 * time.Second
+)
+
+// wsTopic is a source of events that connections subscribe to.
+type wsTopic string
+
+// wsTopicGame is the topic of the changes of the game.
+func wsTopicGame(gameID uuid.UUID) wsTopic {
+	return wsTopic("game:" + gameID.String())
+}
+
+// wsTopicRace is the topic of the lobby and the progress of the race.
+func wsTopicRace(raceID uuid.UUID) wsTopic {
+	return wsTopic("race:" + raceID.String())
+}
+
+// wsTopicNotifications is the topic of the notifications for the user (or the anonymous session).
+func wsTopicNotifications(session *app.Session) wsTopic {
+	if session.UserID > 0 {
+		return wsTopic(fmt.Sprintf("notifications:user:%d", session.UserID))
+	}
+	return wsTopic(fmt.Sprintf("notifications:session:%d", session.SessionID))
+}
+
+// wsTopicPool is the topic of new puzzles in the pool of the type and level.
+func wsTopicPool(typ app.PuzzleType, level app.PuzzleLevel) wsTopic {
+	return wsTopic("pool:" + typ.String() + ":" + level.String())
+}
+
+// wsConnection is the state of a websocket connection.
+type wsConnection struct {
+	// id identifies the connection for other players of shared games.
+	id      string
+	name    string
+	session *app.Session
+	log     zerolog.Logger
+	conn    *websocket.Conn
+	// queue is the outbound queue of the connection. Only the writer goroutine writes to conn.
+	queue     chan websocketMessage
+	closeOnce sync.Once
+	done      chan struct{}
+	// games are the games used in the connection.
+	games map[uuid.UUID]struct{}
+	// topics are the topics the connection is subscribed to. They are used only by the reading goroutine.
+	topics map[wsTopic]struct{}
+}
+
+func newWsConnection(conn *websocket.Conn, session *app.Session, name string, log zerolog.Logger) *wsConnection {
+	return &wsConnection{
+		id:      uuid.New().String(),
+		name:    name,
+		session: session,
+		log:     log,
+		conn:    conn,
+		queue:   make(chan websocketMessage, wsOutboundQueueSize),
+		done:    make(chan struct{}),
+		games:   make(map[uuid.UUID]struct{}),
+		topics:  make(map[wsTopic]struct{}),
+	}
+}
+
+// write queues the message for the client. The connection is closed if its queue is full.
+func (c *wsConnection) write(msg websocketMessage) {
+	select {
+	case <-c.done:
+	case c.queue <- msg:
+	default:
+		c.log.Warn().Str("method", msg.Method).Str("event", msg.Event).Msg("outbound queue is full")
+		c.close()
+	}
+}
+
+// writeLoop writes the queued messages to the client until the connection is closed.
+func (c *wsConnection) writeLoop() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.queue:
+			bts, err := json.Marshal(msg)
+			if err != nil {
+				c.log.Error().Err(err).Msg("failed to marshal message")
+				continue
+			}
+			c.log.Debug().Msgf("ws response: %s", bts)
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, bts); err != nil {
+				c.log.Error().Err(err).Msg("failed to write message")
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// close stops the writer goroutine and closes the connection, so the reading goroutine stops too.
+func (c *wsConnection) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// wsHub keeps the subscribers of every topic to push events to them. The events are fanned out between instances of
+// the frontend if the hub is connected to the events repository, so players of the same game may be connected to
+// different instances.
+type wsHub struct {
+	mx     sync.RWMutex
+	topics map[wsTopic]map[*wsConnection]struct{}
+	// watchers are the topics watched by a goroutine of the instance, see startWatcher.
+	watchers map[wsTopic]struct{}
+	// events and subscription are nil if events are delivered only to the connections of the instance.
+	events       app.EventRepository
+	subscription app.EventSubscription
+}
+
+func newWsHub() *wsHub {
+	return &wsHub{
+		topics:   make(map[wsTopic]map[*wsConnection]struct{}),
+		watchers: make(map[wsTopic]struct{}),
+	}
+}
+
+// fanOut connects the hub to other instances. Topics with subscribers on the instance are subscribed in the events
+// repository, and published events come back to the hub through the subscription.
+//
+// Errors: unknown.
+func (h *wsHub) fanOut(events app.EventRepository) error {
+	subscription, err := events.SubscribeEvents(context.Background())
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	h.events, h.subscription = events, subscription
+	go func() {
+		for event := range subscription.Events() {
+			h.deliver(wsTopic(event.Topic), event.Except, websocketMessage{Event: event.Name, Body: event.Body})
+		}
+	}()
+	return nil
+}
+
+// subscribe adds the connection to the topic and returns true if it is the first subscriber of the topic.
+func (h *wsHub) subscribe(topic wsTopic, c *wsConnection) bool {
+	if h == nil {
+		return false
+	}
+	if _, ok := c.topics[topic]; ok {
+		return false
+	}
+	c.topics[topic] = struct{}{}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	conns, ok := h.topics[topic]
+	if !ok {
+		conns = make(map[*wsConnection]struct{})
+		h.topics[topic] = conns
+		// the repository is changed under the lock to keep the order of subscribing and unsubscribing
+		if h.subscription != nil {
+			if err := h.subscription.Subscribe(string(topic)); err != nil {
+				c.log.Error().Err(err).Str("topic", string(topic)).Msg("failed to subscribe to events")
+			}
+		}
+	}
+	conns[c] = struct{}{}
+	return !ok
+}
+
+// unsubscribe removes the connection from the topic and returns the number of remaining subscribers of the topic.
+func (h *wsHub) unsubscribe(topic wsTopic, c *wsConnection) int {
+	if h == nil {
+		return 0
+	}
+	delete(c.topics, topic)
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	conns := h.topics[topic]
+	delete(conns, c)
+	if len(conns) == 0 {
+		delete(h.topics, topic)
+		if h.subscription != nil {
+			if err := h.subscription.Unsubscribe(string(topic)); err != nil {
+				c.log.Error().Err(err).Str("topic", string(topic)).Msg("failed to unsubscribe from events")
+			}
+		}
+	}
+	return len(conns)
+}
+
+// startWatcher returns true if the topic has no watcher yet, so the caller starts one. The watcher keeps running
+// until stopWatcher returns true, even if the topic has lost all subscribers and got new ones in the meantime.
+func (h *wsHub) startWatcher(topic wsTopic) bool {
+	if h == nil {
+		return false
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if _, ok := h.watchers[topic]; ok {
+		return false
+	}
+	h.watchers[topic] = struct{}{}
+	return true
+}
+
+// stopWatcher returns true and forgets the watcher of the topic if the topic has no subscribers on the instance.
+func (h *wsHub) stopWatcher(topic wsTopic) bool {
+	if h == nil {
+		return true
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if len(h.topics[topic]) > 0 {
+		return false
+	}
+	delete(h.watchers, topic)
+	return true
+}
+
+// subscribers returns the number of subscribers of the topic on the instance.
+func (h *wsHub) subscribers(topic wsTopic) int {
+	if h == nil {
+		return 0
+	}
+	h.mx.RLock()
+	defer h.mx.RUnlock()
+	return len(h.topics[topic])
+}
+
+// publish pushes the event to all subscribers of the topic on all instances except the connection. except can be nil.
+func (h *wsHub) publish(topic wsTopic, except *wsConnection, event string, body interface{}) {
+	if h == nil {
+		return
+	}
+	bts, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+	exceptID := ""
+	if except != nil {
+		exceptID = except.id
+	}
+	if h.events != nil {
+		err := h.events.PublishEvent(context.Background(), app.Event{
+			Topic:  string(topic),
+			Name:   event,
+			Body:   bts,
+			Except: exceptID,
+		})
+		if err == nil {
+			return
+		}
+		// other instances miss the event, but the players of this instance still get it
+		log.Error().Err(err).Str("topic", string(topic)).Msg("failed to publish event")
+	}
+	h.deliver(topic, exceptID, websocketMessage{Event: event, Body: bts})
+}
+
+// publishLocal pushes the event only to the subscribers of the topic on the instance.
+func (h *wsHub) publishLocal(topic wsTopic, event string, body interface{}) {
+	if h == nil {
+		return
+	}
+	bts, err := json.Marshal(body)
+	if err != nil {
+		return
+	}
+	h.deliver(topic, "", websocketMessage{Event: event, Body: bts})
+}
+
+func (h *wsHub) deliver(topic wsTopic, exceptID string, msg websocketMessage) {
+	h.mx.RLock()
+	defer h.mx.RUnlock()
+	for c := range h.topics[topic] {
+		if c.id != exceptID {
+			c.write(msg)
+		}
+	}
+}
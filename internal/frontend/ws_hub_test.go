@@ -0,0 +1,151 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"testing"
+	"time"
+)
+
+func TestWsHub(t *testing.T) {
+	hub := newWsHub()
+	session := &app.Session{CookieSession: app.CookieSession{SessionID: 1}}
+	a := newWsConnection(nil, session, "a", zerolog.Nop())
+	b := newWsConnection(nil, session, "b", zerolog.Nop())
+	topic := wsTopicGame(uuid.New())
+
+	if first := hub.subscribe(topic, a); !first {
+		t.Errorf("subscribe() got first = %v, want = %v", first, true)
+	}
+	if first := hub.subscribe(topic, b); first {
+		t.Errorf("subscribe() got first = %v, want = %v", first, false)
+	}
+
+	hub.publish(topic, a, "gameUpdate", struct{}{})
+	if len(a.queue) != 0 {
+		t.Errorf("publish() got %d messages for the excepted connection", len(a.queue))
+	}
+	select {
+	case msg := <-b.queue:
+		if msg.Event != "gameUpdate" || msg.Method != "" {
+			t.Errorf("publish() got message = %+v", msg)
+		}
+	default:
+		t.Errorf("publish() got no messages")
+	}
+
+	if n := hub.unsubscribe(topic, a); n != 1 {
+		t.Errorf("unsubscribe() got = %d, want = %d", n, 1)
+	}
+	if n := hub.unsubscribe(topic, b); n != 0 {
+		t.Errorf("unsubscribe() got = %d, want = %d", n, 0)
+	}
+	if n := hub.subscribers(topic); n != 0 {
+		t.Errorf("subscribers() got = %d, want = %d", n, 0)
+	}
+}
+
+func TestWsHub_watcher(t *testing.T) {
+	hub := newWsHub()
+	session := &app.Session{CookieSession: app.CookieSession{SessionID: 1}}
+	a := newWsConnection(nil, session, "a", zerolog.Nop())
+	topic := wsTopicPool(app.PuzzleSudokuClassic, app.PuzzleLevelEasy)
+
+	hub.subscribe(topic, a)
+	if ok := hub.startWatcher(topic); !ok {
+		t.Errorf("startWatcher() got = %v, want = %v", ok, true)
+	}
+	if ok := hub.startWatcher(topic); ok {
+		t.Errorf("startWatcher() of the watched topic got = %v, want = %v", ok, false)
+	}
+
+	// the subscriber comes back before the watcher checks the topic
+	hub.unsubscribe(topic, a)
+	hub.subscribe(topic, a)
+	if ok := hub.startWatcher(topic); ok {
+		t.Errorf("startWatcher() of the resubscribed topic got = %v, want = %v", ok, false)
+	}
+	if ok := hub.stopWatcher(topic); ok {
+		t.Errorf("stopWatcher() of the topic with subscribers got = %v, want = %v", ok, false)
+	}
+
+	hub.unsubscribe(topic, a)
+	if ok := hub.stopWatcher(topic); !ok {
+		t.Errorf("stopWatcher() of the topic without subscribers got = %v, want = %v", ok, true)
+	}
+	if ok := hub.startWatcher(topic); !ok {
+		t.Errorf("startWatcher() after stop got = %v, want = %v", ok, true)
+	}
+}
+
+type mockEventRepository struct {
+	events chan app.Event
+	topics map[string]struct{}
+}
+
+func (m *mockEventRepository) PublishEvent(ctx context.Context, event app.Event) error {
+	if _, ok := m.topics[event.Topic]; ok {
+		m.events <- event
+	}
+	return nil
+}
+
+func (m *mockEventRepository) SubscribeEvents(ctx context.Context) (app.EventSubscription, error) {
+	return m, nil
+}
+
+func (m *mockEventRepository) Subscribe(topics ...string) error {
+	for _, topic := range topics {
+		m.topics[topic] = struct{}{}
+	}
+	return nil
+}
+
+func (m *mockEventRepository) Unsubscribe(topics ...string) error {
+	for _, topic := range topics {
+		delete(m.topics, topic)
+	}
+	return nil
+}
+
+func (m *mockEventRepository) Events() <-chan app.Event { return m.events }
+
+func (m *mockEventRepository) Close() error { return nil }
+
+func TestWsHub_fanOut(t *testing.T) {
+	events := &mockEventRepository{events: make(chan app.Event, 1), topics: make(map[string]struct{})}
+	hub := newWsHub()
+	if err := hub.fanOut(events); err != nil {
+		t.Fatalf("fanOut() error = %v", err)
+	}
+	session := &app.Session{CookieSession: app.CookieSession{SessionID: 1}}
+	a := newWsConnection(nil, session, "a", zerolog.Nop())
+	b := newWsConnection(nil, session, "b", zerolog.Nop())
+	topic := wsTopicGame(uuid.New())
+	hub.subscribe(topic, a)
+	hub.subscribe(topic, b)
+	if _, ok := events.topics[string(topic)]; !ok {
+		t.Fatalf("subscribe() did not subscribe to events of the topic")
+	}
+
+	hub.publish(topic, a, "gameUpdate", struct{}{})
+	select {
+	case msg := <-b.queue:
+		if msg.Event != "gameUpdate" {
+			t.Errorf("publish() got message = %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("publish() got no messages")
+	}
+	if len(a.queue) != 0 {
+		t.Errorf("publish() got %d messages for the excepted connection", len(a.queue))
+	}
+
+	hub.unsubscribe(topic, a)
+	hub.unsubscribe(topic, b)
+	if _, ok := events.topics[string(topic)]; ok {
+		t.Errorf("unsubscribe() did not unsubscribe from events of the topic")
+	}
+}
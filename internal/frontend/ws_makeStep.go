@@ -6,6 +6,7 @@ import (
 	"github.com/cnblvr/puzzles/app"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"time"
 )
 
 func init() {
@@ -21,11 +22,10 @@ func (r *wsMakeStepRequest) Validate(ctx context.Context) app.Status {
 	if err := r.Step.Type.Validate(); err != nil {
 		return app.StatusBadRequest.WithMessage("invalid .step").WithError(errors.WithStack(err))
 	}
-	return nil
+	return r.validateGameNotPaused()
 }
 
 func (r *wsMakeStepRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
-	rpl := new(wsMakeStepReply)
 	srv := FromContextServiceFrontendOrNil(ctx)
 
 	statePuzzle, err := srv.puzzleLibrary.GetAssistant(r.puzzle.Type, r.game.State)
@@ -37,27 +37,90 @@ func (r *wsMakeStepRequest) Execute(ctx context.Context) (wsIncomingReply, app.S
 	if err != nil {
 		return nil, app.StatusUnknown.WithMessage("failed to make step").WithError(errors.WithStack(err))
 	}
+	if err := srv.puzzleRepository.AddPuzzleGameMove(ctx, r.game.ID, app.PuzzleGameMove{
+		Type:      app.MoveStep,
+		Step:      &r.Step,
+		CreatedAt: app.DateTime{Time: time.Now()},
+	}); err != nil {
+		return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	mistake := false
+	if r.Step.Type == app.UserStepSetDigit {
+		wrongPoints, _, err := statePuzzle.GetMistakes(r.puzzle.Solution, newStateCandidates)
+		if err != nil {
+			return nil, app.StatusUnknown.WithMessage("failed to get mistakes").WithError(errors.WithStack(err))
+		}
+		for _, p := range wrongPoints {
+			if p == r.Step.Point {
+				mistake = true
+				break
+			}
+		}
+	}
+	countMistake := func(game *app.PuzzleGame) {
+		if mistake {
+			game.Mistakes++
+		}
+	}
+	countMistake(r.game)
 	r.game.IsNew = false
 	r.game.State, r.game.StateCandidates = statePuzzle.String(), newStateCandidates
+	now := time.Now()
+	r.game.TimerTick(now, app.DefaultGameIdleTimeout)
+	if r.game.State == r.puzzle.Solution {
+		r.game.TimerPause(now, app.DefaultGameIdleTimeout)
+		r.game.IsWin = true
+	}
 
-	defer func() {
-		if err := srv.puzzleRepository.UpdatePuzzleGame(ctx, r.game); err != nil {
-			log.Error().Err(err).Msg("failed to update puzzle game")
+	var update *wsUndoReply
+	switch err := srv.puzzleRepository.UpdatePuzzleGame(ctx, r.game); {
+	case err == nil:
+		update = &wsUndoReply{
+			Puzzle:           r.puzzle.Clues,
+			StatePuzzle:      r.game.State,
+			StateCandidates:  json.RawMessage(r.game.StateCandidates),
+			Wrongs:           statePuzzle.GetWrongPoints(),
+			WrongsCandidates: json.RawMessage(wrongCandidates),
+			IsWin:            r.game.IsWin,
+			CanUndo:          true,
+			Timer:            newWsTimerReply(r.game),
 		}
-	}()
+		srv.gameHub.publish(wsTopicGame(r.game.ID), FromContextWsConnectionOrNil(ctx), "gameUpdate", update)
+	case errors.Is(err, app.ErrorPuzzleGameConflict):
+		// Another player has changed the game. The game is rebuilt from the log of moves and all players get the
+		// new state including this one.
+		r.game, err = srv.puzzleRepository.GetPuzzleGame(ctx, r.game.ID)
+		if err != nil {
+			return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+		}
+		var status app.Status
+		update, status = r.rebuild(ctx, countMistake)
+		if status != nil {
+			return nil, status
+		}
+		srv.gameHub.publish(wsTopicGame(r.game.ID), nil, "gameUpdate", update)
+	default:
+		return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
 
-	if r.game.State == r.puzzle.Solution {
-		// WIN
-		r.game.IsWin = true
-		rpl.Win = true
-		log.Info().Stringer("game_id", r.game.ID).Msg("win")
-		return rpl, nil
+	if r.game.Race != "" {
+		if status := srv.updateRaceProgress(ctx, r.puzzle, r.game); status != nil {
+			log.Error().Err(status.GetError()).Stringer("game_id", r.game.ID).Msg("failed to update race progress")
+		}
 	}
 
-	rpl.Wrongs = statePuzzle.GetWrongPoints()
-	rpl.WrongsCandidates = json.RawMessage(wrongCandidates)
+	if update.IsWin {
+		log.Info().Stringer("game_id", r.game.ID).Msg("win")
+		return &wsMakeStepReply{
+			Win:     true,
+			Elapsed: r.game.Elapsed.Milliseconds(),
+		}, nil
+	}
 
-	return rpl, nil
+	return &wsMakeStepReply{
+		Wrongs:           update.Wrongs,
+		WrongsCandidates: update.WrongsCandidates,
+	}, nil
 }
 
 // TODO handle and test
@@ -65,4 +128,6 @@ type wsMakeStepReply struct {
 	Wrongs           []app.Point     `json:"wrongs,omitempty"`
 	WrongsCandidates json.RawMessage `json:"wrongsCandidates,omitempty"`
 	Win              bool            `json:"win,omitempty"`
+	// Elapsed is the solve time in milliseconds if Win is true.
+	Elapsed int64 `json:"elapsed,omitempty"`
 }
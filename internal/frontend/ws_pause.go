@@ -0,0 +1,48 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"time"
+)
+
+func init() {
+	wsAddIncoming("pause", (*wsPauseRequest)(nil))
+}
+
+type wsPauseRequest struct {
+	wsGameMiddleware
+}
+
+func (r *wsPauseRequest) Validate(ctx context.Context) app.Status {
+	return nil
+}
+
+func (r *wsPauseRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	if status := r.updateGame(ctx, func(game *app.PuzzleGame) bool {
+		if !game.TimerRunning() {
+			return false
+		}
+		game.TimerPause(time.Now(), app.DefaultGameIdleTimeout)
+		return true
+	}); status != nil {
+		return nil, status
+	}
+
+	rpl := newWsTimerReply(r.game)
+	return &rpl, nil
+}
+
+// wsTimerReply is the state of the game timer.
+type wsTimerReply struct {
+	// Elapsed is the active time in milliseconds.
+	Elapsed int64 `json:"elapsed"`
+	Running bool  `json:"running"`
+}
+
+func newWsTimerReply(game *app.PuzzleGame) wsTimerReply {
+	return wsTimerReply{
+		Elapsed: game.TimerElapsed(time.Now(), app.DefaultGameIdleTimeout).Milliseconds(),
+		Running: game.TimerRunning(),
+	}
+}
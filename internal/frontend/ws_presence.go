@@ -0,0 +1,42 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+)
+
+func init() {
+	wsAddIncoming("presence", (*wsPresenceRequest)(nil))
+}
+
+// wsPresenceRequest shares the selected cell of the player with other players of the game.
+type wsPresenceRequest struct {
+	wsGameMiddleware
+	// Point is the selected cell. It is empty if nothing is selected.
+	Point *app.Point `json:"point,omitempty"`
+}
+
+func (r *wsPresenceRequest) Validate(ctx context.Context) app.Status {
+	return nil
+}
+
+func (r *wsPresenceRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	srv := FromContextServiceFrontendOrNil(ctx)
+
+	if wsConn := FromContextWsConnectionOrNil(ctx); wsConn != nil && r.game.Shared {
+		srv.gameHub.publish(wsTopicGame(r.game.ID), wsConn, "presence", wsPresenceEvent{
+			ID:    wsConn.id,
+			Name:  wsConn.name,
+			Point: r.Point,
+		})
+	}
+
+	return &struct{}{}, nil
+}
+
+// wsPresenceEvent is the selected cell of another player. Point is empty if the player has no selection or has left.
+type wsPresenceEvent struct {
+	ID    string     `json:"id"`
+	Name  string     `json:"name"`
+	Point *app.Point `json:"point,omitempty"`
+}
@@ -0,0 +1,190 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"time"
+)
+
+func init() {
+	wsAddIncoming("getRace", (*wsGetRaceRequest)(nil))
+	wsAddIncoming("startRace", (*wsStartRaceRequest)(nil))
+}
+
+// wsGetRaceRequest returns the lobby of the race and subscribes the connection to the changes of the race.
+type wsGetRaceRequest struct {
+	RaceID uuid.UUID `json:"race_id"`
+}
+
+func (r *wsGetRaceRequest) Validate(ctx context.Context) app.Status {
+	if r.RaceID == uuid.Nil {
+		return app.StatusBadRequest.WithMessage("race_id is empty")
+	}
+	return nil
+}
+
+func (r *wsGetRaceRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	srv := FromContextServiceFrontendOrNil(ctx)
+
+	race, err := srv.puzzleRepository.GetRace(ctx, r.RaceID)
+	switch {
+	case err == nil:
+	case errors.Is(err, app.ErrorRaceNotFound):
+		return nil, app.StatusBadRequest.WithMessage("race not found").WithError(errors.WithStack(err))
+	default:
+		return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	if wsConn := FromContextWsConnectionOrNil(ctx); wsConn != nil {
+		srv.gameHub.subscribe(wsTopicRace(r.RaceID), wsConn)
+	}
+
+	return srv.raceReply(ctx, race)
+}
+
+// wsStartRaceRequest starts the countdown of the race. Only the owner of the race can start it.
+type wsStartRaceRequest struct {
+	RaceID uuid.UUID `json:"race_id"`
+}
+
+func (r *wsStartRaceRequest) Validate(ctx context.Context) app.Status {
+	if r.RaceID == uuid.Nil {
+		return app.StatusBadRequest.WithMessage("race_id is empty")
+	}
+	return nil
+}
+
+func (r *wsStartRaceRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	srv := FromContextServiceFrontendOrNil(ctx)
+
+	race, err := srv.puzzleRepository.GetRace(ctx, r.RaceID)
+	switch {
+	case err == nil:
+	case errors.Is(err, app.ErrorRaceNotFound):
+		return nil, app.StatusBadRequest.WithMessage("race not found").WithError(errors.WithStack(err))
+	default:
+		return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	if err := race.ValidateSession(FromContextSession(ctx)); err != nil {
+		return nil, app.StatusUnauthorized.WithMessage("only the owner can start the race").WithError(errors.WithStack(err))
+	}
+	if !race.IsCountdown() {
+		race, err = srv.puzzleRepository.StartRace(ctx, r.RaceID, time.Now().Add(app.DefaultRaceCountdown))
+		if err != nil {
+			return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+		}
+	}
+
+	rpl, status := srv.raceReply(ctx, race)
+	if status != nil {
+		return nil, status
+	}
+	srv.gameHub.publish(wsTopicRace(race.ID), FromContextWsConnectionOrNil(ctx), "race", rpl)
+
+	return rpl, nil
+}
+
+// wsRaceReply is the lobby of the race. It is also pushed as the "race" event to all players of the race when
+// the race starts and on every move.
+type wsRaceReply struct {
+	RaceID uuid.UUID `json:"race_id"`
+	// StartIn is the rest of the countdown in milliseconds. It does not depend on the clock of the client.
+	StartIn int64 `json:"start_in"`
+	// Countdown is true if the owner has started the race.
+	Countdown bool             `json:"countdown,omitempty"`
+	Players   []app.RacePlayer `json:"players"`
+}
+
+func (srv *service) raceReply(ctx context.Context, race *app.Race) (*wsRaceReply, app.Status) {
+	players, err := srv.puzzleRepository.GetRacePlayers(ctx, race.ID)
+	if err != nil {
+		return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	app.RankRacePlayers(players)
+	rpl := &wsRaceReply{
+		RaceID:    race.ID,
+		Countdown: race.IsCountdown(),
+		Players:   players,
+	}
+	if race.IsCountdown() {
+		if startIn := time.Until(race.StartAt.Time); startIn > 0 {
+			rpl.StartIn = startIn.Milliseconds()
+		}
+	}
+	return rpl, nil
+}
+
+// updateRaceProgress saves the progress of the race game and pushes the race to all players of the race. The race
+// expires after app.DefaultRaceRetention, but its games do not, so the progress of an expired race is not saved.
+func (srv *service) updateRaceProgress(ctx context.Context, puzzle *app.Puzzle, game *app.PuzzleGame) app.Status {
+	raceID, err := uuid.Parse(game.Race)
+	if err != nil {
+		return app.StatusInternalServerError.WithError(errors.Wrap(err, "race of the game is not uuid"))
+	}
+	race, err := srv.puzzleRepository.GetRace(ctx, raceID)
+	switch {
+	case err == nil:
+	case errors.Is(err, app.ErrorRaceNotFound):
+		return nil
+	default:
+		return app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	players, err := srv.puzzleRepository.GetRacePlayers(ctx, raceID)
+	if err != nil {
+		return app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	var player *app.RacePlayer
+	for i := range players {
+		if players[i].GameID == game.ID {
+			player = &players[i]
+			break
+		}
+	}
+	if player == nil {
+		return app.StatusInternalServerError.WithError(errors.Errorf("game is not a board of the race"))
+	}
+	player.Filled = app.CountFilled(puzzle.Clues, game.State)
+	player.Mistakes = game.Mistakes
+	if game.IsWin && !player.Finished {
+		player.Finished = true
+		player.Elapsed = game.FinishedAt.Sub(race.StartAt.Time)
+	}
+	if err := srv.puzzleRepository.UpdateRacePlayer(ctx, raceID, *player); err != nil {
+		return app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+
+	app.RankRacePlayers(players)
+	srv.gameHub.publish(wsTopicRace(raceID), nil, "race", &wsRaceReply{
+		RaceID:    raceID,
+		Countdown: true,
+		Players:   players,
+	})
+	return nil
+}
+
+// validateRaceStarted forbids the board of the race until the countdown is over. An expired race is over, so its
+// board is allowed.
+//
+// Errors: app.ErrorPuzzleGameNotAllowed, unknown.
+func (srv *service) validateRaceStarted(ctx context.Context, game *app.PuzzleGame) error {
+	if game.Race == "" {
+		return nil
+	}
+	raceID, err := uuid.Parse(game.Race)
+	if err != nil {
+		return errors.Wrap(err, "race of the game is not uuid")
+	}
+	race, err := srv.puzzleRepository.GetRace(ctx, raceID)
+	switch {
+	case err == nil:
+	case errors.Is(err, app.ErrorRaceNotFound):
+		return nil
+	default:
+		return errors.WithStack(err)
+	}
+	if !race.IsStarted(time.Now()) {
+		return app.ErrorPuzzleGameNotAllowed
+	}
+	return nil
+}
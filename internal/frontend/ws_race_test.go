@@ -0,0 +1,69 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"testing"
+	"time"
+)
+
+func TestValidateRaceStarted(t *testing.T) {
+	raceID := uuid.MustParse("aaaabbbb-3333-4444-5555-666677778888")
+	tests := []struct {
+		name    string
+		race    string
+		getRace func(ctx context.Context, id uuid.UUID) (*app.Race, error)
+		wantErr error
+	}{
+		{
+			name: "not a race",
+		},
+		{
+			name: "countdown",
+			race: raceID.String(),
+			getRace: func(ctx context.Context, id uuid.UUID) (*app.Race, error) {
+				return &app.Race{ID: id, StartAt: app.DateTime{Time: time.Now().Add(time.Minute)}}, nil
+			},
+			wantErr: app.ErrorPuzzleGameNotAllowed,
+		},
+		{
+			name: "started",
+			race: raceID.String(),
+			getRace: func(ctx context.Context, id uuid.UUID) (*app.Race, error) {
+				return &app.Race{ID: id, StartAt: app.DateTime{Time: time.Now().Add(-time.Minute)}}, nil
+			},
+		},
+		{
+			name: "expired",
+			race: raceID.String(),
+			getRace: func(ctx context.Context, id uuid.UUID) (*app.Race, error) {
+				return nil, errors.WithStack(app.ErrorRaceNotFound)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := mockService(mockPuzzleRepository{getRace: tt.getRace}, mockPuzzleLibrary{})
+			srv := FromContextServiceFrontendOrNil(ctx)
+			err := srv.validateRaceStarted(ctx, &app.PuzzleGame{Race: tt.race})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("validateRaceStarted() error = %v, want = %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateRaceProgress_expired(t *testing.T) {
+	ctx := mockService(mockPuzzleRepository{
+		getRace: func(ctx context.Context, id uuid.UUID) (*app.Race, error) {
+			return nil, errors.WithStack(app.ErrorRaceNotFound)
+		},
+	}, mockPuzzleLibrary{})
+	srv := FromContextServiceFrontendOrNil(ctx)
+	game := &app.PuzzleGame{Race: "aaaabbbb-3333-4444-5555-666677778888"}
+	if status := srv.updateRaceProgress(ctx, &app.Puzzle{}, game); status != nil {
+		t.Errorf("updateRaceProgress() got status = %v", status.GetError())
+	}
+}
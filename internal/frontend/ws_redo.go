@@ -0,0 +1,25 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+)
+
+func init() {
+	wsAddIncoming("redo", (*wsRedoRequest)(nil))
+}
+
+type wsRedoRequest struct {
+	wsGameMiddleware
+}
+
+func (r *wsRedoRequest) Validate(ctx context.Context) app.Status {
+	if r.game.IsWin {
+		return app.StatusBadRequest.WithMessage("game is over")
+	}
+	return r.validateGameNotPaused()
+}
+
+func (r *wsRedoRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	return r.moveAndRebuild(ctx, app.MoveRedo)
+}
@@ -0,0 +1,34 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"time"
+)
+
+func init() {
+	wsAddIncoming("resume", (*wsResumeRequest)(nil))
+}
+
+type wsResumeRequest struct {
+	wsGameMiddleware
+}
+
+func (r *wsResumeRequest) Validate(ctx context.Context) app.Status {
+	return nil
+}
+
+func (r *wsResumeRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	if status := r.updateGame(ctx, func(game *app.PuzzleGame) bool {
+		if game.TimerRunning() {
+			return false
+		}
+		game.TimerResume(time.Now())
+		return game.TimerRunning()
+	}); status != nil {
+		return nil, status
+	}
+
+	rpl := newWsTimerReply(r.game)
+	return &rpl, nil
+}
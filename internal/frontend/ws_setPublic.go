@@ -0,0 +1,49 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+)
+
+func init() {
+	wsAddIncoming("setPublic", (*wsSetPublicRequest)(nil))
+}
+
+// wsSetPublicRequest lets anyone with the spectator link watch the game or makes the game private again. Only the
+// owner of the game can change it.
+type wsSetPublicRequest struct {
+	wsGameMiddleware
+	Public bool `json:"public"`
+}
+
+func (r *wsSetPublicRequest) RequiredGameRole() app.GameRole {
+	return app.GameRoleOwner
+}
+
+func (r *wsSetPublicRequest) Validate(ctx context.Context) app.Status {
+	return nil
+}
+
+func (r *wsSetPublicRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	if status := r.updateGame(ctx, func(game *app.PuzzleGame) bool {
+		if game.Public == r.Public {
+			return false
+		}
+		game.Public = r.Public
+		return true
+	}); status != nil {
+		return nil, status
+	}
+
+	rpl := &wsSetPublicReply{Public: r.game.Public}
+	if rpl.Public {
+		rpl.Watch = app.EndpointGameWatch{}.Path(r.game.ID)
+	}
+	return rpl, nil
+}
+
+type wsSetPublicReply struct {
+	Public bool `json:"public"`
+	// Watch is the path of the spectator link if the game is public.
+	Watch string `json:"watch,omitempty"`
+}
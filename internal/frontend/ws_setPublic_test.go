@@ -0,0 +1,201 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+func TestWsGameRole(t *testing.T) {
+	owner := &app.Session{CookieSession: app.CookieSession{SessionID: 1}}
+	spectator := &app.Session{CookieSession: app.CookieSession{SessionID: 2}}
+	tests := []struct {
+		name    string
+		session *app.Session
+		public  bool
+		method  string
+		body    string
+		wantSts app.Status
+		// wantSaved is true if the game is updated
+		wantSaved bool
+	}{
+		{
+			name:      "owner makes game public",
+			session:   owner,
+			method:    "setPublic",
+			body:      `{"public":true}`,
+			wantSaved: true,
+		},
+		{
+			name:    "spectator cannot make step",
+			session: spectator,
+			public:  true,
+			method:  "makeStep",
+			body:    `{"step":{"type":"setDigit","point":"a1","digit":1}}`,
+			wantSts: app.StatusUnauthorized,
+		},
+		{
+			name:    "spectator cannot pause",
+			session: spectator,
+			public:  true,
+			method:  "pause",
+			wantSts: app.StatusUnauthorized,
+		},
+		{
+			name:    "spectator cannot make game private",
+			session: spectator,
+			public:  true,
+			method:  "setPublic",
+			body:    `{"public":false}`,
+			wantSts: app.StatusUnauthorized,
+		},
+		{
+			name:    "private game",
+			session: spectator,
+			method:  "getPuzzle",
+			wantSts: app.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := false
+			ctx := mockService(mockPuzzleRepository{
+				getPuzzleAndGame: func(ctx context.Context, id uuid.UUID) (*app.Puzzle, *app.PuzzleGame, error) {
+					return &app.Puzzle{ID: 1}, &app.PuzzleGame{ID: id, SessionID: owner.SessionID, Public: tt.public}, nil
+				},
+				updatePuzzleGame: func(ctx context.Context, game *app.PuzzleGame) error {
+					saved = game.Public != tt.public
+					return nil
+				},
+			}, mockPuzzleLibrary{})
+			ctx = NewContextSession(ctx, tt.session)
+
+			body := map[string]interface{}{"game_id": mockWsGameMiddleware().GameID}
+			if tt.body != "" {
+				if err := json.Unmarshal([]byte(tt.body), &body); err != nil {
+					t.Fatal(err)
+				}
+			}
+			reqBody, _ := json.Marshal(body)
+			_, err := websocketRequestExecute(ctx, tt.method, reqBody)
+			var status app.Status
+			if err != nil {
+				status = err.(app.Status)
+			}
+			if !checkStatus(t, "websocketRequestExecute", status, tt.wantSts) {
+				return
+			}
+			if saved != tt.wantSaved {
+				t.Errorf("websocketRequestExecute() saved = %t, want = %t", saved, tt.wantSaved)
+			}
+		})
+	}
+}
+
+func TestWsUpdateGameConflict(t *testing.T) {
+	owner := &app.Session{CookieSession: app.CookieSession{SessionID: 1}}
+	started := app.DateTime{Time: time.Now().Add(-time.Minute)}
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		// conflicts is the number of failed updates before the update succeeds
+		conflicts int
+		wantSts   app.Status
+		wantGame  func(game *app.PuzzleGame) bool
+	}{
+		{
+			name:      "pause",
+			method:    "pause",
+			conflicts: 1,
+			wantGame: func(game *app.PuzzleGame) bool {
+				return !game.TimerRunning()
+			},
+		},
+		{
+			name:      "resume",
+			method:    "resume",
+			conflicts: 1,
+			wantGame: func(game *app.PuzzleGame) bool {
+				return game.TimerRunning()
+			},
+		},
+		{
+			name:      "share",
+			method:    "share",
+			conflicts: 1,
+			wantGame: func(game *app.PuzzleGame) bool {
+				return game.Shared && game.InviteCode != ""
+			},
+		},
+		{
+			name:      "setPublic",
+			method:    "setPublic",
+			body:      `{"public":true}`,
+			conflicts: 1,
+			wantGame: func(game *app.PuzzleGame) bool {
+				return game.Public
+			},
+		},
+		{
+			name:      "too many conflicts",
+			method:    "setPublic",
+			body:      `{"public":true}`,
+			conflicts: wsMaxConflictAttempts,
+			wantSts:   app.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// every read returns the game as it is changed by another request: paused for resume, running otherwise
+			newGame := func(id uuid.UUID) *app.PuzzleGame {
+				game := &app.PuzzleGame{ID: id, SessionID: owner.SessionID, StartedAt: started}
+				if tt.method != "resume" {
+					game.ActiveAt = app.DateTime{Time: time.Now()}
+				}
+				return game
+			}
+			conflicts := tt.conflicts
+			var saved *app.PuzzleGame
+			ctx := mockService(mockPuzzleRepository{
+				getPuzzleAndGame: func(ctx context.Context, id uuid.UUID) (*app.Puzzle, *app.PuzzleGame, error) {
+					return &app.Puzzle{ID: 1}, newGame(id), nil
+				},
+				getPuzzleGame: func(ctx context.Context, id uuid.UUID) (*app.PuzzleGame, error) {
+					return newGame(id), nil
+				},
+				updatePuzzleGame: func(ctx context.Context, game *app.PuzzleGame) error {
+					if conflicts > 0 {
+						conflicts--
+						return app.ErrorPuzzleGameConflict
+					}
+					saved = game
+					return nil
+				},
+			}, mockPuzzleLibrary{})
+			ctx = NewContextSession(ctx, owner)
+
+			body := map[string]interface{}{"game_id": mockWsGameMiddleware().GameID}
+			if tt.body != "" {
+				if err := json.Unmarshal([]byte(tt.body), &body); err != nil {
+					t.Fatal(err)
+				}
+			}
+			reqBody, _ := json.Marshal(body)
+			_, err := websocketRequestExecute(ctx, tt.method, reqBody)
+			var status app.Status
+			if err != nil {
+				status = err.(app.Status)
+			}
+			if !checkStatus(t, "websocketRequestExecute", status, tt.wantSts) || tt.wantSts != nil {
+				return
+			}
+			if saved == nil || !tt.wantGame(saved) {
+				t.Errorf("websocketRequestExecute() saved game = %+v", saved)
+			}
+		})
+	}
+}
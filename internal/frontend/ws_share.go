@@ -0,0 +1,47 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+)
+
+func init() {
+	wsAddIncoming("share", (*wsShareRequest)(nil))
+}
+
+// wsShareRequest makes the game shared. Only the owner of the game can invite other players.
+type wsShareRequest struct {
+	wsGameMiddleware
+}
+
+func (r *wsShareRequest) RequiredGameRole() app.GameRole {
+	return app.GameRoleOwner
+}
+
+func (r *wsShareRequest) Validate(ctx context.Context) app.Status {
+	if r.game.Race != "" {
+		return app.StatusBadRequest.WithMessage("the board of the race cannot be shared")
+	}
+	return nil
+}
+
+func (r *wsShareRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	if status := r.updateGame(ctx, func(game *app.PuzzleGame) bool {
+		if game.Shared {
+			return false
+		}
+		game.Share()
+		return true
+	}); status != nil {
+		return nil, status
+	}
+
+	return &wsShareReply{
+		Invite: app.EndpointGameJoin{}.Path(r.game.ID, r.game.InviteCode),
+	}, nil
+}
+
+type wsShareReply struct {
+	// Invite is the path of the invite link.
+	Invite string `json:"invite"`
+}
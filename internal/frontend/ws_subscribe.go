@@ -0,0 +1,120 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+func init() {
+	wsAddIncoming("subscribe", (*wsSubscribeRequest)(nil))
+}
+
+// wsPoolWatchInterval is the interval of checking the size of the pool that has subscribers.
+const wsPoolWatchInterval = 10 * time.Second
+
+const (
+	// wsSubscribeNotifications subscribes to the "notification" events. Every connection is subscribed on open.
+	wsSubscribeNotifications = "notifications"
+	// wsSubscribePool subscribes to the "poolRefill" event of the pool of the type and level, e.g. after the
+	// empty pool error.
+	wsSubscribePool = "pool"
+)
+
+// wsSubscribeRequest subscribes the connection to the events of the topic.
+// The events of games and races are subscribed by the requests of the game or the race.
+type wsSubscribeRequest struct {
+	Topic       string          `json:"topic"`
+	PuzzleType  app.PuzzleType  `json:"puzzle_type,omitempty"`
+	PuzzleLevel app.PuzzleLevel `json:"puzzle_level,omitempty"`
+}
+
+func (r *wsSubscribeRequest) Validate(ctx context.Context) app.Status {
+	switch r.Topic {
+	case wsSubscribeNotifications:
+	case wsSubscribePool:
+		if r.PuzzleType == "" || r.PuzzleLevel == app.PuzzleLevelUnknown {
+			return app.StatusBadRequest.WithMessage("puzzle_type and puzzle_level are required")
+		}
+		// only the pools of the home page are watched, every other pool would start a watcher that never fires
+		if !isPlayableType(r.PuzzleType) {
+			return app.StatusBadRequest.WithMessage("unsupported puzzle_type")
+		}
+		if !isPlayableLevel(r.PuzzleLevel) {
+			return app.StatusBadRequest.WithMessage("unsupported puzzle_level")
+		}
+	default:
+		return app.StatusBadRequest.WithMessage("unknown topic")
+	}
+	return nil
+}
+
+func (r *wsSubscribeRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	srv := FromContextServiceFrontendOrNil(ctx)
+	wsConn := FromContextWsConnectionOrNil(ctx)
+	if wsConn == nil {
+		return nil, app.StatusInternalServerError.WithError(errors.Errorf("no websocket connection"))
+	}
+
+	switch r.Topic {
+	case wsSubscribeNotifications:
+		srv.gameHub.subscribe(wsTopicNotifications(FromContextSession(ctx)), wsConn)
+	case wsSubscribePool:
+		size, err := srv.puzzleRepository.GetPuzzlePoolSize(ctx, r.PuzzleType, r.PuzzleLevel)
+		if err != nil {
+			return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+		}
+		topic := wsTopicPool(r.PuzzleType, r.PuzzleLevel)
+		srv.gameHub.subscribe(topic, wsConn)
+		if srv.gameHub.startWatcher(topic) {
+			go srv.watchPool(r.PuzzleType, r.PuzzleLevel, size)
+		}
+	}
+
+	return &struct{}{}, nil
+}
+
+// wsPoolRefillEvent is pushed when new puzzles are added to the pool.
+type wsPoolRefillEvent struct {
+	PuzzleType  app.PuzzleType  `json:"puzzle_type"`
+	PuzzleLevel app.PuzzleLevel `json:"puzzle_level"`
+	Size        int             `json:"size"`
+}
+
+// watchPool pushes the "poolRefill" event to the subscribers of the pool every time the pool grows from size.
+// It stops when the pool has no subscribers anymore. Only one watcher of the pool runs on the instance.
+func (srv *service) watchPool(typ app.PuzzleType, level app.PuzzleLevel, size int) {
+	ctx := context.Background()
+	topic := wsTopicPool(typ, level)
+	ticker := time.NewTicker(wsPoolWatchInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if srv.gameHub.stopWatcher(topic) {
+			return
+		}
+		newSize, err := srv.puzzleRepository.GetPuzzlePoolSize(ctx, typ, level)
+		if err != nil {
+			log.Error().Err(err).Str("topic", string(topic)).Msg("failed to get size of puzzle pool")
+			continue
+		}
+		if newSize > size {
+			// every instance watches the pools of its own connections
+			srv.gameHub.publishLocal(topic, "poolRefill", wsPoolRefillEvent{
+				PuzzleType:  typ,
+				PuzzleLevel: level,
+				Size:        newSize,
+			})
+		}
+		size = newSize
+	}
+}
+
+// notify pushes the notification to all connections of the user (or the anonymous session).
+func (srv *service) notify(session *app.Session, typ app.NotificationType, message string) {
+	srv.gameHub.publish(wsTopicNotifications(session), nil, "notification", app.CookieNotification{
+		Type:    typ,
+		Message: message,
+	})
+}
@@ -0,0 +1,49 @@
+package frontend
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"testing"
+)
+
+func TestWsSubscribeRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     wsSubscribeRequest
+		wantSts app.Status
+	}{
+		{
+			name: "notifications",
+			req:  wsSubscribeRequest{Topic: wsSubscribeNotifications},
+		},
+		{
+			name: "pool",
+			req:  wsSubscribeRequest{Topic: wsSubscribePool, PuzzleType: app.PuzzleSudokuClassic, PuzzleLevel: app.PuzzleLevelHarder},
+		},
+		{
+			name:    "pool without level",
+			req:     wsSubscribeRequest{Topic: wsSubscribePool, PuzzleType: app.PuzzleSudokuClassic},
+			wantSts: app.StatusBadRequest,
+		},
+		{
+			name:    "pool of unsupported type",
+			req:     wsSubscribeRequest{Topic: wsSubscribePool, PuzzleType: "chess", PuzzleLevel: app.PuzzleLevelEasy},
+			wantSts: app.StatusBadRequest,
+		},
+		{
+			name:    "pool of custom level",
+			req:     wsSubscribeRequest{Topic: wsSubscribePool, PuzzleType: app.PuzzleSudokuClassic, PuzzleLevel: app.PuzzleLevelCustom},
+			wantSts: app.StatusBadRequest,
+		},
+		{
+			name:    "unknown topic",
+			req:     wsSubscribeRequest{Topic: "games"},
+			wantSts: app.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkStatus(t, "wsSubscribeRequest.Validate", tt.req.Validate(context.Background()), tt.wantSts)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestWsGameMiddleware(t *testing.T) {
@@ -102,9 +103,36 @@ type mockPuzzleRepository struct {
 	getPuzzleGame          func(ctx context.Context, id uuid.UUID) (*app.PuzzleGame, error)
 	updatePuzzleGame       func(ctx context.Context, game *app.PuzzleGame) error
 	createPuzzle           func(ctx context.Context, params app.CreatePuzzleParams) (*app.Puzzle, error)
+	createPuzzles          func(ctx context.Context, params []app.CreatePuzzleParams) ([]*app.Puzzle, error)
 	getPuzzle              func(ctx context.Context, id int64) (*app.Puzzle, error)
 	getPuzzleByGameID      func(ctx context.Context, gameID uuid.UUID) (*app.Puzzle, error)
 	getPuzzleAndGame       func(ctx context.Context, id uuid.UUID) (*app.Puzzle, *app.PuzzleGame, error)
+	getAmountUnsolved      func(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel) (int, error)
+	addPuzzleGameMove      func(ctx context.Context, gameID uuid.UUID, move app.PuzzleGameMove) error
+	getPuzzleGameMoves     func(ctx context.Context, gameID uuid.UUID) ([]app.PuzzleGameMove, error)
+	getUserPuzzleGames     func(ctx context.Context, userID int64) ([]*app.PuzzleGame, error)
+	getDailyPuzzle         func(ctx context.Context, date string, typ app.PuzzleType, level app.PuzzleLevel) (*app.Puzzle, error)
+	createDailyPuzzleGame  func(ctx context.Context, params app.CreateDailyPuzzleGameParams) (*app.Puzzle, *app.PuzzleGame, error)
+	getDailyLeaderboard    func(ctx context.Context, date string, typ app.PuzzleType, level app.PuzzleLevel, limit int) ([]app.DailyLeaderboardEntry, error)
+	getUserDailyStreak     func(ctx context.Context, userID int64) (*app.DailyStreak, error)
+	getLeaderboard         func(ctx context.Context, board app.Leaderboard, limit int) ([]app.LeaderboardEntry, error)
+	addPuzzleGameMember    func(ctx context.Context, gameID uuid.UUID, session *app.Session) error
+	isPuzzleGameMember     func(ctx context.Context, gameID uuid.UUID, session *app.Session) (bool, error)
+	createRace             func(ctx context.Context, params app.CreateRaceParams) (*app.Race, error)
+	getRace                func(ctx context.Context, id uuid.UUID) (*app.Race, error)
+	joinRace               func(ctx context.Context, id uuid.UUID, session *app.Session, name string) (*app.PuzzleGame, error)
+	startRace              func(ctx context.Context, id uuid.UUID, at time.Time) (*app.Race, error)
+	updateRacePlayer       func(ctx context.Context, id uuid.UUID, player app.RacePlayer) error
+	getRacePlayers         func(ctx context.Context, id uuid.UUID) ([]app.RacePlayer, error)
+	getPuzzlePoolSize      func(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel) (int, error)
+	getPracticePoolSize    func(ctx context.Context, typ app.PuzzleType, target app.StrategyTarget) (int, error)
+	getUnsolvedPuzzleCount func(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel, userID int64) (int, error)
+	createCustomPuzzleGame func(ctx context.Context, params app.CreateCustomPuzzleGameParams) (*app.Puzzle, *app.PuzzleGame, error)
+	getRandomPuzzle        func(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel, practice *app.StrategyTarget) (*app.Puzzle, error)
+	createDrill            func(ctx context.Context, params app.CreateDrillParams) (*app.Drill, error)
+	getDrill               func(ctx context.Context, id uuid.UUID) (*app.Drill, error)
+	answerDrill            func(ctx context.Context, drill *app.Drill, correct bool) error
+	getUserDrillStats      func(ctx context.Context, userID int64) ([]app.DrillStats, error)
 }
 
 func (m mockPuzzleRepository) CreateRandomPuzzleGame(ctx context.Context, params app.CreateRandomPuzzleGameParams) (*app.Puzzle, *app.PuzzleGame, error) {
@@ -135,6 +163,21 @@ func (m mockPuzzleRepository) CreatePuzzle(ctx context.Context, params app.Creat
 	panic("not implemented")
 }
 
+func (m mockPuzzleRepository) CreatePuzzles(ctx context.Context, params []app.CreatePuzzleParams) ([]*app.Puzzle, error) {
+	if m.createPuzzles != nil {
+		return m.createPuzzles(ctx, params)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetLastPuzzleID(ctx context.Context) (int64, error) {
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) SetPuzzleCanonical(ctx context.Context, puzzle *app.Puzzle) error {
+	panic("not implemented")
+}
+
 func (m mockPuzzleRepository) GetPuzzle(ctx context.Context, id int64) (*app.Puzzle, error) {
 	if m.getPuzzle != nil {
 		return m.getPuzzle(ctx, id)
@@ -156,6 +199,153 @@ func (m mockPuzzleRepository) GetPuzzleAndGame(ctx context.Context, id uuid.UUID
 	panic("not implemented")
 }
 
+func (m mockPuzzleRepository) GetAmountUnsolvedPuzzlesForAllUsers(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel) (int, error) {
+	if m.getAmountUnsolved != nil {
+		return m.getAmountUnsolved(ctx, typ, level)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) AddPuzzleGameMove(ctx context.Context, gameID uuid.UUID, move app.PuzzleGameMove) error {
+	if m.addPuzzleGameMove != nil {
+		return m.addPuzzleGameMove(ctx, gameID, move)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetPuzzleGameMoves(ctx context.Context, gameID uuid.UUID) ([]app.PuzzleGameMove, error) {
+	if m.getPuzzleGameMoves != nil {
+		return m.getPuzzleGameMoves(ctx, gameID)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetUserPuzzleGames(ctx context.Context, userID int64) ([]*app.PuzzleGame, error) {
+	if m.getUserPuzzleGames != nil {
+		return m.getUserPuzzleGames(ctx, userID)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetDailyPuzzle(ctx context.Context, date string, typ app.PuzzleType, level app.PuzzleLevel) (*app.Puzzle, error) {
+	if m.getDailyPuzzle != nil {
+		return m.getDailyPuzzle(ctx, date, typ, level)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) CreateDailyPuzzleGame(ctx context.Context, params app.CreateDailyPuzzleGameParams) (*app.Puzzle, *app.PuzzleGame, error) {
+	if m.createDailyPuzzleGame != nil {
+		return m.createDailyPuzzleGame(ctx, params)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetDailyLeaderboard(ctx context.Context, date string, typ app.PuzzleType, level app.PuzzleLevel, limit int) ([]app.DailyLeaderboardEntry, error) {
+	if m.getDailyLeaderboard != nil {
+		return m.getDailyLeaderboard(ctx, date, typ, level, limit)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetUserDailyStreak(ctx context.Context, userID int64) (*app.DailyStreak, error) {
+	if m.getUserDailyStreak != nil {
+		return m.getUserDailyStreak(ctx, userID)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetLeaderboard(ctx context.Context, board app.Leaderboard, limit int) ([]app.LeaderboardEntry, error) {
+	if m.getLeaderboard != nil {
+		return m.getLeaderboard(ctx, board, limit)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) AddPuzzleGameMember(ctx context.Context, gameID uuid.UUID, session *app.Session) error {
+	if m.addPuzzleGameMember != nil {
+		return m.addPuzzleGameMember(ctx, gameID, session)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) IsPuzzleGameMember(ctx context.Context, gameID uuid.UUID, session *app.Session) (bool, error) {
+	if m.isPuzzleGameMember != nil {
+		return m.isPuzzleGameMember(ctx, gameID, session)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) CreateRace(ctx context.Context, params app.CreateRaceParams) (*app.Race, error) {
+	if m.createRace != nil {
+		return m.createRace(ctx, params)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetRace(ctx context.Context, id uuid.UUID) (*app.Race, error) {
+	if m.getRace != nil {
+		return m.getRace(ctx, id)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) JoinRace(ctx context.Context, id uuid.UUID, session *app.Session, name string) (*app.PuzzleGame, error) {
+	if m.joinRace != nil {
+		return m.joinRace(ctx, id, session, name)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) StartRace(ctx context.Context, id uuid.UUID, at time.Time) (*app.Race, error) {
+	if m.startRace != nil {
+		return m.startRace(ctx, id, at)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) UpdateRacePlayer(ctx context.Context, id uuid.UUID, player app.RacePlayer) error {
+	if m.updateRacePlayer != nil {
+		return m.updateRacePlayer(ctx, id, player)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetRacePlayers(ctx context.Context, id uuid.UUID) ([]app.RacePlayer, error) {
+	if m.getRacePlayers != nil {
+		return m.getRacePlayers(ctx, id)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetPuzzlePoolSize(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel) (int, error) {
+	if m.getPuzzlePoolSize != nil {
+		return m.getPuzzlePoolSize(ctx, typ, level)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetUnsolvedPuzzleCount(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel, userID int64) (int, error) {
+	if m.getUnsolvedPuzzleCount != nil {
+		return m.getUnsolvedPuzzleCount(ctx, typ, level, userID)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetPracticePoolSize(ctx context.Context, typ app.PuzzleType, target app.StrategyTarget) (int, error) {
+	if m.getPracticePoolSize != nil {
+		return m.getPracticePoolSize(ctx, typ, target)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) CreateCustomPuzzleGame(ctx context.Context, params app.CreateCustomPuzzleGameParams) (*app.Puzzle, *app.PuzzleGame, error) {
+	if m.createCustomPuzzleGame != nil {
+		return m.createCustomPuzzleGame(ctx, params)
+	}
+	panic("not implemented")
+}
+
 type mockPuzzleLibrary struct {
 	getCreator   func(typ app.PuzzleType) (app.PuzzleCreator, error)
 	getGenerator func(typ app.PuzzleType, puzzle string) (app.PuzzleGenerator, error)
@@ -188,8 +378,10 @@ type mockPuzzleAssistant struct {
 	typeFunc           func() app.PuzzleType
 	getWrongPoints     func() []app.Point
 	getWrongCandidates func(candidates string) (string, error)
+	getMistakes        func(solution string, candidates string) (wrongPoints []app.Point, removedCandidates string, err error)
 	makeUserStep       func(candidatesIn string, step app.PuzzleUserStep) (candidatesOut string, wrongCandidates string, err error)
-	solveOneStep       func(candidatesIn string, strategies app.PuzzleStrategy) (candidatesChanges string, step app.PuzzleStep, err error)
+	solveOneStep       func(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error)
+	countSteps         func(candidatesIn string, strategy app.PuzzleStrategy) (int, error)
 }
 
 func (m mockPuzzleAssistant) String() string {
@@ -219,23 +411,44 @@ func (m mockPuzzleAssistant) GetWrongCandidates(candidates string) (string, erro
 	}
 	panic("not implemented")
 }
+func (m mockPuzzleAssistant) GetMistakes(solution string, candidates string) (wrongPoints []app.Point, removedCandidates string, err error) {
+	if m.getMistakes != nil {
+		return m.getMistakes(solution, candidates)
+	}
+	panic("not implemented")
+}
+
 func (m mockPuzzleAssistant) MakeUserStep(candidatesIn string, step app.PuzzleUserStep) (candidatesOut string, wrongCandidates string, err error) {
 	if m.makeUserStep != nil {
 		return m.makeUserStep(candidatesIn, step)
 	}
 	panic("not implemented")
 }
-func (m mockPuzzleAssistant) SolveOneStep(candidatesIn string, strategies app.PuzzleStrategy) (candidatesChanges string, step app.PuzzleStep, err error) {
+func (m mockPuzzleAssistant) SolveOneStep(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
 	if m.solveOneStep != nil {
 		return m.solveOneStep(candidatesIn, strategies)
 	}
 	panic("not implemented")
 }
 
+func (m mockPuzzleAssistant) CountSteps(candidatesIn string, strategy app.PuzzleStrategy) (int, error) {
+	if m.countSteps != nil {
+		return m.countSteps(candidatesIn, strategy)
+	}
+	panic("not implemented")
+}
+
+func mockGetMistakesNone() func(solution string, candidates string) ([]app.Point, string, error) {
+	return func(solution string, candidates string) ([]app.Point, string, error) {
+		return nil, `{}`, nil
+	}
+}
+
 type mockPuzzleStep struct {
 	strategy         func() app.PuzzleStrategy
 	candidateChanges func() string
 	description      func() string
+	payload          func() app.PuzzleStepPayload
 }
 
 func (m mockPuzzleStep) Strategy() app.PuzzleStrategy {
@@ -257,3 +470,45 @@ func (m mockPuzzleStep) Description() string {
 	}
 	panic("not implemented")
 }
+
+func (m mockPuzzleStep) Payload() app.PuzzleStepPayload {
+	if m.payload != nil {
+		return m.payload()
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetRandomPuzzle(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel, practice *app.StrategyTarget) (*app.Puzzle, error) {
+	if m.getRandomPuzzle != nil {
+		return m.getRandomPuzzle(ctx, typ, level, practice)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) CreateDrill(ctx context.Context, params app.CreateDrillParams) (*app.Drill, error) {
+	if m.createDrill != nil {
+		return m.createDrill(ctx, params)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetDrill(ctx context.Context, id uuid.UUID) (*app.Drill, error) {
+	if m.getDrill != nil {
+		return m.getDrill(ctx, id)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) AnswerDrill(ctx context.Context, drill *app.Drill, correct bool) error {
+	if m.answerDrill != nil {
+		return m.answerDrill(ctx, drill, correct)
+	}
+	panic("not implemented")
+}
+
+func (m mockPuzzleRepository) GetUserDrillStats(ctx context.Context, userID int64) ([]app.DrillStats, error) {
+	if m.getUserDrillStats != nil {
+		return m.getUserDrillStats(ctx, userID)
+	}
+	panic("not implemented")
+}
@@ -0,0 +1,152 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
+	"time"
+)
+
+func init() {
+	wsAddIncoming("undo", (*wsUndoRequest)(nil))
+}
+
+type wsUndoRequest struct {
+	wsGameMiddleware
+}
+
+func (r *wsUndoRequest) Validate(ctx context.Context) app.Status {
+	if r.game.IsWin {
+		return app.StatusBadRequest.WithMessage("game is over")
+	}
+	return r.validateGameNotPaused()
+}
+
+func (r *wsUndoRequest) Execute(ctx context.Context) (wsIncomingReply, app.Status) {
+	return r.moveAndRebuild(ctx, app.MoveUndo)
+}
+
+// moveAndRebuild appends the undo or redo move to the log of the game and
+// rebuilds the state of the game from the log.
+func (m *wsGameMiddleware) moveAndRebuild(ctx context.Context, typ app.MoveType) (wsIncomingReply, app.Status) {
+	srv := FromContextServiceFrontendOrNil(ctx)
+
+	moves, err := srv.puzzleRepository.GetPuzzleGameMoves(ctx, m.game.ID)
+	if err != nil {
+		return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	applied, undone := app.ReplayMoves(moves)
+	switch {
+	case typ == app.MoveUndo && len(applied) == 0:
+		return nil, app.StatusBadRequest.WithMessage("nothing to undo")
+	case typ == app.MoveRedo && len(undone) == 0:
+		return nil, app.StatusBadRequest.WithMessage("nothing to redo")
+	}
+	move := app.PuzzleGameMove{Type: typ, CreatedAt: app.DateTime{Time: time.Now()}}
+	if err := srv.puzzleRepository.AddPuzzleGameMove(ctx, m.game.ID, move); err != nil {
+		return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+
+	rpl, status := m.rebuild(ctx, nil)
+	if status != nil {
+		return nil, status
+	}
+	srv.gameHub.publish(wsTopicGame(m.game.ID), FromContextWsConnectionOrNil(ctx), "gameUpdate", rpl)
+
+	return rpl, nil
+}
+
+// wsMaxConflictAttempts is the number of attempts to save the game changed concurrently by other players.
+const wsMaxConflictAttempts = 5
+
+// rebuild replays the log of the game on the clues and saves the state.
+//
+// The log of moves defines the order of moves of all players of the game. If another player changes the game at the
+// same time, the game is read again and rebuilt from the log, so the later move in the log wins the cell. mutate is
+// applied to the game before every attempt to save it and can be nil.
+func (m *wsGameMiddleware) rebuild(ctx context.Context, mutate func(game *app.PuzzleGame)) (*wsUndoReply, app.Status) {
+	srv := FromContextServiceFrontendOrNil(ctx)
+
+	for attempt := 1; ; attempt++ {
+		rpl, status := m.replay(ctx)
+		if status != nil {
+			return nil, status
+		}
+		if mutate != nil {
+			mutate(m.game)
+		}
+		err := srv.puzzleRepository.UpdatePuzzleGame(ctx, m.game)
+		switch {
+		case err == nil:
+			rpl.Timer = newWsTimerReply(m.game)
+			return rpl, nil
+		case errors.Is(err, app.ErrorPuzzleGameConflict) && attempt < wsMaxConflictAttempts:
+		default:
+			return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+		}
+		m.game, err = srv.puzzleRepository.GetPuzzleGame(ctx, m.game.ID)
+		if err != nil {
+			return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+		}
+	}
+}
+
+// replay sets the state of the game to the replay of its log.
+func (m *wsGameMiddleware) replay(ctx context.Context) (*wsUndoReply, app.Status) {
+	srv := FromContextServiceFrontendOrNil(ctx)
+
+	moves, err := srv.puzzleRepository.GetPuzzleGameMoves(ctx, m.game.ID)
+	if err != nil {
+		return nil, app.StatusInternalServerError.WithError(errors.WithStack(err))
+	}
+	applied, undone := app.ReplayMoves(moves)
+
+	statePuzzle, err := srv.puzzleLibrary.GetAssistant(m.puzzle.Type, m.puzzle.Clues)
+	if err != nil {
+		return nil, app.StatusBadRequest.WithError(errors.WithStack(err))
+	}
+	candidates, wrongCandidates := m.game.StartCandidates, emptyCandidates
+	if candidates == "" {
+		candidates = emptyCandidates
+	}
+	for _, step := range applied {
+		candidates, wrongCandidates, err = statePuzzle.MakeUserStep(candidates, step)
+		if err != nil {
+			return nil, app.StatusInternalServerError.WithMessage("failed to replay moves").WithError(errors.WithStack(err))
+		}
+	}
+	m.game.IsNew = false
+	m.game.State, m.game.StateCandidates = statePuzzle.String(), candidates
+	now := time.Now()
+	m.game.TimerTick(now, app.DefaultGameIdleTimeout)
+	if !m.game.IsWin && m.game.State == m.puzzle.Solution {
+		m.game.TimerPause(now, app.DefaultGameIdleTimeout)
+		m.game.IsWin = true
+	}
+
+	return &wsUndoReply{
+		Puzzle:           m.puzzle.Clues,
+		StatePuzzle:      m.game.State,
+		StateCandidates:  json.RawMessage(m.game.StateCandidates),
+		Wrongs:           statePuzzle.GetWrongPoints(),
+		WrongsCandidates: json.RawMessage(wrongCandidates),
+		IsWin:            m.game.IsWin,
+		CanUndo:          len(applied) > 0,
+		CanRedo:          len(undone) > 0,
+	}, nil
+}
+
+// wsUndoReply is the rebuilt state of the game. It is also the reply of redo
+// and the gameUpdate message sent to other players of the shared game.
+type wsUndoReply struct {
+	Puzzle           string          `json:"puzzle"`
+	StatePuzzle      string          `json:"state_puzzle"`
+	StateCandidates  json.RawMessage `json:"state_candidates"`
+	Wrongs           []app.Point     `json:"wrongs,omitempty"`
+	WrongsCandidates json.RawMessage `json:"wrongsCandidates,omitempty"`
+	IsWin            bool            `json:"is_win,omitempty"`
+	CanUndo          bool            `json:"canUndo"`
+	CanRedo          bool            `json:"canRedo"`
+	Timer            wsTimerReply    `json:"timer"`
+}
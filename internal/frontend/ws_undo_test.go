@@ -0,0 +1,166 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/google/uuid"
+	"reflect"
+	"testing"
+)
+
+func TestWsUndoRedo(t *testing.T) {
+	step := func(digit int8) *app.PuzzleUserStep {
+		return &app.PuzzleUserStep{Type: app.UserStepSetDigit, Point: app.Point{Row: 0, Col: 0}, Digit: digit}
+	}
+	tests := []struct {
+		name  string
+		undo  bool
+		moves []app.PuzzleGameMove
+		// conflicts is the number of failed updates because of concurrentMove.
+		conflicts      int
+		concurrentMove *app.PuzzleGameMove
+		wantSteps      []int8
+		wantRpl        wsIncomingReply
+		wantSts        app.Status
+	}{
+		{
+			name:    "nothing to undo",
+			undo:    true,
+			wantSts: app.StatusBadRequest,
+		},
+		{
+			name: "nothing to redo",
+			moves: []app.PuzzleGameMove{
+				{Type: app.MoveStep, Step: step(1)},
+			},
+			wantSts: app.StatusBadRequest,
+		},
+		{
+			name: "undo",
+			undo: true,
+			moves: []app.PuzzleGameMove{
+				{Type: app.MoveStep, Step: step(1)},
+				{Type: app.MoveStep, Step: step(2)},
+			},
+			wantSteps: []int8{1},
+			wantRpl: &wsUndoReply{
+				Puzzle:           "clues",
+				StatePuzzle:      "state 1",
+				StateCandidates:  json.RawMessage(`{}`),
+				WrongsCandidates: json.RawMessage(`{}`),
+				CanUndo:          true,
+				CanRedo:          true,
+				Timer:            wsTimerReply{Running: true},
+			},
+		},
+		{
+			name: "redo",
+			moves: []app.PuzzleGameMove{
+				{Type: app.MoveStep, Step: step(1)},
+				{Type: app.MoveUndo},
+			},
+			wantSteps: []int8{1},
+			wantRpl: &wsUndoReply{
+				Puzzle:           "clues",
+				StatePuzzle:      "state 1",
+				StateCandidates:  json.RawMessage(`{}`),
+				WrongsCandidates: json.RawMessage(`{}`),
+				CanUndo:          true,
+				Timer:            wsTimerReply{Running: true},
+			},
+		},
+		{
+			name: "undo after concurrent change",
+			undo: true,
+			moves: []app.PuzzleGameMove{
+				{Type: app.MoveStep, Step: step(1)},
+				{Type: app.MoveStep, Step: step(2)},
+			},
+			conflicts: 1,
+			// the game is rebuilt again with the move of another player
+			concurrentMove: &app.PuzzleGameMove{Type: app.MoveStep, Step: step(3)},
+			wantSteps:      []int8{1, 1, 3},
+			wantRpl: &wsUndoReply{
+				Puzzle:           "clues",
+				StatePuzzle:      "state 3",
+				StateCandidates:  json.RawMessage(`{}`),
+				WrongsCandidates: json.RawMessage(`{}`),
+				CanUndo:          true,
+				Timer:            wsTimerReply{Running: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moves := append([]app.PuzzleGameMove{}, tt.moves...)
+			var gotSteps []int8
+			conflicts := tt.conflicts
+			ctx := mockService(mockPuzzleRepository{
+				getPuzzleAndGame: func(ctx context.Context, id uuid.UUID) (*app.Puzzle, *app.PuzzleGame, error) {
+					return &app.Puzzle{ID: 1, Clues: "clues"}, &app.PuzzleGame{ID: id}, nil
+				},
+				getPuzzleGameMoves: func(ctx context.Context, gameID uuid.UUID) ([]app.PuzzleGameMove, error) {
+					return moves, nil
+				},
+				addPuzzleGameMove: func(ctx context.Context, gameID uuid.UUID, move app.PuzzleGameMove) error {
+					moves = append(moves, move)
+					return nil
+				},
+				updatePuzzleGame: func(ctx context.Context, game *app.PuzzleGame) error {
+					if conflicts > 0 {
+						conflicts--
+						moves = append(moves, *tt.concurrentMove)
+						return app.ErrorPuzzleGameConflict
+					}
+					return nil
+				},
+				getPuzzleGame: func(ctx context.Context, id uuid.UUID) (*app.PuzzleGame, error) {
+					return &app.PuzzleGame{ID: id}, nil
+				},
+			}, mockPuzzleLibrary{
+				getAssistant: func(typ app.PuzzleType, puzzle string) (app.PuzzleAssistant, error) {
+					return mockPuzzleAssistant{
+						makeUserStep: func(candidatesIn string, step app.PuzzleUserStep) (string, string, error) {
+							gotSteps = append(gotSteps, step.Digit)
+							return candidatesIn, `{}`, nil
+						},
+						string: func() string {
+							if len(gotSteps) == 0 {
+								return "clues"
+							}
+							return "state " + string('0'+byte(gotSteps[len(gotSteps)-1]))
+						},
+						getWrongPoints: func() []app.Point {
+							return nil
+						},
+					}, nil
+				},
+			})
+			var req interface {
+				wsIncomingRequest
+				wsGameMiddlewareInterface
+			} = &wsRedoRequest{wsGameMiddleware: mockWsGameMiddleware()}
+			if tt.undo {
+				req = &wsUndoRequest{wsGameMiddleware: mockWsGameMiddleware()}
+			}
+			if !checkStatus(t, "wsUndoRedo.GameMiddleware", req.GameMiddleware(ctx), nil) {
+				return
+			}
+			if !checkStatus(t, "wsUndoRedo.Validate", req.Validate(ctx), nil) {
+				return
+			}
+			rpl, status := req.Execute(ctx)
+			if !checkStatus(t, "wsUndoRedo.Execute", status, tt.wantSts) {
+				return
+			}
+			if !reflect.DeepEqual(rpl, tt.wantRpl) {
+				t.Errorf("wsUndoRedo.Execute() got = %+v, want = %+v", rpl, tt.wantRpl)
+				return
+			}
+			if !reflect.DeepEqual(gotSteps, tt.wantSteps) {
+				t.Errorf("wsUndoRedo.Execute() replayed steps = %v, want = %v", gotSteps, tt.wantSteps)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+func (srv *service) BackfillCanonical(ctx context.Context) (*app.PuzzleBackfillReport, error) {
+	lastID, err := srv.puzzleRepository.GetLastPuzzleID(ctx)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	report := &app.PuzzleBackfillReport{}
+	for id := int64(1); id <= lastID; id++ {
+		if err := ctx.Err(); err != nil {
+			return report, errors.WithStack(err)
+		}
+		puzzle, err := srv.puzzleRepository.GetPuzzle(ctx, id)
+		switch {
+		case err == nil:
+		case errors.Is(err, app.ErrorPuzzleNotFound):
+			// the identifiers of the duplicates rejected by CreatePuzzles are not used
+			continue
+		default:
+			return report, errors.WithStack(err)
+		}
+		report.Read++
+		if puzzle.Canonical != "" || puzzle.Level == app.PuzzleLevelCustom {
+			continue
+		}
+
+		generator, err := srv.puzzleLibrary.GetGenerator(puzzle.Type, puzzle.Clues)
+		if err != nil {
+			log.Warn().Err(err).Int64("puzzle_id", id).Msg("failed to parse stored puzzle")
+			continue
+		}
+		puzzle.Canonical = generator.Canonical()
+		switch err := srv.puzzleRepository.SetPuzzleCanonical(ctx, puzzle); {
+		case err == nil:
+			report.Indexed++
+		case errors.Is(err, app.ErrorPuzzleDuplicate):
+			report.Duplicates++
+		default:
+			return report, errors.WithStack(err)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,131 @@
+package generator
+
+import (
+	"bufio"
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// loadBatchSize is the number of puzzles created by one call of PuzzleRepository.CreatePuzzles.
+const loadBatchSize = 100
+
+type loadedPuzzle struct {
+	line   string
+	puzzle *app.CustomPuzzle
+	err    error
+}
+
+func (srv *service) Load(ctx context.Context, typ app.PuzzleType, file io.Reader) (*app.PuzzleLoadReport, error) {
+	if _, err := srv.puzzleLibrary.GetCreator(typ); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the lines are read by one goroutine and checked by a goroutine on each CPU, the solver is the slowest part
+	lines := make(chan string)
+	var errRead error
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		errRead = scanner.Err()
+	}()
+	loaded := make(chan loadedPuzzle)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for line := range lines {
+				checkCtx, cancel := context.WithTimeout(ctx, app.DefaultCustomPuzzleCheckTimeout)
+				puzzle, err := app.CheckCustomPuzzle(checkCtx, srv.puzzleLibrary, typ, line)
+				cancel()
+				select {
+				case loaded <- loadedPuzzle{line: line, puzzle: puzzle, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(loaded)
+	}()
+
+	report := &app.PuzzleLoadReport{Created: make(map[app.PuzzleLevel]int)}
+	seen := make(map[string]bool)
+	var batch []app.CreatePuzzleParams
+	createBatch := func() error {
+		created, err := srv.puzzleRepository.CreatePuzzles(ctx, batch)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, puzzle := range created {
+			report.Created[puzzle.Level]++
+		}
+		report.Duplicates += len(batch) - len(created)
+		batch = batch[:0]
+		log.Info().Int("read", report.Read).Int("created", len(created)).Msg("batch of puzzles loaded")
+		return nil
+	}
+	for result := range loaded {
+		report.Read++
+		switch {
+		case result.err != nil:
+			log.Debug().Err(result.err).Str("line", result.line).Msg("invalid puzzle skipped")
+			report.Invalid++
+		case result.puzzle.Rating == app.PuzzleLevelUnknown:
+			report.Unrated++
+		case seen[result.puzzle.Canonical]:
+			report.Duplicates++
+		default:
+			seen[result.puzzle.Canonical] = true
+			batch = append(batch, app.CreatePuzzleParams{
+				Type: typ,
+				GeneratedPuzzle: app.GeneratedPuzzle{
+					Level:      result.puzzle.Rating,
+					Meta:       app.PuzzleMeta{Symmetry: result.puzzle.Symmetry}.String(),
+					Clues:      result.puzzle.Clues,
+					Candidates: result.puzzle.Candidates,
+					Solution:   result.puzzle.Solution,
+					Canonical:  result.puzzle.Canonical,
+				},
+			})
+			if len(batch) == loadBatchSize {
+				if err := createBatch(); err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return report, errors.WithStack(err)
+	}
+	if errRead != nil {
+		return report, errors.Wrap(errRead, "failed to read puzzles")
+	}
+	if len(batch) > 0 {
+		if err := createBatch(); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return report, nil
+}
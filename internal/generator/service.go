@@ -7,18 +7,30 @@ import (
 	"github.com/cnblvr/puzzles/puzzle_library"
 	"github.com/cnblvr/puzzles/repository"
 	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"math/rand"
-	"sort"
+	"os"
+	"sync"
 	"time"
 )
 
 type service struct {
-	config           app.Config
-	puzzleRepository app.PuzzleRepository
-	puzzleLibrary    app.PuzzleLibrary
-	rnd              *rand.Rand
+	config                app.Config
+	puzzleRepository      app.PuzzleRepository
+	puzzleLibrary         app.PuzzleLibrary
+	generateJobRepository app.GenerateJobRepository
+	practiceTargets       []app.StrategyTarget
+	workers               int
+	attemptTimeout        time.Duration
+	consumer              string
+
+	// workerSlots limits the attempts in progress of all jobs by the number of workers.
+	workerSlots chan struct{}
+
+	muRnd sync.Mutex
+	rnd   *rand.Rand
 }
 
 func NewService() (app.ServiceGenerator, error) {
@@ -43,9 +55,45 @@ func NewService() (app.ServiceGenerator, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create puzzle generator repository")
 	}
+	srv.generateJobRepository, err = repository.NewRedisGenerateJobRepository(func() (redis.Conn, error) {
+		address, password, db, err := srv.config.RedisPuzzleConn()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return redis.Dial(
+			"tcp", address,
+			redis.DialPassword(password),
+			redis.DialDatabase(db),
+		)
+	}, srv.config.Debug())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create generate job repository")
+	}
 
 	srv.puzzleLibrary = &puzzle_library.PuzzleLibrary{}
 
+	srv.practiceTargets, err = srv.config.PracticeTargets()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get practice targets")
+	}
+	srv.workers, err = srv.config.GeneratorWorkers()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get generator workers")
+	}
+	srv.workerSlots = make(chan struct{}, srv.workers)
+	srv.attemptTimeout, err = srv.config.GeneratorAttemptTimeout()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get generator attempt timeout")
+	}
+
+	// the consumer name in the queue of the generate jobs is unique for every process, the jobs of a stopped process
+	// are claimed by the others
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get hostname")
+	}
+	srv.consumer = fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8])
+
 	return srv, nil
 }
 
@@ -60,57 +108,286 @@ func NewService() (app.ServiceGenerator, error) {
 //  ) ) >= needPuzzlesUnsolved => it's cool
 const needPuzzlesUnsolved = 5
 
-func (srv *service) Run() error {
-	for {
-		type needPuzzle struct {
-			typ   app.PuzzleType
-			level app.PuzzleLevel
-			need  int
-		}
-		var needPuzzles []needPuzzle
-		for _, typ := range []app.PuzzleType{app.PuzzleSudokuClassic} {
-			for _, level := range []app.PuzzleLevel{
-				app.PuzzleLevelEasy, app.PuzzleLevelNormal, app.PuzzleLevelHard, app.PuzzleLevelHarder,
-			} {
-				currentNum, err := srv.puzzleRepository.GetAmountUnsolvedPuzzlesForAllUsers(context.TODO(), app.PuzzleSudokuClassic, level)
-				if err != nil {
-					log.Error().Err(err).Msg("PuzzleRepository.GetAmountUnsolvedPuzzlesForAllUsers() failed")
-					time.Sleep(time.Second)
-				}
-				if currentNum < needPuzzlesUnsolved {
-					needPuzzles = append(needPuzzles, needPuzzle{
-						typ:   typ,
-						level: level,
-						need:  needPuzzlesUnsolved - currentNum,
-					})
+// Amount of puzzles each type in the practice pool of each strategy target.
+const needPracticePuzzles = 5
+
+// A strategy target may be unreachable by the strategies of the solver, so the generator gives up the practice puzzle
+// after practiceAttempts seeds.
+const practiceAttempts = 1000
+
+// Period between the checks of the pools.
+const checkPoolsPeriod = time.Hour
+
+// Time of waiting for a new generate job before the context is checked again.
+const generateJobReadTimeout = 5 * time.Second
+
+// Number of the generate jobs in progress. The queue has one job per pool, so a long job of one pool doesn't hold back
+// the job of an empty pool. The attempts of all jobs share the workers.
+const concurrentJobs = 4
+
+// Run queues the refills of the pools every checkPoolsPeriod and does the generate jobs of the queue, including the
+// jobs queued by the frontends, until the context is done. Several generators share the jobs of the queue.
+func (srv *service) Run(ctx context.Context) error {
+	log.Info().Int("workers", srv.workers).Dur("attempt_timeout", srv.attemptTimeout).
+		Str("consumer", srv.consumer).Msg("generator started")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			srv.scheduleJobs(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(checkPoolsPeriod):
+			}
+		}
+	}()
+
+	jobs := make(chan struct{}, concurrentJobs)
+	for ctx.Err() == nil {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		job, err := srv.generateJobRepository.ReadGenerateJob(ctx, srv.consumer, generateJobReadTimeout)
+		switch {
+		case errors.Is(err, app.ErrorGenerateJobNone):
+			<-jobs
+			continue
+		case err == nil:
+		default:
+			<-jobs
+			log.Error().Err(err).Msg("GenerateJobRepository.ReadGenerateJob() failed")
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-jobs }()
+			srv.runJob(ctx, job)
+		}()
+	}
+
+	wg.Wait()
+	log.Info().Msg("generator stopped")
+	return nil
+}
+
+// scheduleJobs queues the jobs of the pools with less than needPuzzlesUnsolved unsolved puzzles and of the practice
+// pools with less than needPracticePuzzles puzzles. The pools with a queued job are skipped.
+func (srv *service) scheduleJobs(ctx context.Context) {
+	var jobs []app.GenerateJob
+	for _, typ := range []app.PuzzleType{app.PuzzleSudokuClassic} {
+		for _, level := range []app.PuzzleLevel{
+			app.PuzzleLevelEasy, app.PuzzleLevelNormal, app.PuzzleLevelHard, app.PuzzleLevelHarder,
+		} {
+			currentNum, err := srv.puzzleRepository.GetAmountUnsolvedPuzzlesForAllUsers(ctx, typ, level)
+			if err != nil {
+				log.Error().Err(err).Msg("PuzzleRepository.GetAmountUnsolvedPuzzlesForAllUsers() failed")
+				continue
+			}
+			if currentNum < needPuzzlesUnsolved {
+				jobs = append(jobs, app.GenerateJob{Type: typ, Level: level, Count: needPuzzlesUnsolved - currentNum})
+			}
+		}
+		for _, target := range srv.practiceTargets {
+			size, err := srv.puzzleRepository.GetPracticePoolSize(ctx, typ, target)
+			if err != nil {
+				log.Error().Err(err).Stringer("target", target).Msg("PuzzleRepository.GetPracticePoolSize() failed")
+				continue
+			}
+			if size < needPracticePuzzles {
+				jobs = append(jobs, app.GenerateJob{Type: typ, Practice: target.String(), Count: needPracticePuzzles - size})
+			}
+		}
+	}
+	for _, job := range jobs {
+		job.Reason = app.GenerateJobReasonSchedule
+		queued, err := srv.generateJobRepository.PushGenerateJob(ctx, job)
+		switch {
+		case errors.Is(err, app.ErrorGenerateJobQueued):
+			log.Debug().Str("pool", job.Pool()).Msg("generate job is already queued")
+		case err == nil:
+			log.Info().Str("job_id", queued.ID).Str("pool", job.Pool()).Int("count", job.Count).Msg("generate job queued")
+		default:
+			log.Error().Err(err).Str("pool", job.Pool()).Msg("GenerateJobRepository.PushGenerateJob() failed")
+		}
+	}
+}
+
+// runJob does the missing puzzles of the job and acknowledges it. The job is touched while it is in progress, so
+// other generators don't take it. A job that fails or is interrupted by the context is not acknowledged and is
+// retried by any generator after app.DefaultGenerateJobClaimIdle. A job read more than
+// app.DefaultGenerateJobMaxDeliveries times is dropped.
+func (srv *service) runJob(ctx context.Context, job *app.GenerateJob) {
+	log := log.With().Str("job_id", job.ID).Str("pool", job.Pool()).Int("deliveries", job.Deliveries).Logger()
+	if job.Deliveries > app.DefaultGenerateJobMaxDeliveries {
+		log.Warn().Msg("generate job is dropped after too many deliveries")
+		if err := srv.generateJobRepository.AckGenerateJob(ctx, job); err != nil {
+			log.Error().Err(err).Msg("GenerateJobRepository.AckGenerateJob() failed")
+		}
+		return
+	}
+	log.Info().Int("count", job.Count).Int("done", job.Done).Str("reason", job.Reason).Msg("generate job started")
+
+	touchCtx, stopTouch := context.WithCancel(ctx)
+	touched := make(chan struct{})
+	go func() {
+		defer close(touched)
+		ticker := time.NewTicker(app.DefaultGenerateJobClaimIdle / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-touchCtx.Done():
+				return
+			case <-ticker.C:
+				if err := srv.generateJobRepository.TouchGenerateJob(touchCtx, srv.consumer, job); err != nil {
+					log.Error().Err(err).Msg("GenerateJobRepository.TouchGenerateJob() failed")
 				}
 			}
 		}
-		sort.Slice(needPuzzles, func(i, j int) bool {
-			if needPuzzles[i].typ == needPuzzles[j].typ {
-				return app.PuzzleLevelLess(needPuzzles[i].level, needPuzzles[j].level)
+	}()
+	done, err := srv.doJob(ctx, job)
+	stopTouch()
+	<-touched
+
+	switch {
+	case ctx.Err() != nil:
+		log.Info().Int("done", done).Msg("generate job is interrupted and left for a retry")
+		return
+	case err != nil:
+		log.Error().Err(err).Int("done", done).Msg("generate job failed")
+		return
+	}
+	if err := srv.generateJobRepository.AckGenerateJob(ctx, job); err != nil {
+		log.Error().Err(err).Msg("GenerateJobRepository.AckGenerateJob() failed")
+		return
+	}
+	log.Info().Int("done", done).Msg("generate job done")
+}
+
+// doJob generates the puzzles of the job missed by its previous deliveries and returns the number of created puzzles.
+// The progress is stored after each puzzle, so a retry of the job doesn't generate them again.
+//
+// Errors: unknown.
+func (srv *service) doJob(ctx context.Context, job *app.GenerateJob) (int, error) {
+	need := job.Count - job.Done
+	if need <= 0 {
+		return 0, nil
+	}
+	progress := func(attempt func(ctx context.Context, seed int64) (bool, error)) func(ctx context.Context, seed int64) (bool, error) {
+		return func(ctx context.Context, seed int64) (bool, error) {
+			ok, err := attempt(ctx, seed)
+			if ok {
+				if err := srv.generateJobRepository.AddGenerateJobDone(ctx, job, 1); err != nil {
+					log.Error().Err(err).Str("job_id", job.ID).Msg("GenerateJobRepository.AddGenerateJobDone() failed")
+				}
 			}
-			return app.PuzzleTypeLess(needPuzzles[i].typ, needPuzzles[j].typ)
-		})
-		log.Debug().Msgf("%+v", needPuzzles)
-		for _, need := range needPuzzles {
-			for idx := 1; idx <= need.need; {
-				if gotLevel, err := srv.GeneratePuzzle(need.typ, srv.rnd.Int63(), need.level); err != nil {
-					log.Error().Err(err).Msg("GeneratePuzzle() failed")
-				} else if gotLevel != need.level {
-					log.Debug().Stringer("want_level", need.level).Stringer("got_level", gotLevel).
-						Str("progress", fmt.Sprintf("%d/%d", idx, need.need)).
-						Msg("regenerate want level")
+			return ok, err
+		}
+	}
+	if job.Practice != "" {
+		target, err := app.ParseStrategyTarget(job.Practice)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		done := srv.generate(ctx, need, practiceAttempts, progress(func(ctx context.Context, seed int64) (bool, error) {
+			return srv.GeneratePracticePuzzle(ctx, job.Type, seed, target)
+		}))
+		if done < need && ctx.Err() == nil {
+			log.Warn().Stringer("target", target).Int("attempts", practiceAttempts).
+				Msg("strategy target is not reached, the practice pool is not filled")
+		}
+		return done, nil
+	}
+	return srv.generate(ctx, need, 0, progress(func(ctx context.Context, seed int64) (bool, error) {
+		gotLevel, err := srv.GeneratePuzzle(ctx, job.Type, seed, job.Level)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		if gotLevel != job.Level {
+			log.Debug().Stringer("want_level", job.Level).Stringer("got_level", gotLevel).
+				Msg("regenerate want level")
+			return false, nil
+		}
+		return true, nil
+	})), nil
+}
+
+// generate runs the attempts with random seeds on the workers until need attempts succeed. The workers of concurrent
+// jobs take turns. Each attempt is abandoned after the attempt timeout. If giveUp is positive, the generation stops
+// after giveUp failed attempts in a row. When the context is done or the need is reached, the attempts in progress
+// are abandoned and generate returns after all workers stop. It returns the number of succeeded attempts.
+func (srv *service) generate(ctx context.Context, need, giveUp int, attempt func(ctx context.Context, seed int64) (bool, error)) int {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu             sync.Mutex
+		done, failures int
+		wg             sync.WaitGroup
+	)
+	for i := 0; i < srv.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				select {
+				case srv.workerSlots <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				attemptCtx, cancelAttempt := context.WithTimeout(ctx, srv.attemptTimeout)
+				seed := srv.seed()
+				ok, err := attempt(attemptCtx, seed)
+				cancelAttempt()
+				<-srv.workerSlots
+				switch {
+				case errors.Is(err, context.Canceled):
+					return
+				case errors.Is(err, context.DeadlineExceeded):
+					log.Warn().Int64("seed", seed).Dur("timeout", srv.attemptTimeout).Msg("generation attempt timed out")
+				case err != nil:
+					log.Error().Err(err).Int64("seed", seed).Msg("generation attempt failed")
+				}
+
+				mu.Lock()
+				if ok {
+					done, failures = done+1, 0
+					log.Debug().Str("progress", fmt.Sprintf("%d/%d", done, need)).Msg("generation attempt succeeded")
 				} else {
-					idx++
+					failures++
 				}
+				if done >= need || (giveUp > 0 && failures >= giveUp) {
+					cancel()
+				}
+				mu.Unlock()
 			}
-		}
-		time.Sleep(time.Hour)
+		}()
 	}
+	wg.Wait()
+	return done
 }
 
-func (srv *service) GeneratePuzzle(typ app.PuzzleType, seed int64, level app.PuzzleLevel) (app.PuzzleLevel, error) {
+// seed returns the random seed of the next attempt.
+func (srv *service) seed() int64 {
+	srv.muRnd.Lock()
+	defer srv.muRnd.Unlock()
+	return srv.rnd.Int63()
+}
+
+// GeneratePuzzle generates the puzzle by the seed with the strategies of the level and adds it to the pool if the
+// puzzle has the level. It returns the level of the generated puzzle.
+//
+// Errors: context.Canceled, context.DeadlineExceeded, unknown.
+func (srv *service) GeneratePuzzle(ctx context.Context, typ app.PuzzleType, seed int64, level app.PuzzleLevel) (app.PuzzleLevel, error) {
 	creator, err := srv.puzzleLibrary.GetCreator(typ)
 	if err != nil {
 		return app.PuzzleLevelUnknown, errors.WithStack(err)
@@ -119,24 +396,32 @@ func (srv *service) GeneratePuzzle(typ app.PuzzleType, seed int64, level app.Puz
 	puzzle := creator.NewSolutionBySeed(seed)
 	solution := puzzle.String()
 
-	strategies, err := puzzle.GenerateLogic(seed, level.Strategies())
+	// the symmetry is chosen by the seed, so the puzzle of the seed is the same
+	symmetry := app.PuzzleSymmetries[uint64(seed)%uint64(len(app.PuzzleSymmetries))]
+	strategies, err := puzzle.GenerateLogic(ctx, seed, level.Strategies(), symmetry)
 	if err != nil {
 		return app.PuzzleLevelUnknown, errors.Wrap(err, "failed to generate logic")
 	}
 
 	gotLevel := strategies.Level()
 	if gotLevel == level {
-		sudoku, err := srv.puzzleRepository.CreatePuzzle(context.TODO(), app.CreatePuzzleParams{
+		sudoku, err := srv.puzzleRepository.CreatePuzzle(ctx, app.CreatePuzzleParams{
 			Type: creator.Type(),
 			GeneratedPuzzle: app.GeneratedPuzzle{
 				Seed:       seed,
 				Level:      gotLevel,
-				Meta:       "{}",
+				Meta:       app.PuzzleMeta{Symmetry: puzzle.Symmetry()}.String(),
 				Clues:      puzzle.String(),
 				Candidates: puzzle.GetCandidates(),
 				Solution:   solution,
+				Canonical:  puzzle.Canonical(),
 			},
 		})
+		if errors.Is(err, app.ErrorPuzzleDuplicate) {
+			log.Info().Stringer("puzzle_type", creator.Type()).Stringer("puzzle_level", gotLevel).
+				Msg("generated puzzle is a duplicate of a stored puzzle")
+			return app.PuzzleLevelUnknown, nil
+		}
 		if err != nil {
 			log.Error().Err(err).Msg("failed to create new puzzle in db")
 			return app.PuzzleLevelUnknown, err
@@ -149,3 +434,71 @@ func (srv *service) GeneratePuzzle(typ app.PuzzleType, seed int64, level app.Puz
 
 	return gotLevel, nil
 }
+
+// GeneratePracticePuzzle generates the puzzle by the seed with the strategies of the target and adds it to the
+// practice pool of the target and to the pool of its level. It returns false if the logical solution of the puzzle
+// does not reach the target or the puzzle is a duplicate.
+//
+// Errors: context.Canceled, context.DeadlineExceeded, unknown.
+func (srv *service) GeneratePracticePuzzle(ctx context.Context, typ app.PuzzleType, seed int64, target app.StrategyTarget) (bool, error) {
+	creator, err := srv.puzzleLibrary.GetCreator(typ)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	puzzle := creator.NewSolutionBySeed(seed)
+	solution := puzzle.String()
+
+	symmetry := app.PuzzleSymmetries[uint64(seed)%uint64(len(app.PuzzleSymmetries))]
+	strategies, err := puzzle.GenerateLogic(ctx, seed, target.Strategies(), symmetry)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to generate logic")
+	}
+	// the mask of the generation skips most of the puzzles without a required strategy before the steps are counted
+	for strategy := range target.Require {
+		if !strategies.Has(strategy) {
+			return false, nil
+		}
+	}
+
+	generator, err := srv.puzzleLibrary.GetGenerator(typ, puzzle.String())
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	count, status, err := app.CountStrategies(generator, target.Strategies())
+	if err != nil {
+		return false, errors.Wrap(err, "failed to count strategies")
+	}
+	if status != app.SolveStatusSolved || !target.Satisfied(count) {
+		return false, nil
+	}
+
+	level := count.Strategies().Level()
+	sudoku, err := srv.puzzleRepository.CreatePuzzle(ctx, app.CreatePuzzleParams{
+		Type: creator.Type(),
+		GeneratedPuzzle: app.GeneratedPuzzle{
+			Seed:       seed,
+			Level:      level,
+			Meta:       app.PuzzleMeta{Symmetry: puzzle.Symmetry(), Practice: target.String()}.String(),
+			Clues:      puzzle.String(),
+			Candidates: puzzle.GetCandidates(),
+			Solution:   solution,
+			Canonical:  puzzle.Canonical(),
+		},
+	})
+	if errors.Is(err, app.ErrorPuzzleDuplicate) {
+		log.Info().Stringer("puzzle_type", creator.Type()).Stringer("target", target).
+			Msg("generated practice puzzle is a duplicate of a stored puzzle")
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create new practice puzzle in db")
+	}
+	log.Info().Int64("id", sudoku.ID).
+		Stringer("puzzle_type", creator.Type()).
+		Stringer("puzzle_level", level).
+		Stringer("target", target).
+		Msg("new practice puzzle created and saved")
+
+	return true, nil
+}
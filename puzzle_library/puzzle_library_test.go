@@ -0,0 +1,110 @@
+package puzzle_library
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
+	"testing"
+)
+
+func TestFindDrills(t *testing.T) {
+	puzzle := &app.Puzzle{
+		ID:    1,
+		Type:  app.PuzzleSudokuClassic,
+		Clues: "72..96..3...2.5....8...4.2........6.1.65.38.7.4........3.8...9....7.2...2..43..18",
+	}
+	tests := []struct {
+		strategy  app.PuzzleStrategy
+		wantCount int
+		wantFirst string
+	}{
+		{strategy: app.StrategyNakedSingle, wantCount: 10, wantFirst: "set 9 in point i6"},
+		{strategy: app.StrategyHiddenSingle, wantCount: 2, wantFirst: "set 8 in point a8"},
+		{strategy: app.StrategyNakedPair, wantCount: 0},
+		{strategy: app.StrategyNakedTriple, wantCount: 1, wantFirst: "has candidates [4 5 7] in points [a3 g3 i3]"},
+		{strategy: app.StrategyHiddenPair, wantCount: 1, wantFirst: "has candidates [8 9] in points [h1 h3]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			drills, err := app.FindDrills(context.Background(), PuzzleLibrary{}, puzzle, tt.strategy)
+			if err != nil {
+				t.Fatalf("FindDrills() error = %v", err)
+			}
+			if len(drills) != tt.wantCount {
+				t.Fatalf("FindDrills() got %d drills, want = %d", len(drills), tt.wantCount)
+			}
+			if len(drills) > 0 && drills[0].Description != tt.wantFirst {
+				t.Errorf("FindDrills() got first drill %q, want = %q", drills[0].Description, tt.wantFirst)
+			}
+			for _, drill := range drills {
+				assistant, err := PuzzleLibrary{}.GetAssistant(drill.Type, drill.State)
+				if err != nil {
+					t.Fatal(err)
+				}
+				count, err := assistant.CountSteps(drill.Candidates, tt.strategy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if count != 1 {
+					t.Errorf("drill %s has %d steps of the strategy, want = 1", drill.State, count)
+				}
+			}
+		})
+	}
+}
+
+func TestFindDrills_canceled(t *testing.T) {
+	puzzle := &app.Puzzle{
+		ID:    1,
+		Type:  app.PuzzleSudokuClassic,
+		Clues: "72..96..3...2.5....8...4.2........6.1.65.38.7.4........3.8...9....7.2...2..43..18",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := app.FindDrills(ctx, PuzzleLibrary{}, puzzle, app.StrategyNakedSingle); !errors.Is(err, context.Canceled) {
+		t.Errorf("FindDrills() error = %v, want = %v", err, context.Canceled)
+	}
+}
+
+func TestCheckCustomPuzzle(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		canceled   bool
+		wantRating app.PuzzleLevel
+		wantErr    error
+	}{
+		{
+			name:       "rated",
+			text:       "72..96..3...2.5....8...4.2........6.1.65.38.7.4........3.8...9....7.2...2..43..18",
+			wantRating: app.PuzzleLevelNormal,
+		},
+		{
+			name:    "multiple solutions",
+			text:    "..3456789456789123789123456..4365897365897214897214365531642978642978531978531642",
+			wantErr: app.ErrorPuzzleMultipleSolutions,
+		},
+		{
+			name:     "canceled search",
+			text:     "72..96..3...2.5....8...4.2........6.1.65.38.7.4........3.8...9....7.2...2..43..18",
+			canceled: true,
+			wantErr:  context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.canceled {
+				cancel()
+			}
+			custom, err := app.CheckCustomPuzzle(ctx, PuzzleLibrary{}, app.PuzzleSudokuClassic, tt.text)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("CheckCustomPuzzle() error = %v, want = %v", err, tt.wantErr)
+			}
+			if err == nil && custom.Rating != tt.wantRating {
+				t.Errorf("CheckCustomPuzzle() got rating = %s, want = %s", custom.Rating, tt.wantRating)
+			}
+		})
+	}
+}
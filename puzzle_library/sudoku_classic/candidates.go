@@ -87,6 +87,17 @@ func (c puzzleCandidates) encodeOnlyChanges(base puzzleCandidates) string {
 	return string(bts)
 }
 
+// eliminations returns the candidates deleted from base in the order of points.
+func (c puzzleCandidates) eliminations(base puzzleCandidates) (out []app.StepCandidates) {
+	c.forEach(func(point app.Point, candidates cellCandidates, _ *bool) {
+		del := base[point.Row][point.Col].complement(candidates)
+		if del.len() > 0 {
+			out = append(out, app.StepCandidates{Point: point, Digits: del.sliceInt8()})
+		}
+	})
+	return out
+}
+
 func decodeCandidates(s string) (puzzleCandidates, error) {
 	in := puzzleCandidatesExternal{}
 	if err := json.Unmarshal([]byte(s), &in); err != nil {
@@ -1049,6 +1060,24 @@ func (c cellCandidates) complement(of cellCandidates) cellCandidates {
 	return complement
 }
 
+// onlyIn reports whether the digit is a candidate in the house only in the points.
+func (c puzzleCandidates) onlyIn(house app.House, digit uint8, points ...app.Point) bool {
+	only := true
+	c.forEach(func(point app.Point, candidates cellCandidates, stop *bool) {
+		if houseOf(house.Type, point) != house || !candidates.has(digit) {
+			return
+		}
+		for _, p := range points {
+			if p == point {
+				return
+			}
+		}
+		only = false
+		*stop = true
+	})
+	return only
+}
+
 // BoxIdFrom returns 1, 2, 3, 4, 5, 6, 7, 8 or 9 as box 3x3 id.
 func BoxIdFrom(point app.Point) uint8 {
 	return uint8(point.Row/sizeGrp*sizeGrp + point.Col/sizeGrp + 1)
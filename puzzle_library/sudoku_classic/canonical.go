@@ -0,0 +1,72 @@
+package sudoku_classic
+
+import "github.com/cnblvr/puzzles/app"
+
+// linePermutations are all orders of lines made by SwapLines and SwapBigLines: the bands are ordered and then the
+// lines inside every band. linePermutations[i][line] is the line of the puzzle moved to the line.
+var linePermutations = func() (out [][size]int) {
+	perms := [][sizeGrp]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+	for _, bands := range perms {
+		for _, first := range perms {
+			for _, second := range perms {
+				for _, third := range perms {
+					var lines [size]int
+					for band, inner := range [sizeGrp][sizeGrp]int{first, second, third} {
+						for i := 0; i < sizeGrp; i++ {
+							lines[band*sizeGrp+i] = bands[band]*sizeGrp + inner[i]
+						}
+					}
+					out = append(out, lines)
+				}
+			}
+		}
+	}
+	return out
+}()
+
+// Canonical returns the signature of the puzzle that is the same for all puzzles made from it by SwapLines,
+// SwapBigLines, Rotate, Reflect and SwapDigits. It is the smallest puzzle of these transformations in String form
+// when the empty cell is less than any digit and the digits are renamed from 1 in the order of their first cells.
+func (p puzzle) Canonical() string {
+	var best, current [size * size]uint8
+	for i := range best {
+		best[i] = size + 1
+	}
+	// the rotations and the reflections are the transposition with orders of lines
+	transposed := p.clone()
+	_ = transposed.Reflect(app.ReflectMajorDiagonal)
+	for _, grid := range []puzzle{p, transposed} {
+		for _, rows := range linePermutations {
+			for _, cols := range linePermutations {
+				var names [size + 1]uint8
+				next := uint8(1)
+				less := false
+				for i := 0; i < size*size; i++ {
+					digit := grid[rows[i/size]][cols[i%size]]
+					if digit > 0 {
+						if names[digit] == 0 {
+							names[digit] = next
+							next++
+						}
+						digit = names[digit]
+					}
+					if !less {
+						if digit > best[i] {
+							break
+						}
+						less = digit < best[i]
+					}
+					current[i] = digit
+				}
+				if less {
+					best = current
+				}
+			}
+		}
+	}
+	var out puzzle
+	for i, digit := range best {
+		out[i/size][i%size] = digit
+	}
+	return out.String()
+}
@@ -0,0 +1,110 @@
+package sudoku_classic
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
+	"math/bits"
+)
+
+// ParseClues parses the puzzle typed or pasted by the user in one of Formats, see DetectFormat. Both the string of 81
+// cells and the grid with borders are accepted:
+//
+//	5 3 . | . 7 . | . . .
+//	6 . . | 1 9 5 | . . .
+//	------+-------+------
+//
+// Errors: app.ErrorPuzzleInvalid.
+func (sc SudokuClassic) ParseClues(text string) (app.PuzzleGenerator, error) {
+	grid, err := Import(DetectFormat(text), text)
+	if err != nil {
+		return nil, err
+	}
+	return parse(grid.State)
+}
+
+// searchCheckNodes is the number of the cells filled by FindSolutions between the checks of the context.
+const searchCheckNodes = 1 << 12
+
+// FindSolutions finds at most limit solutions of the puzzle by backtracking. The puzzle itself is not changed. There
+// are no solutions if the clues repeat a digit in a house. A sparse puzzle takes long, so the search stops when the
+// context is done.
+//
+// Errors: context.Canceled, context.DeadlineExceeded.
+func (p puzzle) FindSolutions(ctx context.Context, limit int) (solutions []string, err error) {
+	// rows, cols and boxes are the masks of the digits used in the houses, the bit 1<<digit is set for a digit
+	var rows, cols, boxes [size]uint16
+	box := func(row, col int) int {
+		return row/sizeGrp*sizeGrp + col/sizeGrp
+	}
+	work := p.clone()
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			digit := work[row][col]
+			if digit == 0 {
+				continue
+			}
+			bit := uint16(1) << digit
+			if (rows[row]|cols[col]|boxes[box(row, col)])&bit > 0 {
+				return nil, nil
+			}
+			rows[row], cols[col], boxes[box(row, col)] = rows[row]|bit, cols[col]|bit, boxes[box(row, col)]|bit
+		}
+	}
+
+	const allDigits = uint16(0b1111111110)
+	nodes := 0
+	// search fills the cell with the fewest candidates first and returns true when enough solutions are found or the
+	// context is done
+	var search func() bool
+	search = func() bool {
+		if nodes%searchCheckNodes == 0 {
+			if err = ctx.Err(); err != nil {
+				return true
+			}
+		}
+		nodes++
+		bestRow, bestCol, bestCount, bestMask := -1, -1, size+1, uint16(0)
+		for row := 0; row < size; row++ {
+			for col := 0; col < size; col++ {
+				if work[row][col] != 0 {
+					continue
+				}
+				mask := allDigits &^ (rows[row] | cols[col] | boxes[box(row, col)])
+				count := bits.OnesCount16(mask)
+				if count == 0 {
+					return false
+				}
+				if count < bestCount {
+					bestRow, bestCol, bestCount, bestMask = row, col, count, mask
+				}
+			}
+		}
+		if bestRow < 0 {
+			solutions = append(solutions, work.String())
+			return len(solutions) >= limit
+		}
+		b := box(bestRow, bestCol)
+		for digit := uint8(1); digit <= size; digit++ {
+			bit := uint16(1) << digit
+			if bestMask&bit == 0 {
+				continue
+			}
+			work[bestRow][bestCol] = digit
+			rows[bestRow], cols[bestCol], boxes[b] = rows[bestRow]|bit, cols[bestCol]|bit, boxes[b]|bit
+			if search() {
+				return true
+			}
+			rows[bestRow], cols[bestCol], boxes[b] = rows[bestRow]&^bit, cols[bestCol]&^bit, boxes[b]&^bit
+		}
+		work[bestRow][bestCol] = 0
+		return false
+	}
+	if limit > 0 {
+		search()
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return solutions, nil
+}
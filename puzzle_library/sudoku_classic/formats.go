@@ -0,0 +1,384 @@
+package sudoku_classic
+
+import (
+	"fmt"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// Format is a text format of puzzles used by other sudoku tools.
+type Format string
+
+const (
+	// FormatLine is 81 cells in one line, '.' or '0' for empty cells. A line may have a name after spaces.
+	FormatLine Format = "line"
+	// FormatSadMan is the .sdk file of SadMan Software Sudoku: 9 rows of 9 cells and '#' lines with metadata.
+	FormatSadMan Format = "sdk"
+	// FormatSimpleSudoku is the .ss file of Simple Sudoku: 9 rows with '|' between boxes and '-' lines between
+	// bands.
+	FormatSimpleSudoku Format = "ss"
+	// FormatPencilmarks is the pencilmark grid of HoDoKu and SudokuWiki: every cell is its digit or its candidates.
+	FormatPencilmarks Format = "pm"
+)
+
+// Formats are all supported formats.
+var Formats = []Format{FormatLine, FormatSadMan, FormatSimpleSudoku, FormatPencilmarks}
+
+// Grid is a puzzle in the formats of the repository.
+type Grid struct {
+	// Name is the description of the puzzle if the format keeps it.
+	Name string
+	// Clues are the givens as app.Puzzle.Clues.
+	Clues string
+	// State is the state of the game as app.PuzzleGame.State. Formats without the state have it equal to Clues.
+	State string
+	// Candidates are the candidates of empty cells as app.PuzzleGame.StateCandidates. It is empty if the format has
+	// no candidates.
+	Candidates string
+}
+
+// DetectFormat guesses the format of the text. FormatLine is returned for a file with many puzzles, see ImportLines.
+func DetectFormat(text string) Format {
+	lines := contentLines(text)
+	if len(lines) == 0 {
+		return FormatLine
+	}
+	if fields := strings.Fields(lines[0]); len(fields) > 0 && len(fields[0]) >= size*size {
+		return FormatLine
+	}
+	if isPencilmarks(lines) {
+		return FormatPencilmarks
+	}
+	if strings.Contains(text, "|") {
+		return FormatSimpleSudoku
+	}
+	return FormatSadMan
+}
+
+// Import parses one puzzle in the format.
+//
+// Errors: app.ErrorPuzzleInvalid.
+func Import(format Format, text string) (*Grid, error) {
+	switch format {
+	case FormatLine:
+		lines := contentLines(text)
+		if len(lines) != 1 {
+			return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "%d lines instead of one", len(lines))
+		}
+		return importLine(lines[0])
+	case FormatSadMan:
+		return importRows(text, "", true)
+	case FormatSimpleSudoku:
+		return importRows(text, "|*+", false)
+	case FormatPencilmarks:
+		return importPencilmarks(text)
+	default:
+		return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "unknown format %q", format)
+	}
+}
+
+// ImportLines parses the file with one puzzle per line. Empty lines and lines starting with '#' are skipped.
+//
+// Errors: app.ErrorPuzzleInvalid.
+func ImportLines(text string) ([]Grid, error) {
+	var grids []Grid
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		grid, err := importLine(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", i+1)
+		}
+		grids = append(grids, *grid)
+	}
+	return grids, nil
+}
+
+// Export writes the puzzle in the format. Formats without candidates keep only the givens, so they are written from
+// Clues. The pencilmark grid is written from State and Candidates; empty cells without candidates get the simple
+// candidates. An empty cell with one candidate looks like a placed digit there, so it is imported as one.
+//
+// Errors: app.ErrorPuzzleInvalid.
+func Export(format Format, grid Grid) (string, error) {
+	if format == FormatPencilmarks {
+		return exportPencilmarks(grid)
+	}
+	p, err := parse(grid.Clues)
+	if err != nil {
+		return "", errors.Wrap(app.ErrorPuzzleInvalid, err.Error())
+	}
+	var out strings.Builder
+	switch format {
+	case FormatLine:
+		out.WriteString(p.String())
+		if grid.Name != "" {
+			out.WriteString(" " + grid.Name)
+		}
+		out.WriteString("\n")
+	case FormatSadMan:
+		if grid.Name != "" {
+			out.WriteString("#D " + grid.Name + "\n")
+		}
+		for _, row := range p.rows() {
+			out.WriteString(row + "\n")
+		}
+	case FormatSimpleSudoku:
+		for i, row := range p.rows() {
+			if i > 0 && i%sizeGrp == 0 {
+				out.WriteString(strings.Repeat("-", size+sizeGrp-1) + "\n")
+			}
+			out.WriteString(row[0:3] + "|" + row[3:6] + "|" + row[6:9] + "\n")
+		}
+	default:
+		return "", errors.Wrapf(app.ErrorPuzzleInvalid, "unknown format %q", format)
+	}
+	return out.String(), nil
+}
+
+// ExportLines writes the puzzles in the file with one puzzle per line.
+//
+// Errors: app.ErrorPuzzleInvalid.
+func ExportLines(grids []Grid) (string, error) {
+	var out strings.Builder
+	for i, grid := range grids {
+		line, err := Export(FormatLine, grid)
+		if err != nil {
+			return "", errors.Wrapf(err, "puzzle %d", i+1)
+		}
+		out.WriteString(line)
+	}
+	return out.String(), nil
+}
+
+// contentLines returns the trimmed lines without empty lines.
+func contentLines(text string) (lines []string) {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// newGrid returns the grid of the format without the state.
+func newGrid(name string, p puzzle) *Grid {
+	return &Grid{Name: name, Clues: p.String(), State: p.String()}
+}
+
+func importLine(line string) (*Grid, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || len(fields[0]) != size*size {
+		return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "the line must start with %d cells", size*size)
+	}
+	var p puzzle
+	for i := 0; i < size*size; i++ {
+		digit, ok := parseCell(fields[0][i])
+		if !ok {
+			return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "unknown cell %q", fields[0][i])
+		}
+		p[i/size][i%size] = digit
+	}
+	return newGrid(strings.Join(fields[1:], " "), p), nil
+}
+
+// importRows parses 9 rows of 9 cells. Spaces and borders are ignored, and lines of borders are skipped. metadata
+// allows the '#' lines of .sdk files and their sections like "[Puzzle]".
+func importRows(text string, borders string, metadata bool) (*Grid, error) {
+	var (
+		p    puzzle
+		name string
+		rows int
+	)
+	for _, line := range contentLines(text) {
+		if metadata && strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "#D") {
+				name = strings.TrimSpace(line[2:])
+			}
+			continue
+		}
+		if metadata && strings.HasPrefix(line, "[") {
+			continue
+		}
+		line = strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || strings.ContainsRune(borders, r) {
+				return -1
+			}
+			return r
+		}, line)
+		if strings.Trim(line, "-=") == "" {
+			continue
+		}
+		if rows == size {
+			// .sdk files may have the state of the game after the puzzle
+			if metadata {
+				break
+			}
+			return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "more than %d rows", size)
+		}
+		if len(line) != size {
+			return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "row %d has %d cells", rows+1, len(line))
+		}
+		for col := 0; col < size; col++ {
+			digit, ok := parseCell(line[col])
+			if !ok {
+				return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "unknown cell %q", line[col])
+			}
+			p[rows][col] = digit
+		}
+		rows++
+	}
+	if rows != size {
+		return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "%d rows instead of %d", rows, size)
+	}
+	return newGrid(name, p), nil
+}
+
+// parseCell returns the digit of the cell: 1-9 or 0 for '.' and '0'.
+func parseCell(char byte) (uint8, bool) {
+	switch {
+	case '1' <= char && char <= '9':
+		return char - '0', true
+	case char == '.' || char == '0':
+		return 0, true
+	}
+	return 0, false
+}
+
+// pencilmarkTokens returns the cells of the pencilmark grid. Lines of borders have no digits and are skipped.
+func pencilmarkTokens(lines []string) (tokens []string) {
+	for _, line := range lines {
+		if !strings.ContainsAny(line, "123456789") {
+			continue
+		}
+		tokens = append(tokens, strings.Fields(strings.NewReplacer("|", " ", ":", " ").Replace(line))...)
+	}
+	return tokens
+}
+
+// isPencilmarks reports whether the lines are 81 cells of digits and candidates. Other grids have '.' for empty
+// cells or no spaces between cells.
+func isPencilmarks(lines []string) bool {
+	tokens := pencilmarkTokens(lines)
+	if len(tokens) != size*size {
+		return false
+	}
+	for _, token := range tokens {
+		if strings.Trim(token, "123456789") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func importPencilmarks(text string) (*Grid, error) {
+	tokens := pencilmarkTokens(contentLines(text))
+	if len(tokens) != size*size {
+		return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "%d cells instead of %d", len(tokens), size*size)
+	}
+	var p puzzle
+	candidates := newPuzzleCandidates(false)
+	for i, token := range tokens {
+		row, col := i/size, i%size
+		for j := 0; j < len(token); j++ {
+			digit, ok := parseCell(token[j])
+			if !ok || digit == 0 {
+				return nil, errors.Wrapf(app.ErrorPuzzleInvalid, "unknown cell %q", token)
+			}
+			candidates[row][col].add(digit)
+		}
+		if len(token) == 1 {
+			// the pencilmark grid does not tell the givens from the placed digits
+			p[row][col] = token[0] - '0'
+			candidates[row][col] = newCellCandidatesEmpty()
+		}
+	}
+	grid := newGrid("", p)
+	grid.Candidates = candidates.encode()
+	return grid, nil
+}
+
+func exportPencilmarks(grid Grid) (string, error) {
+	state := grid.State
+	if state == "" {
+		state = grid.Clues
+	}
+	p, err := parse(state)
+	if err != nil {
+		return "", errors.Wrap(app.ErrorPuzzleInvalid, err.Error())
+	}
+	candidates := p.findSimpleCandidates()
+	if grid.Candidates != "" {
+		stateCandidates, err := decodeCandidates(grid.Candidates)
+		if err != nil {
+			return "", errors.Wrap(app.ErrorPuzzleInvalid, err.Error())
+		}
+		stateCandidates.forEach(func(point app.Point, cell cellCandidates, _ *bool) {
+			if cell.len() > 0 {
+				candidates[point.Row][point.Col] = cell
+			}
+		})
+	}
+
+	var cells [size][size]string
+	var widths [size]int
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			if digit := p[row][col]; digit > 0 {
+				cells[row][col] = string('0' + digit)
+			} else {
+				for _, digit := range candidates[row][col].slice() {
+					cells[row][col] += string('0' + digit)
+				}
+			}
+			if len(cells[row][col]) > widths[col] {
+				widths[col] = len(cells[row][col])
+			}
+		}
+	}
+	// border draws the line between bands with the corners, e.g. ".---.---." on the top
+	border := func(left, middle, right string) string {
+		line := left
+		for box := 0; box < sizeGrp; box++ {
+			width := 1
+			for col := box * sizeGrp; col < (box+1)*sizeGrp; col++ {
+				width += widths[col] + 2
+			}
+			line += strings.Repeat("-", width)
+			if box < sizeGrp-1 {
+				line += middle
+			}
+		}
+		return line + right + "\n"
+	}
+
+	var out strings.Builder
+	out.WriteString(border(".", ".", "."))
+	for row := 0; row < size; row++ {
+		if row > 0 && row%sizeGrp == 0 {
+			out.WriteString(border(":", "+", ":"))
+		}
+		out.WriteString("|")
+		for col := 0; col < size; col++ {
+			out.WriteString(fmt.Sprintf(" %-*s ", widths[col], cells[row][col]))
+			if col%sizeGrp == sizeGrp-1 {
+				out.WriteString(" |")
+			}
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString(border("'", "'", "'"))
+	return out.String(), nil
+}
+
+// rows returns the rows of the puzzle as in String.
+func (p puzzle) rows() []string {
+	s := p.String()
+	rows := make([]string, size)
+	for row := 0; row < size; row++ {
+		rows[row] = s[row*size : (row+1)*size]
+	}
+	return rows
+}
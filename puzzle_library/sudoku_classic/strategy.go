@@ -7,15 +7,17 @@ import (
 
 type puzzleStepSetter interface {
 	app.PuzzleStep
-	setCandidateChanges(string)
+	setCandidateChanges(changes string, eliminations []app.StepCandidates)
 }
 
 type candidateChanges struct {
-	changes string
+	changes      string
+	eliminations []app.StepCandidates
 }
 
-func (c *candidateChanges) setCandidateChanges(s string) {
-	c.changes = s
+func (c *candidateChanges) setCandidateChanges(changes string, eliminations []app.StepCandidates) {
+	c.changes = changes
+	c.eliminations = eliminations
 }
 
 func (c candidateChanges) CandidateChanges() string {
@@ -27,12 +29,37 @@ type puzzleStepSet struct {
 	strategy app.PuzzleStrategy
 	point    app.Point
 	value    uint8
+	// house is the house where the value has a single place (Hidden Single).
+	house *app.House
 }
 
 func (s puzzleStepSet) Strategy() app.PuzzleStrategy {
 	return s.strategy
 }
 
+func (s puzzleStepSet) Payload() app.PuzzleStepPayload {
+	payload := app.PuzzleStepPayload{
+		Houses: []app.House{
+			houseOf(app.HouseRow, s.point),
+			houseOf(app.HouseCol, s.point),
+			houseOf(app.HouseBox, s.point),
+		},
+		Pattern:    []app.Point{s.point},
+		Digits:     []int8{int8(s.value)},
+		Placements: []app.StepPlacement{{Point: s.point, Digit: int8(s.value)}},
+	}
+	if s.house != nil {
+		payload.Houses = []app.House{*s.house}
+	}
+	// candidates of the placed cell are not eliminations
+	for _, elimination := range s.eliminations {
+		if elimination.Point != s.point {
+			payload.Eliminations = append(payload.Eliminations, elimination)
+		}
+	}
+	return payload
+}
+
 func (s puzzleStepSet) Description() string {
 	out := fmt.Sprintf("set %d in point %s", s.value, s.point)
 	return out
@@ -57,6 +84,15 @@ func (s puzzleStepNakedStrategy) Strategy() app.PuzzleStrategy {
 	}
 }
 
+func (s puzzleStepNakedStrategy) Payload() app.PuzzleStepPayload {
+	return app.PuzzleStepPayload{
+		Houses:       commonHouses(s.points),
+		Pattern:      s.points,
+		Digits:       digitsInt8(s.set),
+		Eliminations: s.eliminations,
+	}
+}
+
 func (s puzzleStepNakedStrategy) Description() string {
 	return fmt.Sprintf("has candidates %v in points %s", s.set, s.points)
 }
@@ -80,6 +116,15 @@ func (s puzzleStepHiddenStrategy) Strategy() app.PuzzleStrategy {
 	}
 }
 
+func (s puzzleStepHiddenStrategy) Payload() app.PuzzleStepPayload {
+	return app.PuzzleStepPayload{
+		Houses:       commonHouses(s.points),
+		Pattern:      s.points,
+		Digits:       digitsInt8(s.set),
+		Eliminations: s.eliminations,
+	}
+}
+
 func (s puzzleStepHiddenStrategy) Description() string {
 	return fmt.Sprintf("has candidates %v in points %s", s.set, s.points)
 }
@@ -101,6 +146,15 @@ func (s puzzleStepPointingStrategy) Strategy() app.PuzzleStrategy {
 	}
 }
 
+func (s puzzleStepPointingStrategy) Payload() app.PuzzleStepPayload {
+	return app.PuzzleStepPayload{
+		Houses:       commonHouses(s.points),
+		Pattern:      s.points,
+		Digits:       []int8{int8(s.value)},
+		Eliminations: s.eliminations,
+	}
+}
+
 func (s puzzleStepPointingStrategy) Description() string {
 	return fmt.Sprintf("has candidate %d in points %v", s.value, s.points)
 }
@@ -122,6 +176,15 @@ func (s puzzleStepBoxLineReductionStrategy) Strategy() app.PuzzleStrategy {
 	}
 }
 
+func (s puzzleStepBoxLineReductionStrategy) Payload() app.PuzzleStepPayload {
+	return app.PuzzleStepPayload{
+		Houses:       commonHouses(s.points),
+		Pattern:      s.points,
+		Digits:       []int8{int8(s.value)},
+		Eliminations: s.eliminations,
+	}
+}
+
 func (s puzzleStepBoxLineReductionStrategy) Description() string {
 	return fmt.Sprintf("has candidate %d in points %v", s.value, s.points)
 }
@@ -137,6 +200,113 @@ func (s puzzleStepXWingStrategy) Strategy() app.PuzzleStrategy {
 	return app.StrategyXWing
 }
 
+func (s puzzleStepXWingStrategy) Payload() app.PuzzleStepPayload {
+	typ := app.HouseCol
+	if s.pairA[0].InSameRow(s.pairA[1:]...) {
+		typ = app.HouseRow
+	}
+	return app.PuzzleStepPayload{
+		Houses:  []app.House{houseOf(typ, s.pairA[0]), houseOf(typ, s.pairB[0])},
+		Pattern: append(append([]app.Point{}, s.pairA...), s.pairB...),
+		Digits:  []int8{int8(s.value)},
+		Links: []app.StepLink{
+			{From: s.pairA[0], To: s.pairA[1], Digit: int8(s.value)},
+			{From: s.pairB[0], To: s.pairB[1], Digit: int8(s.value)},
+		},
+		Eliminations: s.eliminations,
+	}
+}
+
 func (s puzzleStepXWingStrategy) Description() string {
 	return fmt.Sprintf("has candidate %d in pairs %v and %v", s.value, s.pairA, s.pairB)
 }
+
+// digitsInt8 converts the digits for app.PuzzleStepPayload.
+func digitsInt8(digits []uint8) []int8 {
+	out := make([]int8, len(digits))
+	for i, digit := range digits {
+		out[i] = int8(digit)
+	}
+	return out
+}
+
+// houseOf returns the house of the type that contains the point.
+func houseOf(typ app.HouseType, point app.Point) app.House {
+	switch typ {
+	case app.HouseRow:
+		return app.House{Type: typ, Index: point.Row}
+	case app.HouseCol:
+		return app.House{Type: typ, Index: point.Col}
+	default:
+		return app.House{Type: app.HouseBox, Index: point.Row/sizeGrp*sizeGrp + point.Col/sizeGrp}
+	}
+}
+
+// commonHouses returns the houses that contain all points.
+func commonHouses(points []app.Point) (houses []app.House) {
+	if len(points) == 0 {
+		return nil
+	}
+	for _, typ := range []app.HouseType{app.HouseRow, app.HouseCol, app.HouseBox} {
+		house := houseOf(typ, points[0])
+		common := true
+		for _, point := range points[1:] {
+			if houseOf(typ, point) != house {
+				common = false
+				break
+			}
+		}
+		if common {
+			houses = append(houses, house)
+		}
+	}
+	return houses
+}
+
+// stepPatternIn reports whether the pattern of the step is in the candidates.
+func stepPatternIn(step puzzleStepSetter, candidates puzzleCandidates) bool {
+	switch s := step.(type) {
+	case *puzzleStepSet:
+		if s.house == nil {
+			cell := candidates[s.point.Row][s.point.Col]
+			return cell.len() == 1 && cell.has(s.value)
+		}
+		return candidates.onlyIn(*s.house, s.value, s.point)
+	case *puzzleStepNakedStrategy:
+		for _, point := range s.points {
+			if candidates[point.Row][point.Col].complement(newCellCandidatesWith(s.set...)).len() > 0 {
+				return false
+			}
+		}
+		return true
+	case *puzzleStepHiddenStrategy:
+		for _, house := range commonHouses(s.points) {
+			hidden := true
+			for _, digit := range s.set {
+				hidden = hidden && candidates.onlyIn(house, digit, s.points...)
+			}
+			if hidden {
+				return true
+			}
+		}
+		return false
+	case *puzzleStepPointingStrategy:
+		return candidates.onlyIn(houseOf(app.HouseBox, s.points[0]), s.value, s.points...)
+	case *puzzleStepBoxLineReductionStrategy:
+		for _, house := range commonHouses(s.points) {
+			if house.Type != app.HouseBox && candidates.onlyIn(house, s.value, s.points...) {
+				return true
+			}
+		}
+		return false
+	case *puzzleStepXWingStrategy:
+		typ := app.HouseCol
+		if s.pairA[0].InSameRow(s.pairA[1:]...) {
+			typ = app.HouseRow
+		}
+		return candidates.onlyIn(houseOf(typ, s.pairA[0]), s.value, s.pairA...) &&
+			candidates.onlyIn(houseOf(typ, s.pairB[0]), s.value, s.pairB...)
+	default:
+		return false
+	}
+}
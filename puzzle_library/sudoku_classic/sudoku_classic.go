@@ -1,6 +1,7 @@
 package sudoku_classic
 
 import (
+	"context"
 	crand "crypto/rand"
 	"encoding/binary"
 	"fmt"
@@ -82,12 +83,16 @@ func (sc SudokuClassic) Type() app.PuzzleType {
 // NewRandomSolution generates a solution randomly for further extraction of
 // digits.
 func (sc SudokuClassic) NewRandomSolution() (s app.PuzzleGenerator, seed int64) {
+	seed = randomSeed()
+	return sc.NewSolutionBySeed(seed), seed
+}
+
+func randomSeed() int64 {
 	seedBts := make([]byte, 8)
 	if _, err := crand.Reader.Read(seedBts); err != nil {
 		panic(err)
 	}
-	seed = int64(binary.LittleEndian.Uint64(seedBts))
-	return sc.NewSolutionBySeed(seed), seed
+	return int64(binary.LittleEndian.Uint64(seedBts))
 }
 
 // NewSolutionBySeed generates a solution with a given seed for further
@@ -102,6 +107,106 @@ func (sc SudokuClassic) NewSolutionBySeed(seed int64) app.PuzzleGenerator {
 	return &s
 }
 
+// NewRandomTransform returns a random transform of the transformations of Canonical: the bands, the stacks and the
+// lines inside them are shuffled, the puzzle is rotated and reflected and the digits are renamed.
+//
+// The transform of the symmetric puzzle keeps its symmetry. The lines are shuffled only together with the lines
+// mirrored around the centre, and the rows and the columns get the same order if the symmetry maps the rows to the
+// columns. The rotations and the reflections are limited to those that map the symmetry to itself.
+func (sc SudokuClassic) NewRandomTransform(symmetry app.PuzzleSymmetry) (transform app.PuzzleTransform) {
+	rnd := rand.New(rand.NewSource(randomSeed()))
+	switch symmetry {
+	case app.SymmetryRotational:
+		transform = append(randomMirroredLinesSwaps(rnd, app.Horizontal), randomMirroredLinesSwaps(rnd, app.Vertical)...)
+	case app.SymmetryRotational90:
+		rows := randomMirroredLinesSwaps(rnd, app.Horizontal)
+		transform = append(rows, withDirection(rows, app.Vertical)...)
+	case app.SymmetryMirror:
+		transform = append(randomLinesSwaps(rnd, app.Horizontal), randomMirroredLinesSwaps(rnd, app.Vertical)...)
+	case app.SymmetryDiagonal:
+		rows := randomLinesSwaps(rnd, app.Horizontal)
+		transform = append(rows, withDirection(rows, app.Vertical)...)
+	default:
+		transform = append(randomLinesSwaps(rnd, app.Horizontal), randomLinesSwaps(rnd, app.Vertical)...)
+	}
+	switch symmetry {
+	case app.SymmetryMirror, app.SymmetryDiagonal:
+		// the rotation by 180 degrees and the reflection along the axis of the symmetry keep the axis
+		if rnd.Intn(2) == 1 {
+			transform = append(transform, app.PuzzleTransformStep{Op: app.TransformRotate, A: int(app.RotateTo180)})
+		}
+		reflect := app.ReflectHorizontal
+		if symmetry == app.SymmetryDiagonal {
+			reflect = app.ReflectMajorDiagonal
+		}
+		if rnd.Intn(2) == 1 {
+			transform = append(transform, app.PuzzleTransformStep{Op: app.TransformReflect, A: int(reflect)})
+		}
+	default:
+		if r := rnd.Intn(int(app.RotateTo270) + 1); r > 0 {
+			transform = append(transform, app.PuzzleTransformStep{Op: app.TransformRotate, A: r})
+		}
+		if rnd.Intn(2) == 1 {
+			transform = append(transform, app.PuzzleTransformStep{Op: app.TransformReflect, A: int(app.ReflectHorizontal)})
+		}
+	}
+	for a := size; a > 1; a-- {
+		if b := rnd.Intn(a) + 1; b != a {
+			transform = append(transform, app.PuzzleTransformStep{Op: app.TransformSwapDigits, A: a, B: b})
+		}
+	}
+	return transform
+}
+
+// randomLinesSwaps returns the swaps that shuffle the bands of the direction and the lines inside them. Every order is
+// made by the swaps of the Fisher-Yates shuffle.
+func randomLinesSwaps(rnd *rand.Rand, dir app.DirectionType) (swaps app.PuzzleTransform) {
+	for a := sizeGrp - 1; a > 0; a-- {
+		if b := rnd.Intn(a + 1); b != a {
+			swaps = append(swaps, app.PuzzleTransformStep{Op: app.TransformSwapBigLines, Dir: dir, A: a, B: b})
+		}
+	}
+	for band := 0; band < size; band += sizeGrp {
+		for a := sizeGrp - 1; a > 0; a-- {
+			if b := rnd.Intn(a + 1); b != a {
+				swaps = append(swaps, app.PuzzleTransformStep{Op: app.TransformSwapLines, Dir: dir, A: band + a, B: band + b})
+			}
+		}
+	}
+	return swaps
+}
+
+// randomMirroredLinesSwaps returns the swaps that shuffle the lines of the direction so that the mirrored lines stay
+// mirrored: the first and the last bands are swapped, the lines of the first band are shuffled together with the
+// mirrored lines of the last band and the outer lines of the middle band are swapped.
+func randomMirroredLinesSwaps(rnd *rand.Rand, dir app.DirectionType) (swaps app.PuzzleTransform) {
+	if rnd.Intn(2) == 1 {
+		swaps = append(swaps, app.PuzzleTransformStep{Op: app.TransformSwapBigLines, Dir: dir, A: 0, B: sizeGrp - 1})
+	}
+	for a := sizeGrp - 1; a > 0; a-- {
+		if b := rnd.Intn(a + 1); b != a {
+			swaps = append(swaps,
+				app.PuzzleTransformStep{Op: app.TransformSwapLines, Dir: dir, A: a, B: b},
+				app.PuzzleTransformStep{Op: app.TransformSwapLines, Dir: dir, A: size - 1 - a, B: size - 1 - b},
+			)
+		}
+	}
+	if rnd.Intn(2) == 1 {
+		swaps = append(swaps, app.PuzzleTransformStep{Op: app.TransformSwapLines, Dir: dir, A: sizeGrp, B: 2*sizeGrp - 1})
+	}
+	return swaps
+}
+
+// withDirection returns the copy of the swaps of lines in the direction.
+func withDirection(swaps app.PuzzleTransform, dir app.DirectionType) app.PuzzleTransform {
+	out := make(app.PuzzleTransform, len(swaps))
+	for i, step := range swaps {
+		step.Dir = dir
+		out[i] = step
+	}
+	return out
+}
+
 func (p puzzle) shuffle(rnd *rand.Rand) {
 
 	// swap of horizontal or vertical lines within one "big" line
@@ -332,80 +437,113 @@ func (p *puzzle) SwapDigits(a, b uint8) error {
 	return nil
 }
 
-func (p *puzzle) solve(candidates puzzleCandidates, chanSteps chan<- app.PuzzleStep, strategies app.PuzzleStrategy) (changed bool, candidatesOut string, err error) {
-	changedOnIteration := true
+// solve applies strategies step by step until the puzzle is solved, no strategy
+// applies or a contradiction is found.
+func (p *puzzle) solve(candidates puzzleCandidates, chanSteps chan<- app.PuzzleStep, strategies app.PuzzleStrategy) (outcome app.SolveOutcome) {
 	if chanSteps != nil {
 		defer close(chanSteps)
 	}
-	for changedOnIteration {
-		changedOnIteration = false
-
-		var step app.PuzzleStep
+	for {
 		candidatesBase := candidates.clone()
-		changedOnIteration, step, err = p.solveOneStep(candidates, candidatesBase, strategies)
-		if err != nil {
-			return
+		stepOutcome := p.solveOneStepOutcome(candidates, candidatesBase, strategies)
+		if stepOutcome.Status != app.SolveStatusProgress {
+			stepOutcome.Changed = outcome.Changed
+			return stepOutcome
 		}
-		if changedOnIteration {
-			changed = true
-			if chanSteps != nil {
-				chanSteps <- step
-			}
+		outcome.Changed = true
+		if chanSteps != nil {
+			chanSteps <- stepOutcome.Step
 		}
 	}
-	return
 }
 
-func (p *puzzle) Solve(candidatesIn string, chanSteps chan<- app.PuzzleStep, strategies app.PuzzleStrategy) (changed bool, candidatesOut string, err error) {
-	var candidates puzzleCandidates
-	if candidatesIn == "" {
-		candidates = p.findSimpleCandidates()
-	} else {
-		candidates, err = decodeCandidates(candidatesIn)
-		if err != nil {
-			return
+func (p *puzzle) Solve(candidatesIn string, chanSteps chan<- app.PuzzleStep, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
+	candidates, err := p.prepareCandidates(candidatesIn)
+	if err != nil {
+		if chanSteps != nil {
+			close(chanSteps)
 		}
-		p.optimizeCandidates(&candidates)
+		return app.SolveOutcome{}, errors.WithStack(err)
 	}
-	defer func(candidates puzzleCandidates) {
-		candidatesOut = candidates.encode()
-	}(candidates)
 
-	return p.solve(candidates, chanSteps, strategies)
+	outcome := p.solve(candidates, chanSteps, strategies)
+	outcome.Candidates = candidates.encode()
+	return outcome, nil
 }
 
-func (p *puzzle) SolveOneStep(candidatesIn string, strategies app.PuzzleStrategy) (candidatesChanges string, step app.PuzzleStep, err error) {
-	var candidates, candidatesBase puzzleCandidates
-	if candidatesIn == "" {
-		candidates = p.findSimpleCandidates()
-	} else {
-		candidates, err = decodeCandidates(candidatesIn)
-		if err != nil {
-			return
+func (p *puzzle) SolveOneStep(candidatesIn string, strategies app.PuzzleStrategy) (app.SolveOutcome, error) {
+	candidates, err := p.prepareCandidates(candidatesIn)
+	if err != nil {
+		return app.SolveOutcome{}, errors.WithStack(err)
+	}
+
+	outcome := p.solveOneStepOutcome(candidates, candidates.clone(), strategies)
+	outcome.Candidates = candidates.encode()
+	return outcome, nil
+}
+
+// CountSteps applies the strategy until it is stuck and counts the steps whose pattern is already in the position, so
+// the steps made possible by the previous steps are not counted.
+func (p *puzzle) CountSteps(candidatesIn string, strategy app.PuzzleStrategy) (int, error) {
+	candidates, err := p.prepareCandidates(candidatesIn)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	base, state := candidates.clone(), *p
+	count := 0
+	for {
+		changed, step, contradiction := state.solveOneStep(candidates, candidates.clone(), strategy)
+		if !changed || contradiction != nil {
+			return count, nil
+		}
+		if step.Strategy() == strategy && stepPatternIn(step, base) {
+			count++
 		}
-		p.optimizeCandidates(&candidates)
 	}
-	candidatesBase = candidates.clone()
-	defer func(candidates puzzleCandidates) {
-		candidatesChanges = candidates.encodeOnlyChanges(candidatesBase)
-	}(candidates)
+}
 
-	_, step, err = p.solveOneStep(candidates, candidatesBase, strategies)
+// prepareCandidates decodes candidatesIn or finds simple candidates if
+// candidatesIn is empty.
+func (p *puzzle) prepareCandidates(candidatesIn string) (puzzleCandidates, error) {
+	if candidatesIn == "" {
+		return p.findSimpleCandidates(), nil
+	}
+	candidates, err := decodeCandidates(candidatesIn)
 	if err != nil {
-		return
+		return puzzleCandidates{}, err
 	}
+	p.optimizeCandidates(&candidates)
+	return candidates, nil
+}
 
-	return
+// solveOneStepOutcome checks the state for a contradiction or a solution before
+// applying one step of strategies.
+func (p *puzzle) solveOneStepOutcome(candidates puzzleCandidates, candidatesBase puzzleCandidates, strategies app.PuzzleStrategy) app.SolveOutcome {
+	if contradiction := p.findContradiction(candidates); contradiction != nil {
+		return app.SolveOutcome{Status: app.SolveStatusContradiction, Contradiction: contradiction}
+	}
+	if p.isSolved() {
+		return app.SolveOutcome{Status: app.SolveStatusSolved}
+	}
+	changed, step, contradiction := p.solveOneStep(candidates, candidatesBase, strategies)
+	switch {
+	case contradiction != nil:
+		return app.SolveOutcome{Status: app.SolveStatusContradiction, Contradiction: contradiction}
+	case changed:
+		return app.SolveOutcome{Status: app.SolveStatusProgress, Changed: true, Step: step}
+	default:
+		return app.SolveOutcome{Status: app.SolveStatusStuck}
+	}
 }
 
-func (p *puzzle) solveOneStep(candidates puzzleCandidates, candidatesBase puzzleCandidates, strategies app.PuzzleStrategy) (changed bool, step puzzleStepSetter, err error) {
+func (p *puzzle) solveOneStep(candidates puzzleCandidates, candidatesBase puzzleCandidates, strategies app.PuzzleStrategy) (changed bool, step puzzleStepSetter, contradiction *app.PuzzleContradiction) {
 	makeStep := func(s puzzleStepSetter) {
 		switch s := s.(type) {
 		case *puzzleStepSet:
 			p[s.point.Row][s.point.Col] = s.value
 			candidates.simpleRemoveAfterSet(s.point, s.value)
 		}
-		s.setCandidateChanges(candidates.encodeOnlyChanges(candidatesBase))
+		s.setCandidateChanges(candidates.encodeOnlyChanges(candidatesBase), candidates.eliminations(candidatesBase))
 		step = s
 		changed = true
 	}
@@ -422,7 +560,7 @@ func (p *puzzle) solveOneStep(candidates puzzleCandidates, candidatesBase puzzle
 			case count > 1:
 				return
 			case count == 0:
-				err = errors.Errorf("candidates in %s is emtpy", point1.String())
+				contradiction = contradictionEmptyCell(point1)
 				*stop1 = true
 				return
 			case count == 1:
@@ -436,7 +574,7 @@ func (p *puzzle) solveOneStep(candidates puzzleCandidates, candidatesBase puzzle
 			*stop1 = true
 			return
 		})
-		if changed || err != nil {
+		if changed || contradiction != nil {
 			return
 		}
 	}
@@ -470,10 +608,18 @@ func (p *puzzle) solveOneStep(candidates puzzleCandidates, candidatesBase puzzle
 				if isHiddenSingle == 0 {
 					continue
 				}
+				house := houseOf(app.HouseBox, point1)
+				switch {
+				case isHiddenSingle&0b100 > 0:
+					house = houseOf(app.HouseRow, point1)
+				case isHiddenSingle&0b010 > 0:
+					house = houseOf(app.HouseCol, point1)
+				}
 				makeStep(&puzzleStepSet{
 					strategy: app.StrategyHiddenSingle,
 					point:    point1,
 					value:    candidate,
+					house:    &house,
 				})
 				*stop1 = true
 				return
@@ -571,47 +717,65 @@ func getRandomCountCluesBy(rnd *rand.Rand, level app.PuzzleLevel) int {
 	return (rnd.Int() % (max - min + 1)) + min
 }
 
-func (p *puzzle) GenerateLogic(seed int64, strategies app.PuzzleStrategy) (app.PuzzleStrategy, error) {
+// GenerateLogic removes the clues of the solution while the puzzle is solved by the strategies. The clues are removed
+// by the orbits of the symmetry, so the clues keep it. The generation stops with the error of the context when it is
+// done.
+func (p *puzzle) GenerateLogic(ctx context.Context, seed int64, strategies app.PuzzleStrategy, symmetry app.PuzzleSymmetry) (app.PuzzleStrategy, error) {
 	rnd := rand.New(rand.NewSource(seed))
 	givenStrategies := app.StrategyUnknown
 	limitClues := getRandomCountCluesBy(rnd, strategies.Level())
 	removedClues := 0
+	var removed [size][size]bool
 	for _, point := range getRandomPoints(rnd) {
+		if removed[point.Row][point.Col] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return givenStrategies, errors.WithStack(err)
+		}
 		oneRemoveStrategies := givenStrategies
 		if 81-removedClues <= limitClues {
 			return givenStrategies, nil
 		}
-		digit := p[point.Row][point.Col]
-		p[point.Row][point.Col] = 0
-		removedClues++
+		orbit := symmetryOrbit(symmetry, point)
+		digits := make([]uint8, len(orbit))
+		for i, point := range orbit {
+			digits[i] = p[point.Row][point.Col]
+			p[point.Row][point.Col] = 0
+			removed[point.Row][point.Col] = true
+		}
+		removedClues += len(orbit)
 		revert := func(p *puzzle) {
-			p[point.Row][point.Col] = digit
-			removedClues--
+			for i, point := range orbit {
+				p[point.Row][point.Col] = digits[i]
+			}
+			removedClues -= len(orbit)
 		}
 		candidates := p.findSimpleCandidates()
 		var wg sync.WaitGroup
 		wg.Add(1)
-		var err error
+		var outcome app.SolveOutcome
 		chanSteps := make(chan app.PuzzleStep)
 		solution := p.clone()
 		go func() {
 			defer wg.Done()
-			_, _, err = solution.solve(candidates, chanSteps, strategies)
+			outcome = solution.solve(candidates, chanSteps, strategies)
 		}()
 		for step := range chanSteps {
 			//log.Printf("%010b %+v", oneRemoveStrategies, step)
 			oneRemoveStrategies |= step.Strategy()
 		}
 		wg.Wait()
-		if err != nil {
-			revert(p)
-			continue
-		}
-		if !solution.isSolved() {
+		switch outcome.Status {
+		case app.SolveStatusSolved:
+			givenStrategies = oneRemoveStrategies
+		case app.SolveStatusStuck:
+			// the clue is required by the given strategies
 			revert(p)
-			continue
+		default:
+			// removing a clue from the correct puzzle cannot break it
+			return givenStrategies, errors.Errorf("unexpected solve outcome %q: %s", outcome.Status, outcome.Contradiction)
 		}
-		givenStrategies = oneRemoveStrategies
 	}
 	return givenStrategies, nil
 }
@@ -744,6 +908,116 @@ func (p puzzle) isSolved() bool {
 	return out
 }
 
+// findContradiction returns the first place where the state and its candidates
+// cannot lead to a solution or nil if there is no such place.
+func (p puzzle) findContradiction(c puzzleCandidates) (contradiction *app.PuzzleContradiction) {
+	// a digit is repeated in a house
+	forEachHouse(func(house app.House, points []app.Point, stop *bool) {
+		var placed [size + 1]bool
+		for _, point := range points {
+			val := p[point.Row][point.Col]
+			if val == 0 {
+				continue
+			}
+			if placed[val] {
+				contradiction = contradictionRepeatedDigit(house, val)
+				*stop = true
+				return
+			}
+			placed[val] = true
+		}
+	})
+	if contradiction != nil {
+		return
+	}
+
+	// an empty cell has no candidates
+	p.forEach(func(point app.Point, val uint8, stop *bool) {
+		if val == 0 && c[point.Row][point.Col].len() == 0 {
+			contradiction = contradictionEmptyCell(point)
+			*stop = true
+		}
+	})
+	if contradiction != nil {
+		return
+	}
+
+	// a digit has no place in a house
+	forEachHouse(func(house app.House, points []app.Point, stop *bool) {
+		for digit := uint8(1); digit <= size; digit++ {
+			found := false
+			for _, point := range points {
+				if p[point.Row][point.Col] == digit || (p[point.Row][point.Col] == 0 && c[point.Row][point.Col].has(digit)) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				contradiction = contradictionNoPlace(house, digit)
+				*stop = true
+				return
+			}
+		}
+	})
+	return
+}
+
+func contradictionEmptyCell(point app.Point) *app.PuzzleContradiction {
+	return &app.PuzzleContradiction{
+		Point:  &point,
+		Reason: fmt.Sprintf("cell %s has no candidates", point),
+	}
+}
+
+func contradictionRepeatedDigit(house app.House, digit uint8) *app.PuzzleContradiction {
+	return &app.PuzzleContradiction{
+		House:  &house,
+		Digit:  int8(digit),
+		Reason: fmt.Sprintf("digit %d is repeated in %s", digit, house),
+	}
+}
+
+func contradictionNoPlace(house app.House, digit uint8) *app.PuzzleContradiction {
+	return &app.PuzzleContradiction{
+		House:  &house,
+		Digit:  int8(digit),
+		Reason: fmt.Sprintf("digit %d has no place in %s", digit, house),
+	}
+}
+
+// forEachHouse calls fn for every row, column and box with points of the house.
+func forEachHouse(fn func(house app.House, points []app.Point, stop *bool)) {
+	stop := false
+	for _, typ := range []app.HouseType{app.HouseRow, app.HouseCol, app.HouseBox} {
+		for idx := 0; idx < size; idx++ {
+			if stop {
+				return
+			}
+			house := app.House{Type: typ, Index: idx}
+			fn(house, housePoints(house), &stop)
+		}
+	}
+}
+
+// housePoints returns all points of the house.
+func housePoints(house app.House) []app.Point {
+	points := make([]app.Point, 0, size)
+	for i := 0; i < size; i++ {
+		switch house.Type {
+		case app.HouseRow:
+			points = append(points, app.Point{Row: house.Index, Col: i})
+		case app.HouseCol:
+			points = append(points, app.Point{Row: i, Col: house.Index})
+		case app.HouseBox:
+			points = append(points, app.Point{
+				Row: house.Index/sizeGrp*sizeGrp + i/sizeGrp,
+				Col: house.Index%sizeGrp*sizeGrp + i%sizeGrp,
+			})
+		}
+	}
+	return points
+}
+
 func (p puzzle) GetWrongPoints() (points []app.Point) {
 	pointsUnique := make(map[app.Point]struct{})
 	p.forEach(func(point1 app.Point, val1 uint8, _ *bool) {
@@ -768,6 +1042,28 @@ func (p puzzle) GetWrongPoints() (points []app.Point) {
 	return
 }
 
+func (p puzzle) GetMistakes(solution string, candidates string) (wrongPoints []app.Point, removedCandidates string, err error) {
+	s, err := parse(solution)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to parse solution")
+	}
+	c, err := decodeCandidates(candidates)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	removed := newPuzzleCandidates(false)
+	p.forEach(func(point app.Point, val uint8, _ *bool) {
+		correct := s[point.Row][point.Col]
+		switch {
+		case val > 0 && val != correct:
+			wrongPoints = append(wrongPoints, point)
+		case val == 0 && c[point.Row][point.Col].len() > 0 && !c[point.Row][point.Col].has(correct):
+			removed[point.Row][point.Col].add(correct)
+		}
+	})
+	return wrongPoints, removed.encode(), nil
+}
+
 // Get all puzzle points randomly.
 func getRandomPoints(rnd *rand.Rand) []app.Point {
 	var points []app.Point
@@ -1,10 +1,13 @@
 package sudoku_classic
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/cnblvr/puzzles/app"
+	"github.com/pkg/errors"
 	"math/rand"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -575,7 +578,7 @@ func TestPuzzle_GenerateLogic(t *testing.T) {
 
 	for i := 0; i < 500; i++ {
 		p, seed := SudokuClassic{}.NewRandomSolution()
-		gotStrategies, err := p.GenerateLogic(seed, strategies)
+		gotStrategies, err := p.GenerateLogic(context.Background(), seed, strategies, app.SymmetryNone)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -584,15 +587,82 @@ func TestPuzzle_GenerateLogic(t *testing.T) {
 			strategies, gotStrategies, 81-strings.Count(s, "."),
 			level, gotStrategies.Level(),
 			s)
-		if _, _, err := p.Solve("", nil, strategies); err != nil {
+		outcome, err := p.Solve("", nil, strategies)
+		if err != nil {
 			t.Fatal(err)
 		}
+		if outcome.Status != app.SolveStatusSolved {
+			t.Errorf("Solve() got status = %s, want = %s", outcome.Status, app.SolveStatusSolved)
+		}
 		if wrongs := p.GetWrongPoints(); len(wrongs) > 0 {
 			t.Errorf("is not solved. wrongs: %v", wrongs)
 		}
 	}
 }
 
+func TestPuzzle_GenerateLogic_symmetry(t *testing.T) {
+	const level = app.PuzzleLevelNormal
+	for _, symmetry := range app.PuzzleSymmetries {
+		t.Run(string(symmetry), func(t *testing.T) {
+			for seed := int64(0); seed < 5; seed++ {
+				p := SudokuClassic{}.NewSolutionBySeed(seed).(*puzzle)
+				if _, err := p.GenerateLogic(context.Background(), seed, level.Strategies(), symmetry); err != nil {
+					t.Fatal(err)
+				}
+				p.forEach(func(point app.Point, val uint8, _ *bool) {
+					for _, image := range symmetryOrbit(symmetry, point) {
+						if (p[image.Row][image.Col] > 0) != (val > 0) {
+							t.Errorf("seed %d: cells %s and %s break the symmetry\n%s", seed, point, image, p.String())
+						}
+					}
+				})
+				solution := p.clone()
+				if outcome, err := solution.Solve("", nil, level.Strategies()); err != nil || outcome.Status != app.SolveStatusSolved {
+					t.Errorf("seed %d: Solve() = %s, %v", seed, outcome.Status, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPuzzle_GenerateLogic_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := SudokuClassic{}.NewSolutionBySeed(1)
+	solution := p.String()
+	if _, err := p.GenerateLogic(ctx, 1, app.PuzzleLevelHarder.Strategies(), app.SymmetryNone); !errors.Is(err, context.Canceled) {
+		t.Errorf("GenerateLogic() error = %v, want = %v", err, context.Canceled)
+	}
+	if p.String() != solution {
+		t.Errorf("GenerateLogic() removed clues of the canceled generation\n%s", p.String())
+	}
+}
+
+func TestPuzzle_Symmetry(t *testing.T) {
+	tests := []struct {
+		name string
+		p    string
+		want app.PuzzleSymmetry
+	}{
+		{name: "none", p: "4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......", want: app.SymmetryNone},
+		{name: "rotational", p: "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79", want: app.SymmetryRotational},
+		{name: "rotational 90", p: "1.......2...............................................................3.......4", want: app.SymmetryRotational90},
+		{name: "mirror", p: "1.......2........................................................................", want: app.SymmetryMirror},
+		{name: "diagonal", p: ".1.......2.......................................................................", want: app.SymmetryDiagonal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parse(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Symmetry(); got != tt.want {
+				t.Errorf("Symmetry() = %q, want = %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPuzzle_isSolved(t *testing.T) {
 	p := puzzle{}
 	if p.isSolved() {
@@ -785,16 +855,16 @@ func TestSolve(t *testing.T) {
 			c := p.findSimpleCandidates()
 			chanSteps := make(chan app.PuzzleStep)
 			go func() {
-				changed, cNew, err := p.Solve(c.encode(), chanSteps, app.PuzzleLevelDemon.Strategies())
+				outcome, err := p.Solve(c.encode(), chanSteps, app.PuzzleLevelDemon.Strategies())
 				if err != nil {
 					t.Error(err)
 					return
 				}
-				if !changed {
+				if !outcome.Changed {
 					t.Errorf("solve() is not helped")
 					return
 				}
-				c, err = decodeCandidates(cNew)
+				c, err = decodeCandidates(outcome.Candidates)
 				if err != nil {
 					t.Error(err)
 					return
@@ -820,11 +890,295 @@ func TestPuzzle_SolveOneStep(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	change, step, err := p.SolveOneStep(candidates, app.PuzzleLevelHarder.Strategies())
+	outcome, err := p.SolveOneStep(candidates, app.PuzzleLevelHarder.Strategies())
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Logf("%s\nstrategy: %s\n%s", change, step.Strategy().String(), step.Description())
+	if outcome.Status != app.SolveStatusProgress {
+		t.Fatalf("SolveOneStep() got status = %s, want = %s", outcome.Status, app.SolveStatusProgress)
+	}
+	t.Logf("%s\nstrategy: %s\n%s", outcome.Step.CandidateChanges(), outcome.Step.Strategy().String(), outcome.Step.Description())
+}
+
+func TestPuzzle_SolveOneStepOutcome(t *testing.T) {
+	const solution = "672145398145983672389762451263574819958621743714398526597236184426817935831459267"
+	tests := []struct {
+		name              string
+		p                 string
+		candidates        string
+		strategies        app.PuzzleStrategy
+		wantStatus        app.SolveStatus
+		wantContradiction *app.PuzzleContradiction
+	}{
+		{
+			name:       "solved",
+			p:          solution,
+			strategies: app.PuzzleLevelEasy.Strategies(),
+			wantStatus: app.SolveStatusSolved,
+		},
+		{
+			name:       "progress",
+			p:          "...1.5...14....67..8...24...63.7..1.9.......3.1..9.52...72...8..26....35...4.9...",
+			strategies: app.PuzzleLevelEasy.Strategies(),
+			wantStatus: app.SolveStatusProgress,
+		},
+		{
+			name:       "stuck",
+			p:          "...1.5...14....67..8...24...63.7..1.9.......3.1..9.52...72...8..26....35...4.9...",
+			strategies: app.StrategyXWing,
+			wantStatus: app.SolveStatusStuck,
+		},
+		{
+			name:       "contradiction: repeated digit",
+			p:          "66" + solution[2:],
+			strategies: app.PuzzleLevelEasy.Strategies(),
+			wantStatus: app.SolveStatusContradiction,
+			wantContradiction: &app.PuzzleContradiction{
+				House:  &app.House{Type: app.HouseRow, Index: 0},
+				Digit:  6,
+				Reason: "digit 6 is repeated in row a",
+			},
+		},
+		{
+			name:       "contradiction: empty cell",
+			p:          "." + solution[1:],
+			candidates: `{}`,
+			strategies: app.PuzzleLevelEasy.Strategies(),
+			wantStatus: app.SolveStatusContradiction,
+			wantContradiction: &app.PuzzleContradiction{
+				Point:  &app.Point{Row: 0, Col: 0},
+				Reason: "cell a1 has no candidates",
+			},
+		},
+		{
+			name:       "contradiction: no place for digit",
+			p:          ".." + solution[2:],
+			candidates: `{"base":{"a1":[7],"a2":[7]}}`,
+			strategies: app.PuzzleLevelEasy.Strategies(),
+			wantStatus: app.SolveStatusContradiction,
+			wantContradiction: &app.PuzzleContradiction{
+				House:  &app.House{Type: app.HouseRow, Index: 0},
+				Digit:  6,
+				Reason: "digit 6 has no place in row a",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parse(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outcome, err := p.SolveOneStep(tt.candidates, tt.strategies)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if outcome.Status != tt.wantStatus {
+				t.Errorf("SolveOneStep() got status = %s, want = %s", outcome.Status, tt.wantStatus)
+				return
+			}
+			if !reflect.DeepEqual(outcome.Contradiction, tt.wantContradiction) {
+				t.Errorf("SolveOneStep() got contradiction = %+v, want = %+v", outcome.Contradiction, tt.wantContradiction)
+				return
+			}
+		})
+	}
+}
+
+func TestPuzzle_CountSteps(t *testing.T) {
+	const (
+		clues = "72..96..3...2.5....8...4.2........6.1.65.38.7.4........3.8...9....7.2...2..43..18"
+		state = "72.196..3...285.7..8.374.2....94..6.196523847.4.61.....3.8.1.9....7.2...2..439.18"
+	)
+	tests := []struct {
+		name     string
+		puzzle   string
+		strategy app.PuzzleStrategy
+		want     int
+	}{
+		{name: "naked singles", puzzle: clues, strategy: app.StrategyNakedSingle, want: 5},
+		{name: "hidden singles", puzzle: clues, strategy: app.StrategyHiddenSingle, want: 6},
+		{name: "no naked pair", puzzle: clues, strategy: app.StrategyNakedPair, want: 0},
+		{name: "hidden pairs", puzzle: clues, strategy: app.StrategyHiddenPair, want: 2},
+		{name: "pointing pairs", puzzle: clues, strategy: app.StrategyPointingPair, want: 3},
+		{name: "no naked single", puzzle: state, strategy: app.StrategyNakedSingle, want: 0},
+		{name: "one hidden single", puzzle: state, strategy: app.StrategyHiddenSingle, want: 1},
+		{name: "one naked triple", puzzle: state, strategy: app.StrategyNakedTriple, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parse(tt.puzzle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.CountSteps("", tt.strategy)
+			if err != nil {
+				t.Fatalf("CountSteps() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CountSteps() = %d, want = %d", got, tt.want)
+			}
+			if p.String() != tt.puzzle {
+				t.Errorf("CountSteps() changed the puzzle to %s", p.String())
+			}
+		})
+	}
+}
+
+func TestPuzzle_GetMistakes(t *testing.T) {
+	const solution = "672145398145983672389762451263574819958621743714398526597236184426817935831459267"
+	tests := []struct {
+		name                  string
+		p                     string
+		candidates            string
+		wantWrongPoints       []app.Point
+		wantRemovedCandidates string
+	}{
+		{
+			name:                  "no mistakes",
+			p:                     "...1.5...14....67..8...24...63.7..1.9.......3.1..9.52...72...8..26....35...4.9...",
+			candidates:            `{"base":{"a1":[2,6],"a2":[7,9]}}`,
+			wantRemovedCandidates: `{}`,
+		},
+		{
+			name:                  "wrong digits",
+			p:                     "7..1.5...14....67..8...24...63.7..1.9.......3.1..9.52...72...8..26....35...4.9..1",
+			candidates:            `{}`,
+			wantWrongPoints:       []app.Point{{Row: 0, Col: 0}, {Row: 8, Col: 8}},
+			wantRemovedCandidates: `{}`,
+		},
+		{
+			name:                  "removed correct candidates",
+			p:                     "...1.5...14....67..8...24...63.7..1.9.......3.1..9.52...72...8..26....35...4.9...",
+			candidates:            `{"base":{"a1":[2,3],"a2":[7,9],"a3":[9]}}`,
+			wantRemovedCandidates: `{"base":{"a1":[6],"a3":[2]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parse(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wrongPoints, removedCandidates, err := p.GetMistakes(solution, tt.candidates)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(wrongPoints, tt.wantWrongPoints) {
+				t.Errorf("GetMistakes() got wrong points = %v, want = %v", wrongPoints, tt.wantWrongPoints)
+			}
+			if removedCandidates != tt.wantRemovedCandidates {
+				t.Errorf("GetMistakes() got removed candidates = %s, want = %s", removedCandidates, tt.wantRemovedCandidates)
+			}
+		})
+	}
+}
+
+func TestPuzzleStep_Payload(t *testing.T) {
+	eliminations := []app.StepCandidates{
+		{Point: app.Point{Row: 4, Col: 7}, Digits: []int8{1, 2}},
+		{Point: app.Point{Row: 4, Col: 8}, Digits: []int8{1}},
+	}
+	tests := []struct {
+		name string
+		step puzzleStepSetter
+		want app.PuzzleStepPayload
+	}{
+		{
+			name: "naked single",
+			step: &puzzleStepSet{strategy: app.StrategyNakedSingle, point: app.Point{Row: 4, Col: 7}, value: 1},
+			want: app.PuzzleStepPayload{
+				Houses:       []app.House{{Type: app.HouseRow, Index: 4}, {Type: app.HouseCol, Index: 7}, {Type: app.HouseBox, Index: 5}},
+				Pattern:      []app.Point{{Row: 4, Col: 7}},
+				Digits:       []int8{1},
+				Placements:   []app.StepPlacement{{Point: app.Point{Row: 4, Col: 7}, Digit: 1}},
+				Eliminations: eliminations[1:],
+			},
+		},
+		{
+			name: "hidden single",
+			step: &puzzleStepSet{strategy: app.StrategyHiddenSingle, point: app.Point{Row: 4, Col: 7}, value: 1, house: &app.House{Type: app.HouseCol, Index: 7}},
+			want: app.PuzzleStepPayload{
+				Houses:       []app.House{{Type: app.HouseCol, Index: 7}},
+				Pattern:      []app.Point{{Row: 4, Col: 7}},
+				Digits:       []int8{1},
+				Placements:   []app.StepPlacement{{Point: app.Point{Row: 4, Col: 7}, Digit: 1}},
+				Eliminations: eliminations[1:],
+			},
+		},
+		{
+			name: "naked pair in row and box",
+			step: &puzzleStepNakedStrategy{points: []app.Point{{Row: 2, Col: 0}, {Row: 2, Col: 2}}, set: []uint8{3, 8}},
+			want: app.PuzzleStepPayload{
+				Houses:       []app.House{{Type: app.HouseRow, Index: 2}, {Type: app.HouseBox, Index: 0}},
+				Pattern:      []app.Point{{Row: 2, Col: 0}, {Row: 2, Col: 2}},
+				Digits:       []int8{3, 8},
+				Eliminations: eliminations,
+			},
+		},
+		{
+			name: "pointing pair",
+			step: &puzzleStepPointingStrategy{points: []app.Point{{Row: 3, Col: 6}, {Row: 5, Col: 6}}, value: 2},
+			want: app.PuzzleStepPayload{
+				Houses:       []app.House{{Type: app.HouseCol, Index: 6}, {Type: app.HouseBox, Index: 5}},
+				Pattern:      []app.Point{{Row: 3, Col: 6}, {Row: 5, Col: 6}},
+				Digits:       []int8{2},
+				Eliminations: eliminations,
+			},
+		},
+		{
+			name: "x-wing in columns",
+			step: &puzzleStepXWingStrategy{
+				pairA: []app.Point{{Row: 1, Col: 3}, {Row: 6, Col: 3}},
+				pairB: []app.Point{{Row: 1, Col: 8}, {Row: 6, Col: 8}},
+				value: 4,
+			},
+			want: app.PuzzleStepPayload{
+				Houses:  []app.House{{Type: app.HouseCol, Index: 3}, {Type: app.HouseCol, Index: 8}},
+				Pattern: []app.Point{{Row: 1, Col: 3}, {Row: 6, Col: 3}, {Row: 1, Col: 8}, {Row: 6, Col: 8}},
+				Digits:  []int8{4},
+				Links: []app.StepLink{
+					{From: app.Point{Row: 1, Col: 3}, To: app.Point{Row: 6, Col: 3}, Digit: 4},
+					{From: app.Point{Row: 1, Col: 8}, To: app.Point{Row: 6, Col: 8}, Digit: 4},
+				},
+				Eliminations: eliminations,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.step.setCandidateChanges(`{}`, eliminations)
+			if got := tt.step.Payload(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Payload() got = %+v, want = %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPuzzle_SolveOneStepPayload(t *testing.T) {
+	p, err := parse("...1.5...14....67..8...24...63.7..1.9.......3.1..9.52...72...8..26....35...4.9...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcome, err := p.SolveOneStep("", app.StrategyNakedSingle|app.StrategyHiddenSingle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Status != app.SolveStatusProgress {
+		t.Fatalf("SolveOneStep() got status = %s, want = %s", outcome.Status, app.SolveStatusProgress)
+	}
+	payload := outcome.Step.Payload()
+	if len(payload.Placements) != 1 || !reflect.DeepEqual(payload.Pattern, []app.Point{payload.Placements[0].Point}) {
+		t.Fatalf("Payload() got placements = %v, pattern = %v", payload.Placements, payload.Pattern)
+	}
+	if len(payload.Eliminations) == 0 {
+		t.Errorf("Payload() got no eliminations")
+	}
+	for _, elimination := range payload.Eliminations {
+		if elimination.Point == payload.Placements[0].Point {
+			t.Errorf("Payload() got elimination in the placed cell %s", elimination.Point)
+		}
+	}
 }
 
 // TODO test .SolveOneStep() for all strategies
@@ -837,3 +1191,380 @@ func someErr(errs ...error) error {
 	}
 	return nil
 }
+
+func TestSudokuClassic_ParseClues(t *testing.T) {
+	const clues = "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr error
+	}{
+		{name: "string", in: clues, want: clues},
+		{name: "zeros", in: strings.ReplaceAll(clues, ".", "0"), want: clues},
+		{
+			name: "grid",
+			in: `5 3 . | . 7 . | . . .
+6 . . | 1 9 5 | . . .
+. 9 8 | . . . | . 6 .
+------+-------+------
+8 . . | . 6 . | . . 3
+4 . . | 8 . 3 | . . 1
+7 . . | . 2 . | . . 6
+------+-------+------
+. 6 . | . . . | 2 8 .
+. . . | 4 1 9 | . . 5
+. . . | . 8 . | . 7 9`,
+			want: clues,
+		},
+		{name: "few cells", in: clues[:80], wantErr: app.ErrorPuzzleInvalid},
+		{name: "many cells", in: clues + "1", wantErr: app.ErrorPuzzleInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SudokuClassic{}.ParseClues(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseClues() error = %v, want = %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("ParseClues()\ngot  = %s\nwant = %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPuzzle_FindSolutions(t *testing.T) {
+	tests := []struct {
+		name string
+		p    string
+		want []string
+	}{
+		{
+			name: "unique",
+			p:    "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79",
+			want: []string{"534678912672195348198342567859761423426853791713924856961537284287419635345286179"},
+		},
+		{
+			name: "repeated clue",
+			p:    "55..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79",
+		},
+		{
+			name: "two solutions",
+			p:    "..3456789456789123789123456..4365897365897214897214365531642978642978531978531642",
+			want: []string{
+				"123456789456789123789123456214365897365897214897214365531642978642978531978531642",
+				"213456789456789123789123456124365897365897214897214365531642978642978531978531642",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parse(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := p.FindSolutions(context.Background(), 2)
+			if err != nil {
+				t.Fatalf("FindSolutions() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindSolutions() got = %v, want = %v", got, tt.want)
+			}
+			if p.String() != tt.p {
+				t.Errorf("FindSolutions() changed the puzzle")
+			}
+		})
+	}
+}
+
+func TestPuzzle_FindSolutions_canceled(t *testing.T) {
+	var p puzzle
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the empty grid has too many solutions to find them all
+	if _, err := p.FindSolutions(ctx, 1<<30); !errors.Is(err, context.Canceled) {
+		t.Errorf("FindSolutions() error = %v, want = %v", err, context.Canceled)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	grid := Grid{
+		Name:  "Wikipedia",
+		Clues: "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79",
+	}
+	grid.State = grid.Clues
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			out, err := Export(format, grid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectFormat(out); got != format {
+				t.Fatalf("DetectFormat() = %s, want = %s\n%s", got, format, out)
+			}
+			got, err := Import(format, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format == FormatPencilmarks {
+				// cells with one candidate become placed digits, so the second export must be the same
+				again, err := Export(format, *got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if again != out {
+					t.Errorf("Export()\ngot  =\n%s\nwant =\n%s", again, out)
+				}
+				return
+			}
+			if got.Clues != grid.Clues || got.State != grid.Clues || got.Candidates != "" {
+				t.Errorf("Import() = %+v, want clues %s", got, grid.Clues)
+			}
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	const clues = "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+	tests := []struct {
+		name     string
+		format   Format
+		in       string
+		wantName string
+		wantErr  error
+	}{
+		{name: "line with name", format: FormatLine, in: clues + " Wikipedia", wantName: "Wikipedia"},
+		{name: "line with zeros", format: FormatLine, in: strings.ReplaceAll(clues, ".", "0")},
+		{name: "two lines", format: FormatLine, in: clues + "\n" + clues, wantErr: app.ErrorPuzzleInvalid},
+		{
+			name:   "sdk with state",
+			format: FormatSadMan,
+			in: `[Puzzle]
+#A Author
+#D Wikipedia
+53..7....
+6..195...
+.98....6.
+8...6...3
+4..8.3..1
+7...2...6
+.6....28.
+...419..5
+....8..79
+[State]
+534......`,
+			wantName: "Wikipedia",
+		},
+		{name: "sdk short", format: FormatSadMan, in: "53..7....\n6..195...", wantErr: app.ErrorPuzzleInvalid},
+		{
+			name:   "ss with borders",
+			format: FormatSimpleSudoku,
+			in: `*-----------*
+|53.|.7.|...|
+|6..|195|...|
+|.98|...|.6.|
+|---+---+---|
+|8..|.6.|..3|
+|4..|8.3|..1|
+|7..|.2.|..6|
+|---+---+---|
+|.6.|...|28.|
+|...|419|..5|
+|...|.8.|.79|
+*-----------*`,
+		},
+		{name: "unknown cell", format: FormatSimpleSudoku, in: strings.Repeat("53x|.7.|...\n", 9), wantErr: app.ErrorPuzzleInvalid},
+		{name: "unknown format", format: "txt", in: clues, wantErr: app.ErrorPuzzleInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Import(tt.format, tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Import() error = %v, want = %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Clues != clues || got.Name != tt.wantName {
+				t.Errorf("Import() = %+v, want clues %s and name %q", got, clues, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestImportPencilmarks(t *testing.T) {
+	in := `.----------------.----------------.----------------.
+| 5    3    12   | 6    7    8    | 9    14   24   |
+| 6    7    24   | 1    9    5    | 348  234  248  |
+| 12   9    8    | 3    4    2    | 5    6    7    |
+:----------------+----------------+----------------:
+| 8    5    9    | 7    6    1    | 4    2    3    |
+| 4    2    6    | 8    5    3    | 7    9    1    |
+| 7    1    3    | 9    2    4    | 8    5    6    |
+:----------------+----------------+----------------:
+| 9    6    1    | 5    3    7    | 2    8    4    |
+| 2    8    7    | 4    1    9    | 6    3    5    |
+| 3    4    5    | 2    8    6    | 1    7    9    |
+'----------------'----------------'----------------'`
+	if got := DetectFormat(in); got != FormatPencilmarks {
+		t.Fatalf("DetectFormat() = %s", got)
+	}
+	got, err := Import(FormatPencilmarks, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const wantState = "53.6789..67.195....98342567859761423426853791713924856961537284287419635345286179"
+	if got.State != wantState {
+		t.Errorf("Import() state\ngot  = %s\nwant = %s", got.State, wantState)
+	}
+	candidates, err := decodeCandidates(got.Candidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]uint8{"a3": {1, 2}, "a8": {1, 4}, "a9": {2, 4}, "b3": {2, 4}, "b7": {3, 4, 8}, "b8": {2, 3, 4}, "b9": {2, 4, 8}, "c1": {1, 2}}
+	candidates.forEach(func(point app.Point, cell cellCandidates, _ *bool) {
+		if !reflect.DeepEqual(cell.slice(), want[point.String()]) {
+			t.Errorf("candidates of %s = %v, want = %v", point, cell.slice(), want[point.String()])
+		}
+	})
+}
+
+func TestImportLines(t *testing.T) {
+	in := `# puzzles
+53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79 Wikipedia
+
+4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......`
+	grids, err := ImportLines(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grids) != 2 || grids[0].Name != "Wikipedia" || grids[1].Name != "" {
+		t.Fatalf("ImportLines() = %+v", grids)
+	}
+	out, err := ExportLines(grids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79 Wikipedia
+4.....8.5.3..........7......2.....6.....8.4......1.......6.3.7.5..2.....1.4......
+`
+	if out != want {
+		t.Errorf("ExportLines()\ngot  = %s\nwant = %s", out, want)
+	}
+	if _, err := ImportLines(in + "\n123"); !errors.Is(err, app.ErrorPuzzleInvalid) {
+		t.Errorf("ImportLines() error = %v, want = %v", err, app.ErrorPuzzleInvalid)
+	}
+}
+
+func TestPuzzle_Canonical(t *testing.T) {
+	const clues = "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+	p, err := parse(clues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := p.Canonical()
+	if len(want) != size*size {
+		t.Fatalf("Canonical() = %s", want)
+	}
+	tests := []struct {
+		name string
+		fn   func(p app.PuzzleGenerator) error
+	}{
+		{name: "swapLines", fn: func(p app.PuzzleGenerator) error { return p.SwapLines(app.Vertical, 3, 5) }},
+		{name: "swapBigLines", fn: func(p app.PuzzleGenerator) error { return p.SwapBigLines(app.Horizontal, 0, 2) }},
+		{name: "rotate", fn: func(p app.PuzzleGenerator) error { return p.Rotate(app.RotateTo90) }},
+		{name: "reflect", fn: func(p app.PuzzleGenerator) error { return p.Reflect(app.ReflectMinorDiagonal) }},
+		{name: "swapDigits", fn: func(p app.PuzzleGenerator) error { return p.SwapDigits(1, 9) }},
+		{name: "all", fn: func(p app.PuzzleGenerator) error {
+			if err := p.Rotate(app.RotateTo270); err != nil {
+				return err
+			}
+			if err := p.SwapDigits(2, 5); err != nil {
+				return err
+			}
+			if err := p.SwapLines(app.Horizontal, 6, 8); err != nil {
+				return err
+			}
+			return p.SwapBigLines(app.Vertical, 1, 2)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformed, _ := ParseGenerator(clues)
+			if err := tt.fn(transformed); err != nil {
+				t.Fatal(err)
+			}
+			if transformed.String() == clues {
+				t.Fatal("the puzzle is not transformed")
+			}
+			if got := transformed.Canonical(); got != want {
+				t.Errorf("Canonical()\ngot  = %s\nwant = %s", got, want)
+			}
+		})
+	}
+
+	other, _ := ParseGenerator(strings.Replace(clues, "5", ".", 1))
+	if other.Canonical() == want {
+		t.Errorf("Canonical() of another puzzle = %s", want)
+	}
+}
+
+func TestSudokuClassic_NewRandomTransform(t *testing.T) {
+	const (
+		clues    = "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+		solution = "534678912672195348198342567859761423426853791713924856961537284287419635345286179"
+	)
+	original, _ := ParseGenerator(clues)
+	canonical := original.Canonical()
+	for i := 0; i < 10; i++ {
+		transform := SudokuClassic{}.NewRandomTransform(app.SymmetryNone)
+		p, _ := ParseGenerator(clues)
+		s, _ := ParseGenerator(solution)
+		if err := transform.Apply(p); err != nil {
+			t.Fatal(err)
+		}
+		if err := transform.Apply(s); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := p.FindSolutions(context.Background(), 2); err != nil || len(got) != 1 || got[0] != s.String() {
+			t.Errorf("transform %v: solutions = %v, want = %s", transform, got, s.String())
+		}
+		if p.Canonical() != canonical {
+			t.Errorf("transform %v changes the canonical form", transform)
+		}
+	}
+}
+
+func TestSudokuClassic_NewRandomTransform_Symmetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        string
+		symmetry app.PuzzleSymmetry
+	}{
+		{name: "rotational", p: "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79", symmetry: app.SymmetryRotational},
+		{name: "rotational 90", p: "1.......2..5.............6.............................8.............7..4.......3", symmetry: app.SymmetryRotational90},
+		{name: "mirror", p: "1.......2..3...4........................7.......5.6..............................", symmetry: app.SymmetryMirror},
+		{name: "diagonal", p: ".1.......2.............3.......................4......................5..........", symmetry: app.SymmetryDiagonal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, err := parse(tt.p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !original.isSymmetric(tt.symmetry) {
+				t.Fatalf("the puzzle is not %s", tt.symmetry)
+			}
+			for i := 0; i < 50; i++ {
+				transform := SudokuClassic{}.NewRandomTransform(tt.symmetry)
+				p := *original
+				if err := transform.Apply(&p); err != nil {
+					t.Fatal(err)
+				}
+				if !p.isSymmetric(tt.symmetry) {
+					t.Fatalf("transform %v breaks the symmetry:\n%s", transform, p.String())
+				}
+			}
+		})
+	}
+}
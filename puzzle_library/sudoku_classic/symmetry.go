@@ -0,0 +1,62 @@
+package sudoku_classic
+
+import "github.com/cnblvr/puzzles/app"
+
+// symmetryOrbit returns the cells that the symmetry maps the point to, including the point itself.
+func symmetryOrbit(symmetry app.PuzzleSymmetry, point app.Point) []app.Point {
+	row, col := point.Row, point.Col
+	var images []app.Point
+	switch symmetry {
+	case app.SymmetryRotational:
+		images = []app.Point{{Row: size - 1 - row, Col: size - 1 - col}}
+	case app.SymmetryRotational90:
+		images = []app.Point{
+			{Row: col, Col: size - 1 - row},
+			{Row: size - 1 - row, Col: size - 1 - col},
+			{Row: size - 1 - col, Col: row},
+		}
+	case app.SymmetryMirror:
+		images = []app.Point{{Row: row, Col: size - 1 - col}}
+	case app.SymmetryDiagonal:
+		images = []app.Point{{Row: col, Col: row}}
+	}
+	orbit := []app.Point{point}
+	for _, image := range images {
+		known := false
+		for _, p := range orbit {
+			known = known || p == image
+		}
+		if !known {
+			orbit = append(orbit, image)
+		}
+	}
+	return orbit
+}
+
+// Symmetry returns the strongest symmetry of the cells with clues.
+func (p puzzle) Symmetry() app.PuzzleSymmetry {
+	for _, symmetry := range []app.PuzzleSymmetry{
+		app.SymmetryRotational90, app.SymmetryRotational, app.SymmetryMirror, app.SymmetryDiagonal,
+	} {
+		if p.isSymmetric(symmetry) {
+			return symmetry
+		}
+	}
+	return app.SymmetryNone
+}
+
+// isSymmetric reports whether the cells with clues have the symmetry. A puzzle with a stronger symmetry may also have
+// the weaker one.
+func (p puzzle) isSymmetric(symmetry app.PuzzleSymmetry) bool {
+	symmetric := true
+	p.forEach(func(point app.Point, val uint8, stop *bool) {
+		for _, image := range symmetryOrbit(symmetry, point) {
+			if (p[image.Row][image.Col] > 0) != (val > 0) {
+				symmetric = false
+				*stop = true
+				return
+			}
+		}
+	})
+	return symmetric
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"time"
+)
+
+func (r *redisRepository) GetDailyPuzzle(ctx context.Context, date string, typ app.PuzzleType, level app.PuzzleLevel) (*app.Puzzle, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	puzzle, err := r.getDailyPuzzle(ctx, conn, date, typ, level)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return puzzle, nil
+}
+
+func (r *redisRepository) CreateDailyPuzzleGame(ctx context.Context, params app.CreateDailyPuzzleGameParams) (*app.Puzzle, *app.PuzzleGame, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	if params.Session == nil {
+		return nil, nil, errors.Errorf("params.Session is nil")
+	}
+
+	puzzle, err := r.getDailyPuzzle(ctx, conn, params.Date, params.Type, params.Level)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	player := r.player(params.Session.UserID, params.Session.SessionID)
+	game := r.newPuzzleGame(params.Session, puzzle)
+	game.ID = r.generateDailyPuzzleGameID(params.Date, params.Type, params.Level, player)
+	game.Daily = params.Date
+
+	isNew, err := redis.Bool(conn.Do("HSETNX", r.keyDailyPuzzleGames(params.Date, params.Type, params.Level),
+		player, game.ID.String()))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to register daily puzzle game")
+	}
+	if !isNew {
+		game, err = r.getPuzzleGame(ctx, conn, game.ID)
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+		return puzzle, game, nil
+	}
+	if err := r.createPuzzleGame(ctx, conn, game); err != nil {
+		// the registration without the game would return the missing game on every next try
+		if _, errDel := conn.Do("HDEL", r.keyDailyPuzzleGames(params.Date, params.Type, params.Level), player); errDel != nil {
+			return nil, nil, errors.Wrapf(err, "failed to create daily puzzle game (and to unregister it: %v)", errDel)
+		}
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return puzzle, game, nil
+}
+
+func (r *redisRepository) GetDailyLeaderboard(ctx context.Context, date string, typ app.PuzzleType, level app.PuzzleLevel, limit int) ([]app.DailyLeaderboardEntry, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	values, err := redis.Values(conn.Do("ZRANGE", r.keyDailyLeaderboard(date, typ, level), 0, limit-1, "WITHSCORES"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get daily leaderboard")
+	}
+	var pairs []struct {
+		UserID  int64
+		Elapsed int64
+	}
+	if err := redis.ScanSlice(values, &pairs); err != nil {
+		return nil, errors.Wrap(err, "failed to scan daily leaderboard")
+	}
+	entries := make([]app.DailyLeaderboardEntry, 0, len(pairs))
+	for i, pair := range pairs {
+		entries = append(entries, app.DailyLeaderboardEntry{
+			Rank:    i + 1,
+			UserID:  pair.UserID,
+			Elapsed: time.Duration(pair.Elapsed) * time.Millisecond,
+		})
+	}
+
+	return entries, nil
+}
+
+func (r *redisRepository) GetUserDailyStreak(ctx context.Context, userID int64) (*app.DailyStreak, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	streak, err := r.getUserDailyStreak(ctx, conn, userID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return streak, nil
+}
+
+// Errors: app.ErrorPuzzlePoolEmpty, app.ErrorPuzzleNotFound, unknown.
+func (r *redisRepository) getDailyPuzzle(ctx context.Context, conn redis.Conn, date string, typ app.PuzzleType, level app.PuzzleLevel) (*app.Puzzle, error) {
+	key := r.keyDailyPuzzle(date, typ, level)
+	puzzleID, err := redis.Int64(conn.Do("GET", key))
+	switch err {
+	case nil:
+		return r.getPuzzle(ctx, conn, puzzleID)
+	case redis.ErrNil:
+	default:
+		return nil, errors.Wrap(err, "failed to get daily puzzle id")
+	}
+
+	// The pool grows during the day, so the picked puzzle is pinned for the date.
+	keyPool := r.keyPuzzleByTypeAndLevel(typ, level)
+	size, err := redis.Int64(conn.Do("SCARD", keyPool))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get size of puzzle pool")
+	}
+	if size == 0 {
+		return nil, errors.WithStack(app.ErrorPuzzlePoolEmpty)
+	}
+	ids, err := redis.Int64s(conn.Do("SORT", keyPool, "LIMIT", app.DailySeed(date, typ, level)%size, 1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to pick daily puzzle id")
+	}
+	// the pool has shrunk since SCARD
+	if len(ids) == 0 {
+		return nil, errors.WithStack(app.ErrorPuzzlePoolEmpty)
+	}
+	if _, err := conn.Do("SETNX", key, ids[0]); err != nil {
+		return nil, errors.Wrap(err, "failed to pin daily puzzle id")
+	}
+	// Another instance may have pinned the puzzle first.
+	puzzleID, err = redis.Int64(conn.Do("GET", key))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get daily puzzle id")
+	}
+
+	return r.getPuzzle(ctx, conn, puzzleID)
+}
+
+// finishDailyPuzzleGame adds the won daily game of a user to the leaderboard and the streak of the user.
+//
+// Errors: unknown.
+func (r *redisRepository) finishDailyPuzzleGame(ctx context.Context, conn redis.Conn, game *app.PuzzleGame) error {
+	if _, err := conn.Do("ZADD", r.keyDailyLeaderboard(game.Daily, game.PuzzleType, game.PuzzleLevel), "NX",
+		game.Elapsed.Milliseconds(), game.UserID); err != nil {
+		return errors.Wrap(err, "failed to add to daily leaderboard")
+	}
+
+	streak, err := r.getUserDailyStreak(ctx, conn, game.UserID)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	streak.Add(game.Daily)
+	if _, err := conn.Do("HSET", redis.Args{}.Add(r.keyUserDailyStreak(game.UserID)).AddFlat(streak)...); err != nil {
+		return errors.Wrap(err, "failed to set daily streak")
+	}
+
+	return nil
+}
+
+// Errors: unknown.
+func (r *redisRepository) getUserDailyStreak(ctx context.Context, conn redis.Conn, userID int64) (*app.DailyStreak, error) {
+	streakReply, err := redis.Values(conn.Do("HGETALL", r.keyUserDailyStreak(userID)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get daily streak")
+	}
+	streak := &app.DailyStreak{}
+	if err := redis.ScanStruct(streakReply, streak); err != nil {
+		return nil, errors.Wrap(err, "failed to scan daily streak")
+	}
+	streak.UserID = userID
+
+	return streak, nil
+}
+
+func (r *redisRepository) generateDailyPuzzleGameID(date string, typ app.PuzzleType, level app.PuzzleLevel, player string) uuid.UUID {
+	return uuid.NewSHA1(uuidPuzzleGameSpace, []byte("daily:"+date+":"+typ.String()+":"+level.String()+":"+player))
+}
+
+// keyDailyPuzzle returns a key to the identifier of the daily puzzle.
+func (r *redisRepository) keyDailyPuzzle(date string, typ app.PuzzleType, level app.PuzzleLevel) string {
+	return fmt.Sprintf("daily:%s:%s:%s", date, typ.String(), level.String())
+}
+
+// keyDailyPuzzleGames returns a key to the games of the daily puzzle.
+// The value type is a hash of game identifiers by players.
+func (r *redisRepository) keyDailyPuzzleGames(date string, typ app.PuzzleType, level app.PuzzleLevel) string {
+	return fmt.Sprintf("%s:games", r.keyDailyPuzzle(date, typ, level))
+}
+
+// keyDailyLeaderboard returns a key to the leaderboard of the daily puzzle.
+// The value type is a sorted set of user identifiers by the solve time in milliseconds.
+func (r *redisRepository) keyDailyLeaderboard(date string, typ app.PuzzleType, level app.PuzzleLevel) string {
+	return fmt.Sprintf("%s:leaderboard", r.keyDailyPuzzle(date, typ, level))
+}
+
+// keyUserDailyStreak returns a key to the daily streak of the user.
+func (r *redisRepository) keyUserDailyStreak(id int64) string {
+	return fmt.Sprintf("%s:daily_streak", r.keyUser(id))
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (r *redisRepository) GetRandomPuzzle(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel, practice *app.StrategyTarget) (*app.Puzzle, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	var (
+		puzzleID int64
+		err      = redis.ErrNil
+	)
+	if practice != nil {
+		puzzleID, err = redis.Int64(conn.Do("SRANDMEMBER", r.keyPuzzlePracticeByType(typ, practice.String())))
+	}
+	if err == redis.ErrNil {
+		puzzleID, err = redis.Int64(conn.Do("SRANDMEMBER", r.keyPuzzleByTypeAndLevel(typ, level)))
+	}
+	switch err {
+	case redis.ErrNil:
+		return nil, errors.WithStack(app.ErrorPuzzlePoolEmpty)
+	case nil:
+	default:
+		return nil, errors.Wrap(err, "failed to get random puzzle id")
+	}
+
+	puzzle, err := r.getPuzzle(ctx, conn, puzzleID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return puzzle, nil
+}
+
+func (r *redisRepository) CreateDrill(ctx context.Context, params app.CreateDrillParams) (*app.Drill, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	if params.Session == nil {
+		return nil, errors.Errorf("params.Session is nil")
+	}
+
+	drill := &app.Drill{
+		ID:            uuid.New(),
+		SessionID:     params.Session.SessionID,
+		UserID:        params.Session.UserID,
+		DrillPosition: params.DrillPosition,
+		CreatedAt:     app.DateTime{Time: time.Now()},
+	}
+	key := r.keyDrill(drill.ID)
+	if _, err := conn.Do("HSET", redis.Args{}.Add(key).AddFlat(drill)...); err != nil {
+		return nil, errors.Wrap(err, "failed to set drill")
+	}
+	if _, err := conn.Do("EXPIRE", key, int64(app.DefaultDrillRetention.Seconds())); err != nil {
+		return nil, errors.Wrap(err, "failed to set expiration for drill")
+	}
+
+	return drill, nil
+}
+
+func (r *redisRepository) GetDrill(ctx context.Context, id uuid.UUID) (*app.Drill, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	drillReply, err := redis.Values(conn.Do("HGETALL", r.keyDrill(id)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get drill")
+	}
+	if len(drillReply) == 0 {
+		return nil, errors.WithStack(app.ErrorDrillNotFound)
+	}
+	drill := &app.Drill{}
+	if err := redis.ScanStruct(drillReply, drill); err != nil {
+		return nil, errors.Wrap(err, "failed to scan drill")
+	}
+	drill.ID = id
+
+	return drill, nil
+}
+
+func (r *redisRepository) AnswerDrill(ctx context.Context, drill *app.Drill, correct bool) error {
+	conn := r.connect()
+	defer conn.Close()
+
+	answers, err := redis.Int(conn.Do("HINCRBY", r.keyDrill(drill.ID), "answers", 1))
+	if err != nil {
+		return errors.Wrap(err, "failed to count drill answer")
+	}
+	drill.Answers = answers
+	if answers > 1 {
+		return errors.WithStack(app.ErrorDrillAnswered)
+	}
+	drill.Correct = correct
+	if _, err := conn.Do("HSET", r.keyDrill(drill.ID), "correct", correct); err != nil {
+		return errors.Wrap(err, "failed to set drill answer")
+	}
+
+	if drill.UserID > 0 {
+		strategy := strconv.FormatUint(uint64(drill.Strategy), 10)
+		if _, err := conn.Do("HINCRBY", r.keyUserDrillStats(drill.UserID), strategy+":attempts", 1); err != nil {
+			return errors.Wrap(err, "failed to count drill attempt")
+		}
+		if correct {
+			if _, err := conn.Do("HINCRBY", r.keyUserDrillStats(drill.UserID), strategy+":correct", 1); err != nil {
+				return errors.Wrap(err, "failed to count correct drill")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *redisRepository) GetUserDrillStats(ctx context.Context, userID int64) ([]app.DrillStats, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	counters, err := redis.IntMap(conn.Do("HGETALL", r.keyUserDrillStats(userID)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get drill stats")
+	}
+	byStrategy := make(map[app.PuzzleStrategy]*app.DrillStats)
+	for field, count := range counters {
+		idx := strings.LastIndex(field, ":")
+		if idx < 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(field[:idx], 10, 64)
+		if err != nil {
+			continue
+		}
+		strategy := app.PuzzleStrategy(n)
+		stats, ok := byStrategy[strategy]
+		if !ok {
+			stats = &app.DrillStats{Strategy: strategy}
+			byStrategy[strategy] = stats
+		}
+		switch field[idx+1:] {
+		case "attempts":
+			stats.Attempts = count
+		case "correct":
+			stats.Correct = count
+		}
+	}
+	out := make([]app.DrillStats, 0, len(byStrategy))
+	for strategy := app.StrategyNakedSingle; strategy <= app.PuzzleLevelDemon.Strategies(); strategy <<= 1 {
+		if stats, ok := byStrategy[strategy]; ok {
+			out = append(out, *stats)
+		}
+	}
+
+	return out, nil
+}
+
+// keyDrill returns a key to the drill.
+// The value type is a hash for app.Drill structure.
+func (r *redisRepository) keyDrill(id uuid.UUID) string {
+	return fmt.Sprintf("drill:%s", id.String())
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"sync"
+	"time"
+)
+
+// eventReconnectDelay is the delay between attempts to restore a broken subscription.
+const eventReconnectDelay = time.Second
+
+func NewRedisEventRepository(dial func() (redis.Conn, error), debug bool) (app.EventRepository, error) {
+	return newRedisRepository(dial, debug)
+}
+
+func (r *redisRepository) PublishEvent(ctx context.Context, event app.Event) error {
+	conn := r.connect()
+	defer conn.Close()
+
+	bts, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal event")
+	}
+	if _, err := conn.Do("PUBLISH", r.keyEvents(event.Topic), bts); err != nil {
+		return errors.Wrap(err, "failed to publish event")
+	}
+
+	return nil
+}
+
+func (r *redisRepository) SubscribeEvents(ctx context.Context) (app.EventSubscription, error) {
+	s := &redisEventSubscription{
+		repository: r,
+		topics:     make(map[string]struct{}),
+		events:     make(chan app.Event, 256),
+		done:       make(chan struct{}),
+	}
+	if err := s.connect(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	go s.receive()
+
+	return s, nil
+}
+
+// redisEventSubscription is a subscription of an instance on its own connection. The connection is restored with
+// all topics of the subscription if it is broken.
+type redisEventSubscription struct {
+	repository *redisRepository
+	// mx guards psc for writes and topics. psc is read only by the receiving goroutine.
+	mx        sync.Mutex
+	psc       redis.PubSubConn
+	topics    map[string]struct{}
+	events    chan app.Event
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *redisEventSubscription) Subscribe(topics ...string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	channels := make([]interface{}, 0, len(topics))
+	for _, topic := range topics {
+		s.topics[topic] = struct{}{}
+		channels = append(channels, s.repository.keyEvents(topic))
+	}
+	if err := s.psc.Subscribe(channels...); err != nil {
+		return errors.Wrap(err, "failed to subscribe to events")
+	}
+	return nil
+}
+
+func (s *redisEventSubscription) Unsubscribe(topics ...string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	channels := make([]interface{}, 0, len(topics))
+	for _, topic := range topics {
+		delete(s.topics, topic)
+		channels = append(channels, s.repository.keyEvents(topic))
+	}
+	if err := s.psc.Unsubscribe(channels...); err != nil {
+		return errors.Wrap(err, "failed to unsubscribe from events")
+	}
+	return nil
+}
+
+func (s *redisEventSubscription) Events() <-chan app.Event {
+	return s.events
+}
+
+func (s *redisEventSubscription) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.mx.Lock()
+		defer s.mx.Unlock()
+		s.psc.Close()
+	})
+	return nil
+}
+
+// connect opens a new connection and subscribes it to all topics. s.mx must be held after the start.
+//
+// Errors: unknown.
+func (s *redisEventSubscription) connect() error {
+	conn := s.repository.pool.Get()
+	if err := conn.Err(); err != nil {
+		conn.Close()
+		return errors.Wrap(err, "failed to connect for events")
+	}
+	s.psc = redis.PubSubConn{Conn: conn}
+	if len(s.topics) == 0 {
+		return nil
+	}
+	channels := make([]interface{}, 0, len(s.topics))
+	for topic := range s.topics {
+		channels = append(channels, s.repository.keyEvents(topic))
+	}
+	if err := s.psc.Subscribe(channels...); err != nil {
+		s.psc.Close()
+		return errors.Wrap(err, "failed to subscribe to events")
+	}
+	return nil
+}
+
+// receive passes the received events to s.events until the subscription is closed.
+func (s *redisEventSubscription) receive() {
+	defer close(s.events)
+	for {
+		s.mx.Lock()
+		psc := s.psc
+		s.mx.Unlock()
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			var event app.Event
+			if err := json.Unmarshal(v.Data, &event); err != nil {
+				log.Warn().Err(err).Str("channel", v.Channel).Msg("failed to unmarshal event")
+				continue
+			}
+			select {
+			case s.events <- event:
+			case <-s.done:
+				return
+			}
+		case error:
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			log.Error().Err(v).Msg("event subscription is broken")
+			psc.Close()
+			if !s.reconnect() {
+				return
+			}
+		}
+	}
+}
+
+// reconnect restores the connection of the subscription. It returns false if the subscription is closed.
+func (s *redisEventSubscription) reconnect() bool {
+	for {
+		select {
+		case <-s.done:
+			return false
+		case <-time.After(eventReconnectDelay):
+		}
+		s.mx.Lock()
+		err := s.connect()
+		s.mx.Unlock()
+		if err == nil {
+			return true
+		}
+		log.Error().Err(err).Msg("failed to restore event subscription")
+	}
+}
+
+// keyEvents returns a channel of the events of the topic.
+func (r *redisRepository) keyEvents(topic string) string {
+	return fmt.Sprintf("events:%s", topic)
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// generateJobsMaxLen is the approximate number of entries kept in the stream of the jobs. The acknowledged jobs are
+// deleted, so the limit only guards against a queue without generators.
+const generateJobsMaxLen = 1000
+
+func NewRedisGenerateJobRepository(dial func() (redis.Conn, error), debug bool) (app.GenerateJobRepository, error) {
+	return newRedisRepository(dial, debug)
+}
+
+func (r *redisRepository) PushGenerateJob(ctx context.Context, job app.GenerateJob) (*app.GenerateJob, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	keyQueued := r.keyGenerateJobQueued(job.Pool())
+	_, err := redis.String(conn.Do("SET", keyQueued, 1, "NX", "EX", int64(app.DefaultGenerateJobTTL.Seconds())))
+	switch err {
+	case redis.ErrNil:
+		return nil, errors.WithStack(app.ErrorGenerateJobQueued)
+	case nil:
+	default:
+		return nil, errors.Wrap(err, "failed to mark generate job as queued")
+	}
+
+	job.CreatedAt = app.DateTime{Time: time.Now()}
+	job.ID, err = redis.String(conn.Do("XADD", redis.Args{}.Add(r.keyGenerateJobs(), "MAXLEN", "~", generateJobsMaxLen, "*").
+		AddFlat(job)...))
+	if err != nil {
+		if _, errDel := conn.Do("DEL", keyQueued); errDel != nil {
+			return nil, errors.Wrapf(err, "failed to add generate job (and to unmark it: %v)", errDel)
+		}
+		return nil, errors.Wrap(err, "failed to add generate job")
+	}
+
+	return &job, nil
+}
+
+func (r *redisRepository) ReadGenerateJob(ctx context.Context, consumer string, timeout time.Duration) (*app.GenerateJob, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	if err := r.createGenerateJobGroup(conn); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// the jobs of stopped consumers are retried first
+	job, err := r.claimGenerateJob(conn, consumer)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if job != nil {
+		return job, nil
+	}
+
+	streams, err := redis.Values(conn.Do("XREADGROUP", "GROUP", generateJobsGroup, consumer,
+		"COUNT", 1, "BLOCK", timeout.Milliseconds(), "STREAMS", r.keyGenerateJobs(), ">"))
+	switch err {
+	case redis.ErrNil:
+		return nil, errors.WithStack(app.ErrorGenerateJobNone)
+	case nil:
+	default:
+		return nil, errors.Wrap(err, "failed to read generate job")
+	}
+	for _, stream := range streams {
+		var (
+			key     string
+			entries []interface{}
+		)
+		values, err := redis.Values(stream, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get stream of generate jobs")
+		}
+		if _, err := redis.Scan(values, &key, &entries); err != nil {
+			return nil, errors.Wrap(err, "failed to scan stream of generate jobs")
+		}
+		for _, entry := range entries {
+			job, err := r.scanGenerateJob(entry)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			job.Deliveries = 1
+			return job, nil
+		}
+	}
+	return nil, errors.WithStack(app.ErrorGenerateJobNone)
+}
+
+// claimGenerateJob moves the oldest job that is not acknowledged for app.DefaultGenerateJobClaimIdle to the
+// consumer. It returns nil if there is no such job.
+func (r *redisRepository) claimGenerateJob(conn redis.Conn, consumer string) (*app.GenerateJob, error) {
+	minIdle := app.DefaultGenerateJobClaimIdle.Milliseconds()
+	for {
+		pending, err := redis.Values(conn.Do("XPENDING", r.keyGenerateJobs(), generateJobsGroup,
+			"IDLE", minIdle, "-", "+", 1))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get pending generate jobs")
+		}
+		if len(pending) == 0 {
+			return nil, nil
+		}
+		var (
+			id, owner        string
+			idle, deliveries int64
+		)
+		values, err := redis.Values(pending[0], nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get pending generate job")
+		}
+		if _, err := redis.Scan(values, &id, &owner, &idle, &deliveries); err != nil {
+			return nil, errors.Wrap(err, "failed to scan pending generate job")
+		}
+
+		entries, err := redis.Values(conn.Do("XCLAIM", r.keyGenerateJobs(), generateJobsGroup, consumer, minIdle, id))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to claim generate job")
+		}
+		// another consumer claimed the job first
+		if len(entries) == 0 {
+			return nil, nil
+		}
+		// the entry is trimmed from the stream, so it is dropped
+		if entries[0] == nil {
+			if _, err := conn.Do("XACK", r.keyGenerateJobs(), generateJobsGroup, id); err != nil {
+				return nil, errors.Wrap(err, "failed to acknowledge trimmed generate job")
+			}
+			continue
+		}
+		job, err := r.scanGenerateJob(entries[0])
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		job.Deliveries = int(deliveries) + 1
+		job.Done, err = redis.Int(conn.Do("GET", r.keyGenerateJobDone(job.ID)))
+		if err != nil && err != redis.ErrNil {
+			return nil, errors.Wrap(err, "failed to get progress of generate job")
+		}
+		return job, nil
+	}
+}
+
+func (r *redisRepository) TouchGenerateJob(ctx context.Context, consumer string, job *app.GenerateJob) error {
+	conn := r.connect()
+	defer conn.Close()
+
+	// JUSTID resets the idle time without counting a delivery
+	if _, err := conn.Do("XCLAIM", r.keyGenerateJobs(), generateJobsGroup, consumer, 0, job.ID, "JUSTID"); err != nil {
+		return errors.Wrap(err, "failed to touch generate job")
+	}
+	if _, err := conn.Do("EXPIRE", r.keyGenerateJobQueued(job.Pool()), int64(app.DefaultGenerateJobTTL.Seconds())); err != nil {
+		return errors.Wrap(err, "failed to prolong queued mark of generate job")
+	}
+
+	return nil
+}
+
+func (r *redisRepository) AddGenerateJobDone(ctx context.Context, job *app.GenerateJob, done int) error {
+	conn := r.connect()
+	defer conn.Close()
+
+	keyDone := r.keyGenerateJobDone(job.ID)
+	if _, err := conn.Do("INCRBY", keyDone, done); err != nil {
+		return errors.Wrap(err, "failed to add progress of generate job")
+	}
+	if _, err := conn.Do("EXPIRE", keyDone, int64(app.DefaultGenerateJobTTL.Seconds())); err != nil {
+		return errors.Wrap(err, "failed to set expiration of progress of generate job")
+	}
+
+	return nil
+}
+
+func (r *redisRepository) AckGenerateJob(ctx context.Context, job *app.GenerateJob) error {
+	conn := r.connect()
+	defer conn.Close()
+
+	if _, err := conn.Do("XACK", r.keyGenerateJobs(), generateJobsGroup, job.ID); err != nil {
+		return errors.Wrap(err, "failed to acknowledge generate job")
+	}
+	if _, err := conn.Do("XDEL", r.keyGenerateJobs(), job.ID); err != nil {
+		return errors.Wrap(err, "failed to delete generate job")
+	}
+	if _, err := conn.Do("DEL", r.keyGenerateJobQueued(job.Pool()), r.keyGenerateJobDone(job.ID)); err != nil {
+		return errors.Wrap(err, "failed to unmark generate job")
+	}
+
+	return nil
+}
+
+// createGenerateJobGroup creates the stream and the group of the generators if they don't exist. The group reads the
+// jobs added before its creation.
+func (r *redisRepository) createGenerateJobGroup(conn redis.Conn) error {
+	_, err := conn.Do("XGROUP", "CREATE", r.keyGenerateJobs(), generateJobsGroup, "0", "MKSTREAM")
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return errors.Wrap(err, "failed to create group of generate jobs")
+	}
+	return nil
+}
+
+// scanGenerateJob decodes the stream entry of the job: the identifier and the list of fields and values.
+func (r *redisRepository) scanGenerateJob(entry interface{}) (*app.GenerateJob, error) {
+	var (
+		id     string
+		fields []interface{}
+	)
+	values, err := redis.Values(entry, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get entry of generate job")
+	}
+	if _, err := redis.Scan(values, &id, &fields); err != nil {
+		return nil, errors.Wrap(err, "failed to scan entry of generate job")
+	}
+	job := &app.GenerateJob{}
+	if err := redis.ScanStruct(fields, job); err != nil {
+		return nil, errors.Wrap(err, "failed to scan generate job")
+	}
+	job.ID = id
+	return job, nil
+}
+
+// generateJobsGroup is the consumer group of all generators. Each job is read by one generator.
+const generateJobsGroup = "generators"
+
+// keyGenerateJobs returns a key to the queue of the generate jobs.
+// The value type is a stream of app.GenerateJob structures.
+func (r *redisRepository) keyGenerateJobs() string {
+	return "generate_jobs"
+}
+
+// keyGenerateJobQueued returns a key to the mark of the queued job of the pool.
+// The value type is an integer.
+func (r *redisRepository) keyGenerateJobQueued(pool string) string {
+	return "generate_job_queued:" + pool
+}
+
+// keyGenerateJobDone returns a key to the number of the puzzles generated by the job.
+// The value type is an integer.
+func (r *redisRepository) keyGenerateJobDone(id string) string {
+	return "generate_job_done:" + id
+}
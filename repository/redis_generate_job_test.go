@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestRedisRepository connects to the database REDIS_TEST_DB of REDIS_ADDRESS. The test is skipped without them.
+// The database must be dedicated to the tests, because the keys of the tests are deleted.
+func newTestRedisRepository(t *testing.T, keys ...string) *redisRepository {
+	address, ok := os.LookupEnv("REDIS_ADDRESS")
+	if !ok {
+		t.Skip("REDIS_ADDRESS is not set")
+	}
+	db, err := strconv.Atoi(os.Getenv("REDIS_TEST_DB"))
+	if err != nil {
+		t.Skip("REDIS_TEST_DB is not set")
+	}
+	r, err := newRedisRepository(func() (redis.Conn, error) {
+		return redis.Dial("tcp", address,
+			redis.DialPassword(os.Getenv("REDIS_PASSWORD")),
+			redis.DialDatabase(db),
+		)
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clean := func() {
+		conn := r.connect()
+		defer conn.Close()
+		if _, err := conn.Do("DEL", redis.Args{}.AddFlat(keys)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clean()
+	t.Cleanup(clean)
+	return r
+}
+
+func TestRedisRepository_GenerateJob(t *testing.T) {
+	ctx := context.Background()
+	jobLevel := app.GenerateJob{Type: app.PuzzleSudokuClassic, Level: app.PuzzleLevelHard, Count: 5, Reason: app.GenerateJobReasonEmpty}
+	jobPractice := app.GenerateJob{Type: app.PuzzleSudokuClassic, Practice: "x-wing:1", Count: 3, Reason: app.GenerateJobReasonSchedule}
+	r := newTestRedisRepository(t, "generate_jobs",
+		"generate_job_queued:"+jobLevel.Pool(), "generate_job_queued:"+jobPractice.Pool())
+
+	pushed, err := r.PushGenerateJob(ctx, jobLevel)
+	if err != nil {
+		t.Fatalf("PushGenerateJob() error = %v", err)
+	}
+	if _, err := r.PushGenerateJob(ctx, jobLevel); !errors.Is(err, app.ErrorGenerateJobQueued) {
+		t.Fatalf("PushGenerateJob() of the queued pool error = %v, want = %v", err, app.ErrorGenerateJobQueued)
+	}
+	if _, err := r.PushGenerateJob(ctx, jobPractice); err != nil {
+		t.Fatalf("PushGenerateJob() of other pool error = %v", err)
+	}
+
+	read, err := r.ReadGenerateJob(ctx, "test", time.Second)
+	if err != nil {
+		t.Fatalf("ReadGenerateJob() error = %v", err)
+	}
+	if read.ID != pushed.ID || read.Pool() != jobLevel.Pool() || read.Count != jobLevel.Count ||
+		read.Reason != jobLevel.Reason || read.Deliveries != 1 || read.Done != 0 {
+		t.Errorf("ReadGenerateJob() = %+v, want = %+v", read, pushed)
+	}
+
+	if err := r.AddGenerateJobDone(ctx, read, 2); err != nil {
+		t.Fatalf("AddGenerateJobDone() error = %v", err)
+	}
+	conn := r.connect()
+	done, err := redis.Int(conn.Do("GET", r.keyGenerateJobDone(read.ID)))
+	conn.Close()
+	if err != nil || done != 2 {
+		t.Errorf("progress of the job = %d (%v), want = 2", done, err)
+	}
+
+	if err := r.AckGenerateJob(ctx, read); err != nil {
+		t.Fatalf("AckGenerateJob() error = %v", err)
+	}
+	conn = r.connect()
+	exists, err := redis.Int(conn.Do("EXISTS", r.keyGenerateJobDone(read.ID)))
+	conn.Close()
+	if err != nil || exists != 0 {
+		t.Errorf("progress of the acknowledged job exists = %d (%v), want = 0", exists, err)
+	}
+	if _, err := r.PushGenerateJob(ctx, jobLevel); err != nil {
+		t.Fatalf("PushGenerateJob() of the acknowledged pool error = %v", err)
+	}
+
+	for _, want := range []app.GenerateJob{jobPractice, jobLevel} {
+		read, err := r.ReadGenerateJob(ctx, "test", time.Second)
+		if err != nil {
+			t.Fatalf("ReadGenerateJob() error = %v", err)
+		}
+		if read.Pool() != want.Pool() {
+			t.Errorf("ReadGenerateJob() got pool %s, want = %s", read.Pool(), want.Pool())
+		}
+		if err := r.AckGenerateJob(ctx, read); err != nil {
+			t.Fatalf("AckGenerateJob() error = %v", err)
+		}
+	}
+	if _, err := r.ReadGenerateJob(ctx, "test", 100*time.Millisecond); !errors.Is(err, app.ErrorGenerateJobNone) {
+		t.Errorf("ReadGenerateJob() of the empty queue error = %v, want = %v", err, app.ErrorGenerateJobNone)
+	}
+}
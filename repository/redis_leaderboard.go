@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (r *redisRepository) GetLeaderboard(ctx context.Context, board app.Leaderboard, limit int) ([]app.LeaderboardEntry, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	// The fastest solve is the lowest score, the most solved puzzles is the highest one.
+	command := "ZRANGE"
+	if board.Kind == app.LeaderboardSolved {
+		command = "ZREVRANGE"
+	}
+	values, err := redis.Values(conn.Do(command, r.keyLeaderboard(board), 0, limit-1, "WITHSCORES"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get leaderboard")
+	}
+	var pairs []struct {
+		Player string
+		Score  int64
+	}
+	if err := redis.ScanSlice(values, &pairs); err != nil {
+		return nil, errors.Wrap(err, "failed to scan leaderboard")
+	}
+	entries := make([]app.LeaderboardEntry, 0, len(pairs))
+	for i, pair := range pairs {
+		entry := app.LeaderboardEntry{Rank: i + 1}
+		switch board.Kind {
+		case app.LeaderboardSolved:
+			entry.Solved = int(pair.Score)
+		default:
+			entry.Elapsed = time.Duration(pair.Score) * time.Millisecond
+		}
+		userID, sessionID, err := r.parsePlayer(pair.Player)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if userID > 0 {
+			entry.UserID = userID
+		} else {
+			entry.Name = r.anonymousName(sessionID)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// addToLeaderboards adds the won game to the leaderboards of the puzzle, the level and the solved puzzles.
+// Only the best time of the player is kept in the leaderboards of the fastest solves.
+//
+// Errors: unknown.
+func (r *redisRepository) addToLeaderboards(ctx context.Context, conn redis.Conn, game *app.PuzzleGame) error {
+	player := r.player(game.UserID, game.SessionID)
+	anonymous := game.UserID <= 0
+	elapsed := game.Elapsed.Milliseconds()
+
+	if _, err := conn.Do("ZADD", r.keyLeaderboard(app.Leaderboard{
+		Kind:      app.LeaderboardPuzzle,
+		PuzzleID:  game.PuzzleID,
+		Anonymous: anonymous,
+	}), "LT", elapsed, player); err != nil {
+		return errors.Wrap(err, "failed to add to puzzle leaderboard")
+	}
+
+	for _, period := range app.LeaderboardPeriods {
+		key := r.keyLeaderboard(app.Leaderboard{
+			Kind:      app.LeaderboardLevel,
+			Type:      game.PuzzleType,
+			Level:     game.PuzzleLevel,
+			Period:    period,
+			At:        game.FinishedAt.Time,
+			Anonymous: anonymous,
+		})
+		if _, err := conn.Do("ZADD", key, "LT", elapsed, player); err != nil {
+			return errors.Wrapf(err, "failed to add to %s leaderboard", period)
+		}
+		if retention := period.Retention(); retention > 0 {
+			if _, err := conn.Do("EXPIRE", key, int64(retention.Seconds())); err != nil {
+				return errors.Wrapf(err, "failed to set expiration of %s leaderboard", period)
+			}
+		}
+	}
+
+	if _, err := conn.Do("ZINCRBY", r.keyLeaderboard(app.Leaderboard{
+		Kind:      app.LeaderboardSolved,
+		Anonymous: anonymous,
+	}), 1, player); err != nil {
+		return errors.Wrap(err, "failed to add to solved leaderboard")
+	}
+
+	return nil
+}
+
+// player returns the identifier of the player in leaderboards: the user or the anonymous session.
+func (r *redisRepository) player(userID, sessionID int64) string {
+	if userID > 0 {
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+	return "session:" + strconv.FormatInt(sessionID, 10)
+}
+
+// Errors: unknown.
+func (r *redisRepository) parsePlayer(player string) (userID int64, sessionID int64, err error) {
+	parts := strings.SplitN(player, ":", 2)
+	if len(parts) != 2 {
+		return 0, 0, errors.Errorf("invalid player '%s'", player)
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid player '%s'", player)
+	}
+	switch parts[0] {
+	case "user":
+		return id, 0, nil
+	case "session":
+		return 0, id, nil
+	}
+	return 0, 0, errors.Errorf("invalid player '%s'", player)
+}
+
+var uuidAnonymousSpace = uuid.MustParse("73829105-2389-4578-9236-189205738291")
+
+// anonymousName returns the pseudonym of the anonymous session that does not reveal its identifier.
+func (r *redisRepository) anonymousName(sessionID int64) string {
+	return "anonymous-" + uuid.NewSHA1(uuidAnonymousSpace, []byte(strconv.FormatInt(sessionID, 10))).String()[:8]
+}
+
+// keyLeaderboard returns a key to the leaderboard.
+// The value type is a sorted set of players by the solve time in milliseconds or by the number of solved puzzles.
+func (r *redisRepository) keyLeaderboard(board app.Leaderboard) string {
+	group := "users"
+	if board.Anonymous {
+		group = "anonymous"
+	}
+	switch board.Kind {
+	case app.LeaderboardPuzzle:
+		return fmt.Sprintf("leaderboard:%s:puzzle:%d", group, board.PuzzleID)
+	case app.LeaderboardLevel:
+		return fmt.Sprintf("leaderboard:%s:level:%s:%s:%s", group, board.Type.String(), board.Level.String(),
+			board.Period.Bucket(board.At))
+	}
+	return fmt.Sprintf("leaderboard:%s:solved", group)
+}
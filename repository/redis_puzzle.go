@@ -2,13 +2,13 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/cnblvr/puzzles/app"
 	"github.com/gomodule/redigo/redis"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
-	"strconv"
 	"time"
 )
 
@@ -24,16 +24,50 @@ func (r *redisRepository) CreateRandomPuzzleGame(ctx context.Context, params app
 		return nil, nil, errors.Errorf("params.Session is nil")
 	}
 
-	keyForRandom := r.keyPuzzleByTypeAndLevel(params.Type, params.Level)
+	var (
+		puzzleID int64
+		err      = app.ErrorPuzzlePoolEmpty
+	)
+	switch {
+	case params.Practice != nil:
+		puzzleID, err = r.randomUnsolvedPuzzleID(conn, r.keyPuzzlePracticeByType(params.Type, params.Practice.String()), params.Session.UserID)
+	case params.Symmetric:
+		puzzleID, err = r.randomUnsolvedPuzzleID(conn, r.keyPuzzleSymmetricByTypeAndLevel(params.Type, params.Level), params.Session.UserID)
+	}
+	// the puzzle of any symmetry is better than the empty pool
+	if params.Practice == nil && errors.Is(err, app.ErrorPuzzlePoolEmpty) {
+		puzzleID, err = r.randomUnsolvedPuzzleID(conn, r.keyPuzzleByTypeAndLevel(params.Type, params.Level), params.Session.UserID)
+	}
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	puzzle, err := r.getPuzzle(ctx, conn, puzzleID)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
 
-	if params.Session.UserID > 0 {
+	game := r.newPuzzleGame(params.Session, puzzle)
+	if err := r.createPuzzleGame(ctx, conn, game); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return puzzle, game, nil
+}
+
+// randomUnsolvedPuzzleID returns the random puzzle of the pool that is not solved by the user.
+//
+// Errors: app.ErrorPuzzlePoolEmpty, unknown.
+func (r *redisRepository) randomUnsolvedPuzzleID(conn redis.Conn, keyPool string, userID int64) (int64, error) {
+	keyForRandom := keyPool
+
+	if userID > 0 {
 		keyTemp := r.keyTemporary()
 		// TODO SDIFFSTORE is slow
-		if _, err := conn.Do("SDIFFSTORE", keyTemp, keyForRandom, r.keyUserSolvedPuzzles(params.Session.UserID)); err != nil {
-			return nil, nil, errors.Wrap(err, "failed to create list of unsolved puzzles for user")
+		if _, err := conn.Do("SDIFFSTORE", keyTemp, keyForRandom, r.keyUserSolvedPuzzles(userID)); err != nil {
+			return 0, errors.Wrap(err, "failed to create list of unsolved puzzles for user")
 		}
 		if _, err := conn.Do("EXPIRE", keyTemp, 10); err != nil {
-			return nil, nil, errors.Wrap(err, "failed to set expiration for list of unsolved puzzles")
+			return 0, errors.Wrap(err, "failed to set expiration for list of unsolved puzzles")
 		}
 		keyForRandom = keyTemp
 	}
@@ -41,30 +75,12 @@ func (r *redisRepository) CreateRandomPuzzleGame(ctx context.Context, params app
 	puzzleID, err := redis.Int64(conn.Do("SRANDMEMBER", keyForRandom))
 	switch err {
 	case redis.ErrNil:
-		return nil, nil, errors.WithStack(app.ErrorPuzzlePoolEmpty)
+		return 0, errors.WithStack(app.ErrorPuzzlePoolEmpty)
 	case nil:
 	default:
-		return nil, nil, errors.Wrap(err, "failed to get random puzzle id")
-	}
-	puzzle, err := r.getPuzzle(ctx, conn, puzzleID)
-	if err != nil {
-		return nil, nil, errors.WithStack(err)
+		return 0, errors.Wrap(err, "failed to get random puzzle id")
 	}
-
-	game := &app.PuzzleGame{
-		ID:        r.generatePuzzleGameID(params.Session, puzzle),
-		SessionID: params.Session.SessionID,
-		PuzzleID:  puzzleID,
-		IsNew:     true,
-	}
-	if userID := params.Session.UserID; userID > 0 {
-		game.UserID = userID
-	}
-	if err := r.setPuzzleGame(ctx, conn, game); err != nil {
-		return nil, nil, errors.WithStack(err)
-	}
-
-	return puzzle, game, nil
+	return puzzleID, nil
 }
 
 func (r *redisRepository) GetPuzzleGame(ctx context.Context, id uuid.UUID) (*app.PuzzleGame, error) {
@@ -83,10 +99,28 @@ func (r *redisRepository) UpdatePuzzleGame(ctx context.Context, game *app.Puzzle
 	conn := r.connect()
 	defer conn.Close()
 
-	if err := r.setPuzzleGame(ctx, conn, game); err != nil {
+	firstWin := game.IsWin && game.FinishedAt.IsZero()
+	if firstWin {
+		game.FinishedAt = app.DateTime{Time: time.Now()}
+	}
+	if err := r.compareAndSetPuzzleGame(ctx, conn, game); err != nil {
+		if firstWin {
+			game.FinishedAt = app.DateTime{}
+		}
 		return errors.WithStack(err)
 	}
 
+	if firstWin && game.Daily != "" && game.UserID > 0 {
+		if err := r.finishDailyPuzzleGame(ctx, conn, game); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if firstWin && game.IsRanked() {
+		if err := r.addToLeaderboards(ctx, conn, game); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
 	if game.IsWin && game.UserID > 0 {
 		if _, err := conn.Do("SADD", r.keyUserSolvedPuzzles(game.UserID), game.PuzzleID); err != nil {
 			return errors.Wrap(err, "failed to add solved puzzle")
@@ -96,6 +130,45 @@ func (r *redisRepository) UpdatePuzzleGame(ctx context.Context, game *app.Puzzle
 	return nil
 }
 
+func (r *redisRepository) GetLastPuzzleID(ctx context.Context) (int64, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	id, err := redis.Int64(conn.Do("GET", r.keyLastPuzzleID()))
+	switch err {
+	case nil:
+	case redis.ErrNil:
+		return 0, nil
+	default:
+		return 0, errors.Wrap(err, "failed to get last puzzle id")
+	}
+
+	return id, nil
+}
+
+func (r *redisRepository) SetPuzzleCanonical(ctx context.Context, puzzle *app.Puzzle) error {
+	conn := r.connect()
+	defer conn.Close()
+
+	key := r.keyPuzzleByCanonical(puzzle.Type)
+	if _, err := conn.Do("HSETNX", key, puzzle.Canonical, puzzle.ID); err != nil {
+		return errors.Wrap(err, "failed to add puzzle id by canonical form")
+	}
+	// the index of the puzzle itself is kept by a retry of the interrupted backfill
+	id, err := redis.Int64(conn.Do("HGET", key, puzzle.Canonical))
+	if err != nil {
+		return errors.Wrap(err, "failed to get puzzle id by canonical form")
+	}
+	if id != puzzle.ID {
+		return errors.WithStack(app.ErrorPuzzleDuplicate)
+	}
+	if _, err := conn.Do("HSET", r.keyPuzzle(puzzle.ID), "canonical", puzzle.Canonical); err != nil {
+		return errors.Wrap(err, "failed to set canonical form of puzzle")
+	}
+
+	return nil
+}
+
 func (r *redisRepository) GetPuzzle(ctx context.Context, id int64) (*app.Puzzle, error) {
 	conn := r.connect()
 	defer conn.Close()
@@ -151,9 +224,142 @@ func (r *redisRepository) CreatePuzzle(ctx context.Context, params app.CreatePuz
 		return nil, errors.Wrap(err, "failed to increment puzzle id")
 	}
 
-	// TODO unique app.Puzzle.Solution
+	puzzle := r.newPuzzle(id, params)
 
-	puzzle := &app.Puzzle{
+	if puzzle.Canonical != "" {
+		ok, err := redis.Bool(conn.Do("HSETNX", r.keyPuzzleByCanonical(puzzle.Type), puzzle.Canonical, puzzle.ID))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add puzzle id by canonical form")
+		}
+		if !ok {
+			return nil, errors.WithStack(app.ErrorPuzzleDuplicate)
+		}
+	}
+
+	if err := r.writePuzzles(conn, []*app.Puzzle{puzzle}); err != nil {
+		if errDel := r.releaseCanonical(conn, []*app.Puzzle{puzzle}); errDel != nil {
+			return nil, errors.Wrapf(err, "failed to create puzzle (and to release its canonical form: %v)", errDel)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return puzzle, nil
+}
+
+func (r *redisRepository) CreatePuzzles(ctx context.Context, params []app.CreatePuzzleParams) ([]*app.Puzzle, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	conn := r.connect()
+	defer conn.Close()
+
+	lastID, err := redis.Int64(conn.Do("INCRBY", r.keyLastPuzzleID(), len(params)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to increment puzzle id")
+	}
+
+	// the canonical forms are reserved for the new ids in one round trip, the ids of duplicates are not used
+	puzzles := make([]*app.Puzzle, len(params))
+	for idx, param := range params {
+		puzzles[idx] = r.newPuzzle(lastID-int64(len(params)-1-idx), param)
+		if param.Canonical == "" {
+			continue
+		}
+		if err := conn.Send("HSETNX", r.keyPuzzleByCanonical(param.Type), param.Canonical, puzzles[idx].ID); err != nil {
+			return nil, errors.Wrap(err, "failed to add puzzle id by canonical form")
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return nil, errors.Wrap(err, "failed to add puzzle ids by canonical form")
+	}
+	var created []*app.Puzzle
+	for _, puzzle := range puzzles {
+		if puzzle.Canonical == "" {
+			created = append(created, puzzle)
+			continue
+		}
+		ok, err := redis.Bool(conn.Receive())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add puzzle id by canonical form")
+		}
+		if ok {
+			created = append(created, puzzle)
+		}
+	}
+	if len(created) == 0 {
+		return nil, nil
+	}
+
+	if err := r.writePuzzles(conn, created); err != nil {
+		if errDel := r.releaseCanonical(conn, created); errDel != nil {
+			return nil, errors.Wrapf(err, "failed to create puzzles (and to release their canonical forms: %v)", errDel)
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	return created, nil
+}
+
+// writePuzzles stores the new puzzles and adds them to their pools in one transaction.
+//
+// Errors: unknown.
+func (r *redisRepository) writePuzzles(conn redis.Conn, puzzles []*app.Puzzle) error {
+	if err := conn.Send("MULTI"); err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	for _, puzzle := range puzzles {
+		if err := conn.Send("HSET", redis.Args{}.Add(r.keyPuzzle(puzzle.ID)).AddFlat(puzzle)...); err != nil {
+			return errors.Wrap(err, "failed to set puzzle")
+		}
+		for _, key := range r.keysPuzzlePools(puzzle) {
+			if err := conn.Send("SADD", key, puzzle.ID); err != nil {
+				return errors.Wrap(err, "failed to add puzzle id in list by type and level")
+			}
+		}
+	}
+	if _, err := conn.Do("EXEC"); err != nil {
+		return errors.Wrap(err, "failed to create puzzles")
+	}
+	return nil
+}
+
+// releaseCanonical removes the canonical forms reserved for the puzzles that are not stored, so the forms are not
+// taken by the ids without puzzles.
+//
+// Errors: unknown.
+func (r *redisRepository) releaseCanonical(conn redis.Conn, puzzles []*app.Puzzle) error {
+	for _, puzzle := range puzzles {
+		if puzzle.Canonical == "" {
+			continue
+		}
+		if _, err := conn.Do("HDEL", r.keyPuzzleByCanonical(puzzle.Type), puzzle.Canonical); err != nil {
+			return errors.Wrap(err, "failed to remove puzzle id by canonical form")
+		}
+	}
+	return nil
+}
+
+// keysPuzzlePools returns the keys of the pools of the new puzzle: the pool of its type and level, the pool of
+// symmetric puzzles if the clues are symmetric and the practice pool if the puzzle is generated for a strategy target.
+func (r *redisRepository) keysPuzzlePools(puzzle *app.Puzzle) []string {
+	keys := []string{r.keyPuzzleByTypeAndLevel(puzzle.Type, puzzle.Level)}
+	meta, err := app.ParsePuzzleMeta(puzzle.Meta)
+	if err != nil {
+		log.Warn().Err(err).Int64("puzzle_id", puzzle.ID).Msg("failed to parse puzzle meta")
+		return keys
+	}
+	if meta.Symmetry != app.SymmetryNone {
+		keys = append(keys, r.keyPuzzleSymmetricByTypeAndLevel(puzzle.Type, puzzle.Level))
+	}
+	if meta.Practice != "" {
+		keys = append(keys, r.keyPuzzlePracticeByType(puzzle.Type, meta.Practice))
+	}
+	return keys
+}
+
+func (r *redisRepository) newPuzzle(id int64, params app.CreatePuzzleParams) *app.Puzzle {
+	return &app.Puzzle{
 		ID:         id,
 		Type:       params.Type,
 		Seed:       params.Seed,
@@ -162,17 +368,46 @@ func (r *redisRepository) CreatePuzzle(ctx context.Context, params app.CreatePuz
 		Clues:      params.Clues,
 		Candidates: params.Candidates,
 		Solution:   params.Solution,
+		Canonical:  params.Canonical,
+	}
+}
+
+func (r *redisRepository) CreateCustomPuzzleGame(ctx context.Context, params app.CreateCustomPuzzleGameParams) (*app.Puzzle, *app.PuzzleGame, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	if params.Session == nil {
+		return nil, nil, errors.Errorf("params.Session is nil")
+	}
+
+	id, err := redis.Int64(conn.Do("INCR", r.keyLastPuzzleID()))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to increment puzzle id")
 	}
 
+	puzzle := &app.Puzzle{
+		ID:         id,
+		Type:       params.Type,
+		Level:      app.PuzzleLevelCustom,
+		Meta:       "{}",
+		Clues:      params.Clues,
+		Candidates: params.Candidates,
+		Solution:   params.Solution,
+		Canonical:  params.Canonical,
+		Rating:     params.Rating,
+		UserID:     params.Session.UserID,
+		SessionID:  params.Session.SessionID,
+	}
 	if err := r.setPuzzle(ctx, conn, puzzle); err != nil {
-		return nil, errors.WithStack(err)
+		return nil, nil, errors.WithStack(err)
 	}
 
-	if _, err := conn.Do("SADD", r.keyPuzzleByTypeAndLevel(puzzle.Type, puzzle.Level), puzzle.ID); err != nil {
-		return nil, errors.Wrap(err, "failed to add puzzle id in list by type and level")
+	game := r.newPuzzleGame(params.Session, puzzle)
+	if err := r.createPuzzleGame(ctx, conn, game); err != nil {
+		return nil, nil, errors.WithStack(err)
 	}
 
-	return puzzle, nil
+	return puzzle, game, nil
 }
 
 func (r *redisRepository) GetAmountUnsolvedPuzzlesForAllUsers(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel) (int, error) {
@@ -219,6 +454,117 @@ func (r *redisRepository) GetAmountUnsolvedPuzzlesForAllUsers(ctx context.Contex
 	return size, nil
 }
 
+func (r *redisRepository) GetPuzzlePoolSize(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel) (int, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	size, err := redis.Int(conn.Do("SCARD", r.keyPuzzleByTypeAndLevel(typ, level)))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get size of puzzle pool")
+	}
+
+	return size, nil
+}
+
+func (r *redisRepository) GetUnsolvedPuzzleCount(ctx context.Context, typ app.PuzzleType, level app.PuzzleLevel, userID int64) (int, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	if userID <= 0 {
+		size, err := redis.Int(conn.Do("SCARD", r.keyPuzzleByTypeAndLevel(typ, level)))
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to get size of puzzle pool")
+		}
+		return size, nil
+	}
+
+	keyTemp := r.keyTemporary()
+	size, err := redis.Int(conn.Do("SDIFFSTORE", keyTemp, r.keyPuzzleByTypeAndLevel(typ, level), r.keyUserSolvedPuzzles(userID)))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to create list of unsolved puzzles for user")
+	}
+	if _, err := conn.Do("DEL", keyTemp); err != nil {
+		log.Warn().Err(err).Msg("failed to delete temporary key of unsolved puzzles")
+	}
+
+	return size, nil
+}
+
+func (r *redisRepository) GetPracticePoolSize(ctx context.Context, typ app.PuzzleType, target app.StrategyTarget) (int, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	size, err := redis.Int(conn.Do("SCARD", r.keyPuzzlePracticeByType(typ, target.String())))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get size of practice pool")
+	}
+
+	return size, nil
+}
+
+func (r *redisRepository) GetUserPuzzleGames(ctx context.Context, userID int64) ([]*app.PuzzleGame, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	ids, err := redis.Strings(conn.Do("ZRANGE", r.keyUserPuzzleGames(userID), 0, -1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get user puzzle games")
+	}
+	games := make([]*app.PuzzleGame, 0, len(ids))
+	for _, idStr := range ids {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid puzzle game id '%s'", idStr)
+		}
+		game, err := r.getPuzzleGame(ctx, conn, id)
+		switch {
+		case err == nil:
+		case errors.Is(err, app.ErrorPuzzleGameNotFound):
+			continue
+		default:
+			return nil, errors.WithStack(err)
+		}
+		games = append(games, game)
+	}
+
+	return games, nil
+}
+
+func (r *redisRepository) AddPuzzleGameMove(ctx context.Context, gameID uuid.UUID, move app.PuzzleGameMove) error {
+	conn := r.connect()
+	defer conn.Close()
+
+	bts, err := json.Marshal(move)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal puzzle game move")
+	}
+	if _, err := conn.Do("RPUSH", r.keyPuzzleGameMoves(gameID), bts); err != nil {
+		return errors.Wrap(err, "failed to add puzzle game move")
+	}
+
+	return nil
+}
+
+func (r *redisRepository) GetPuzzleGameMoves(ctx context.Context, gameID uuid.UUID) ([]app.PuzzleGameMove, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	movesReply, err := redis.ByteSlices(conn.Do("LRANGE", r.keyPuzzleGameMoves(gameID), 0, -1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get puzzle game moves")
+	}
+	moves := make([]app.PuzzleGameMove, 0, len(movesReply))
+	for _, bts := range movesReply {
+		var move app.PuzzleGameMove
+		if err := json.Unmarshal(bts, &move); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal puzzle game move")
+		}
+		moves = append(moves, move)
+	}
+
+	return moves, nil
+}
+
 // Errors: app.ErrorPuzzleNotFound, unknown.
 func (r *redisRepository) getPuzzle(ctx context.Context, conn redis.Conn, id int64) (*app.Puzzle, error) {
 	if ok, err := redis.Bool(conn.Do("EXISTS", r.keyPuzzle(id))); err != nil {
@@ -277,14 +623,112 @@ func (r *redisRepository) setPuzzleGame(ctx context.Context, conn redis.Conn, pu
 	return nil
 }
 
-var uuidPuzzleGameSpace = uuid.MustParse("87234032-7832-8923-8298-237589207129")
+// newPuzzleGame returns the new game of the puzzle with a random identifier, so a new game of the same puzzle never
+// takes the state or the log of moves of the previous one.
+func (r *redisRepository) newPuzzleGame(session *app.Session, puzzle *app.Puzzle) *app.PuzzleGame {
+	game := &app.PuzzleGame{
+		ID:          uuid.New(),
+		SessionID:   session.SessionID,
+		PuzzleID:    puzzle.ID,
+		IsNew:       true,
+		PuzzleType:  puzzle.Type,
+		PuzzleLevel: puzzle.Level,
+		CreatedAt:   app.DateTime{Time: time.Now()},
+	}
+	if userID := session.UserID; userID > 0 {
+		game.UserID = userID
+	}
+	return game
+}
+
+// createPuzzleGame stores the new game and adds it to the games of the user. A log of moves left under the
+// identifier of the game is deleted, so undo, redo and replay start from the clues.
+//
+// Errors: unknown.
+func (r *redisRepository) createPuzzleGame(ctx context.Context, conn redis.Conn, game *app.PuzzleGame) error {
+	if _, err := conn.Do("DEL", r.keyPuzzleGameMoves(game.ID)); err != nil {
+		return errors.Wrap(err, "failed to delete moves of puzzle game")
+	}
+	if err := r.setPuzzleGame(ctx, conn, game); err != nil {
+		return errors.WithStack(err)
+	}
+	if game.UserID > 0 {
+		if _, err := conn.Do("ZADD", r.keyUserPuzzleGames(game.UserID), game.CreatedAt.UnixMilli(), game.ID.String()); err != nil {
+			return errors.Wrap(err, "failed to add puzzle game to user games")
+		}
+	}
+	return nil
+}
+
+// compareAndSetPuzzleGame saves the game only if the stored version is equal to game.Version and increments it.
+//
+// Errors: app.ErrorPuzzleGameConflict, unknown.
+func (r *redisRepository) compareAndSetPuzzleGame(ctx context.Context, conn redis.Conn, game *app.PuzzleGame) error {
+	key := r.keyPuzzleGame(game.ID)
+	if _, err := conn.Do("WATCH", key); err != nil {
+		return errors.Wrap(err, "failed to watch puzzle game")
+	}
+	version, err := redis.Int64(conn.Do("HGET", key, "version"))
+	switch err {
+	case nil, redis.ErrNil:
+	default:
+		conn.Do("UNWATCH")
+		return errors.Wrap(err, "failed to get puzzle game version")
+	}
+	if version != game.Version {
+		conn.Do("UNWATCH")
+		return errors.WithStack(app.ErrorPuzzleGameConflict)
+	}
+
+	game.Version++
+	if err := conn.Send("MULTI"); err != nil {
+		game.Version--
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	if err := conn.Send("HSET", redis.Args{}.Add(key).AddFlat(game)...); err != nil {
+		game.Version--
+		return errors.Wrap(err, "failed to set puzzle game")
+	}
+	reply, err := conn.Do("EXEC")
+	if err != nil {
+		game.Version--
+		return errors.Wrap(err, "failed to set puzzle game")
+	}
+	if reply == nil {
+		// the key was changed after WATCH
+		game.Version--
+		return errors.WithStack(app.ErrorPuzzleGameConflict)
+	}
+	return nil
+}
 
-func (r *redisRepository) generatePuzzleGameID(session *app.Session, puzzle *app.Puzzle) uuid.UUID {
-	return uuid.NewSHA1(uuidPuzzleGameSpace, []byte(strconv.FormatInt(session.SessionID, 10)+
-		strconv.FormatInt(session.UserID, 10)+
-		strconv.FormatInt(puzzle.ID, 10)))
+func (r *redisRepository) AddPuzzleGameMember(ctx context.Context, gameID uuid.UUID, session *app.Session) error {
+	conn := r.connect()
+	defer conn.Close()
+
+	if _, err := conn.Do("SADD", r.keyPuzzleGameMembers(gameID), r.player(session.UserID, session.SessionID)); err != nil {
+		return errors.Wrap(err, "failed to add puzzle game member")
+	}
+
+	return nil
 }
 
+func (r *redisRepository) IsPuzzleGameMember(ctx context.Context, gameID uuid.UUID, session *app.Session) (bool, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	ok, err := redis.Bool(conn.Do("SISMEMBER", r.keyPuzzleGameMembers(gameID), r.player(session.UserID, session.SessionID)))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to check puzzle game member")
+	}
+
+	return ok, nil
+}
+
+// uuidPuzzleGameSpace is the namespace of the identifiers of the daily and race games, which are the same for all
+// requests of the player.
+var uuidPuzzleGameSpace = uuid.MustParse("87234032-7832-8923-8298-237589207129")
+
 func (r *redisRepository) keyLastPuzzleID() string {
 	return "last_puzzle_id"
 }
@@ -297,10 +741,40 @@ func (r redisRepository) keyPuzzleByTypeAndLevel(typ app.PuzzleType, level app.P
 	return fmt.Sprintf("puzzle_by:%s:%s", typ.String(), level.String())
 }
 
+// keyPuzzleSymmetricByTypeAndLevel returns a key to the puzzles of the pool with symmetric clues.
+// The value type is a set of ids.
+func (r redisRepository) keyPuzzleSymmetricByTypeAndLevel(typ app.PuzzleType, level app.PuzzleLevel) string {
+	return fmt.Sprintf("puzzle_by:%s:%s:symmetric", typ.String(), level.String())
+}
+
+// keyPuzzlePracticeByType returns a key to the puzzles generated for the strategy target given by
+// app.StrategyTarget.String().
+// The value type is a set of ids.
+func (r redisRepository) keyPuzzlePracticeByType(typ app.PuzzleType, target string) string {
+	return fmt.Sprintf("puzzle_by:%s:practice:%s", typ.String(), target)
+}
+
+// keyPuzzleByCanonical returns a key to the ids of the puzzles of the type by their canonical forms. Custom puzzles
+// are not there.
+// The value type is a hash of ids.
+func (r redisRepository) keyPuzzleByCanonical(typ app.PuzzleType) string {
+	return fmt.Sprintf("puzzle_by_canonical:%s", typ.String())
+}
+
 func (r redisRepository) keyPuzzleGame(id uuid.UUID) string {
 	return fmt.Sprintf("puzzle_game:%s", id.String())
 }
 
+func (r redisRepository) keyPuzzleGameMoves(id uuid.UUID) string {
+	return fmt.Sprintf("puzzle_game:%s:moves", id.String())
+}
+
+// keyPuzzleGameMembers returns a key to the players invited to the shared game.
+// The value type is a set of players.
+func (r redisRepository) keyPuzzleGameMembers(id uuid.UUID) string {
+	return fmt.Sprintf("puzzle_game:%s:members", id.String())
+}
+
 func (r redisRepository) keyTemporary() string {
 	return fmt.Sprintf("temp:%s", uuid.New().String())
 }
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cnblvr/puzzles/app"
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"time"
+)
+
+func (r *redisRepository) CreateRace(ctx context.Context, params app.CreateRaceParams) (*app.Race, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	if params.Session == nil {
+		return nil, errors.Errorf("params.Session is nil")
+	}
+
+	puzzleID, err := redis.Int64(conn.Do("SRANDMEMBER", r.keyPuzzleByTypeAndLevel(params.Type, params.Level)))
+	switch err {
+	case redis.ErrNil:
+		return nil, errors.WithStack(app.ErrorPuzzlePoolEmpty)
+	case nil:
+	default:
+		return nil, errors.Wrap(err, "failed to get random puzzle id")
+	}
+
+	race := &app.Race{
+		ID:                uuid.New(),
+		SessionID:         params.Session.SessionID,
+		UserID:            params.Session.UserID,
+		PuzzleID:          puzzleID,
+		Type:              params.Type,
+		Level:             params.Level,
+		CandidatesAtStart: params.CandidatesAtStart,
+		CreatedAt:         app.DateTime{Time: time.Now()},
+	}
+	key := r.keyRace(race.ID)
+	if _, err := conn.Do("HSET", redis.Args{}.Add(key).AddFlat(race)...); err != nil {
+		return nil, errors.Wrap(err, "failed to set race")
+	}
+	if _, err := conn.Do("EXPIRE", key, int64(app.DefaultRaceRetention.Seconds())); err != nil {
+		return nil, errors.Wrap(err, "failed to set expiration for race")
+	}
+
+	return race, nil
+}
+
+func (r *redisRepository) GetRace(ctx context.Context, id uuid.UUID) (*app.Race, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	race, err := r.getRace(ctx, conn, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return race, nil
+}
+
+func (r *redisRepository) JoinRace(ctx context.Context, id uuid.UUID, session *app.Session, name string) (*app.PuzzleGame, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	race, err := r.getRace(ctx, conn, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	player := r.player(session.UserID, session.SessionID)
+	gameID, err := redis.String(conn.Do("HGET", r.keyRaceGames(id), player))
+	switch err {
+	case nil:
+		return r.getRacePuzzleGame(ctx, conn, gameID)
+	case redis.ErrNil:
+	default:
+		return nil, errors.Wrap(err, "failed to get race game")
+	}
+
+	if race.IsCountdown() {
+		return nil, errors.WithStack(app.ErrorRaceStarted)
+	}
+	size, err := redis.Int(conn.Do("ZCARD", r.keyRacePlayers(id)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get number of race players")
+	}
+	if size >= app.DefaultRaceMaxPlayers {
+		return nil, errors.WithStack(app.ErrorRaceFull)
+	}
+
+	puzzle, err := r.getPuzzle(ctx, conn, race.PuzzleID)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	game := r.newPuzzleGame(session, puzzle)
+	game.ID = r.generateRacePuzzleGameID(id, player)
+	game.Race = id.String()
+	game.State = puzzle.Clues
+	game.StateCandidates = "{}"
+	if race.CandidatesAtStart {
+		game.StateCandidates = puzzle.Candidates
+	}
+	game.StartCandidates = game.StateCandidates
+
+	isNew, err := redis.Bool(conn.Do("HSETNX", r.keyRaceGames(id), player, game.ID.String()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to register race game")
+	}
+	if !isNew {
+		// The player has joined from another page at the same time.
+		return r.getRacePuzzleGame(ctx, conn, game.ID.String())
+	}
+	if err := r.createPuzzleGame(ctx, conn, game); err != nil {
+		// the registration without the game would return the missing game on every next join
+		if _, errDel := conn.Do("HDEL", r.keyRaceGames(id), player); errDel != nil {
+			return nil, errors.Wrapf(err, "failed to create race game (and to unregister it: %v)", errDel)
+		}
+		return nil, errors.WithStack(err)
+	}
+	if _, err := conn.Do("ZADD", r.keyRacePlayers(id), time.Now().UnixMilli(), game.ID.String()); err != nil {
+		return nil, errors.Wrap(err, "failed to add race player")
+	}
+	if err := r.setRacePlayer(ctx, conn, id, app.RacePlayer{GameID: game.ID, Name: name}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, key := range []string{r.keyRaceGames(id), r.keyRacePlayers(id), r.keyRaceProgress(id)} {
+		if _, err := conn.Do("EXPIRE", key, int64(app.DefaultRaceRetention.Seconds())); err != nil {
+			return nil, errors.Wrap(err, "failed to set expiration for race")
+		}
+	}
+
+	return game, nil
+}
+
+func (r *redisRepository) StartRace(ctx context.Context, id uuid.UUID, at time.Time) (*app.Race, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	race, err := r.getRace(ctx, conn, id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if race.IsCountdown() {
+		return race, nil
+	}
+	race.StartAt = app.DateTime{Time: at}
+	if _, err := conn.Do("HSET", r.keyRace(id), "start_at", race.StartAt.String()); err != nil {
+		return nil, errors.Wrap(err, "failed to start race")
+	}
+
+	return race, nil
+}
+
+func (r *redisRepository) UpdateRacePlayer(ctx context.Context, id uuid.UUID, player app.RacePlayer) error {
+	conn := r.connect()
+	defer conn.Close()
+
+	if err := r.setRacePlayer(ctx, conn, id, player); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (r *redisRepository) GetRacePlayers(ctx context.Context, id uuid.UUID) ([]app.RacePlayer, error) {
+	conn := r.connect()
+	defer conn.Close()
+
+	gameIDs, err := redis.Strings(conn.Do("ZRANGE", r.keyRacePlayers(id), 0, -1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get race players")
+	}
+	if len(gameIDs) == 0 {
+		return nil, nil
+	}
+	progressReply, err := redis.ByteSlices(conn.Do("HMGET", redis.Args{}.Add(r.keyRaceProgress(id)).AddFlat(gameIDs)...))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get progress of race players")
+	}
+	players := make([]app.RacePlayer, 0, len(progressReply))
+	for i, bts := range progressReply {
+		if bts == nil {
+			continue
+		}
+		var player app.RacePlayer
+		if err := json.Unmarshal(bts, &player); err != nil {
+			return nil, errors.Wrapf(err, "failed to unmarshal progress of race player %s", gameIDs[i])
+		}
+		players = append(players, player)
+	}
+
+	return players, nil
+}
+
+// Errors: app.ErrorRaceNotFound, unknown.
+func (r *redisRepository) getRace(ctx context.Context, conn redis.Conn, id uuid.UUID) (*app.Race, error) {
+	raceReply, err := redis.Values(conn.Do("HGETALL", r.keyRace(id)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get race")
+	}
+	if len(raceReply) == 0 {
+		return nil, errors.WithStack(app.ErrorRaceNotFound)
+	}
+	race := &app.Race{}
+	if err := redis.ScanStruct(raceReply, race); err != nil {
+		return nil, errors.Wrap(err, "failed to scan race")
+	}
+	race.ID = id
+
+	return race, nil
+}
+
+// Errors: app.ErrorPuzzleGameNotFound, unknown.
+func (r *redisRepository) getRacePuzzleGame(ctx context.Context, conn redis.Conn, gameID string) (*app.PuzzleGame, error) {
+	id, err := uuid.Parse(gameID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse race game id")
+	}
+	return r.getPuzzleGame(ctx, conn, id)
+}
+
+// Errors: unknown.
+func (r *redisRepository) setRacePlayer(ctx context.Context, conn redis.Conn, id uuid.UUID, player app.RacePlayer) error {
+	bts, err := json.Marshal(player)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal race player")
+	}
+	if _, err := conn.Do("HSET", r.keyRaceProgress(id), player.GameID.String(), bts); err != nil {
+		return errors.Wrap(err, "failed to set race player")
+	}
+	return nil
+}
+
+func (r *redisRepository) generateRacePuzzleGameID(id uuid.UUID, player string) uuid.UUID {
+	return uuid.NewSHA1(uuidPuzzleGameSpace, []byte("race:"+id.String()+":"+player))
+}
+
+// keyRace returns a key to the race.
+func (r *redisRepository) keyRace(id uuid.UUID) string {
+	return fmt.Sprintf("race:%s", id.String())
+}
+
+// keyRaceGames returns a key to the games of the race.
+// The value type is a hash of game identifiers by players.
+func (r *redisRepository) keyRaceGames(id uuid.UUID) string {
+	return fmt.Sprintf("%s:games", r.keyRace(id))
+}
+
+// keyRacePlayers returns a key to the order of joining the race.
+// The value type is a sorted set of game identifiers by the time of joining in milliseconds.
+func (r *redisRepository) keyRacePlayers(id uuid.UUID) string {
+	return fmt.Sprintf("%s:players", r.keyRace(id))
+}
+
+// keyRaceProgress returns a key to the progress of the players of the race.
+// The value type is a hash of JSON encoded app.RacePlayer by game identifiers.
+func (r *redisRepository) keyRaceProgress(id uuid.UUID) string {
+	return fmt.Sprintf("%s:progress", r.keyRace(id))
+}
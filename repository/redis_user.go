@@ -317,6 +317,18 @@ func (r *redisRepository) keyUserSolvedPuzzles(id int64) string {
 	return fmt.Sprintf("%s:solved_puzzles", r.keyUser(id))
 }
 
+// keyUserPuzzleGames returns a key to the games of the user.
+// The value type is a sorted set of game identifiers by creation time in milliseconds.
+func (r *redisRepository) keyUserPuzzleGames(id int64) string {
+	return fmt.Sprintf("%s:puzzle_games", r.keyUser(id))
+}
+
+// keyUserDrillStats returns a key to the accuracy of the user in the drills.
+// The value type is a hash of counters by "<strategy>:attempts" and "<strategy>:correct" fields.
+func (r *redisRepository) keyUserDrillStats(id int64) string {
+	return fmt.Sprintf("%s:drill_stats", r.keyUser(id))
+}
+
 func (r *redisRepository) keyUserPreferences(id int64) string {
 	return fmt.Sprintf("%s:preferences", r.keyUser(id))
 }